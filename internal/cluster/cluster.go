@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"path"
@@ -19,6 +20,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/jetstack/jsctl/internal/client"
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
 )
 
 type (
@@ -112,6 +114,7 @@ type ApplyAgentYAMLOptions struct {
 	Name           string          // The name of the cluster
 	ServiceAccount *ServiceAccount // The authentication credentials for the agent to use
 	ImageRegistry  string          // The image registry for the agent image
+	Patches        []*patch.Patch  // Patches to apply to the generated resources
 }
 
 // ApplyAgentYAML generates all Kubernetes YAML required for an agent installation and returns it within an
@@ -139,7 +142,12 @@ func ApplyAgentYAML(ctx context.Context, applier Applier, options ApplyAgentYAML
 		return err
 	}
 
-	return applier.Apply(ctx, buf)
+	patched, err := patch.ApplyYAML(buf, options.Patches)
+	if err != nil {
+		return fmt.Errorf("error patching agent manifests: %w", err)
+	}
+
+	return applier.Apply(ctx, patched)
 }
 
 func marshalBase64(in interface{}) ([]byte, error) {
@@ -0,0 +1,24 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/certificaterequests"
+)
+
+// CertificateRequests returns a cobra.Command instance that is the root for all "jsctl certificaterequests" subcommands.
+func CertificateRequests() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "certificaterequests",
+		Aliases: []string{"certificaterequest", "cr"},
+		Short:   "Subcommands for reviewing cert-manager CertificateRequests in the current cluster",
+	}
+
+	cmd.AddCommand(
+		certificaterequests.List(run, &kubeConfig),
+		certificaterequests.Approve(run, &kubeConfig),
+		certificaterequests.Deny(run, &kubeConfig),
+	)
+
+	return cmd
+}
@@ -0,0 +1,256 @@
+package certificaterequests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+// requestSummary describes a CertificateRequest along with the CertificateRequestPolicies that apply to it.
+type requestSummary struct {
+	Namespace          string                          `json:"namespace"`
+	Name               string                          `json:"name"`
+	IssuerKind         string                          `json:"issuerKind"`
+	IssuerName         string                          `json:"issuerName"`
+	Username           string                          `json:"username"`
+	State              string                          `json:"state"`
+	Created            time.Time                       `json:"created"`
+	ApplicablePolicies []string                        `json:"applicablePolicies,omitempty"`
+	PolicyEvaluations  []certificates.PolicyEvaluation `json:"policyEvaluations,omitempty"`
+}
+
+// List returns a cobra.Command instance that lists CertificateRequests and how approver-policy reviewed them.
+func List(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		namespace    string
+		outputFormat string
+		pending      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List CertificateRequests along with whether they are approved and the policies that apply to them",
+		Long: `Lists cert-manager CertificateRequests along with whether they have been approved or denied. For requests
+denied by approver-policy, the CertificateRequestPolicies that were evaluated are shown along with why they did not
+approve the request. For pending requests, the policies whose issuerRef selector matches the request are shown, as
+approver-policy only evaluates those that are also ready and bound to the requester by RBAC.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "json":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, json", outputFormat)
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			requestClient, err := clients.NewCertificateRequestClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("failed to create certificate request client: %w", err)
+			}
+
+			var requests cmapi.CertificateRequestList
+			if err := requestClient.List(ctx, &clients.GenericRequestOptions{Namespace: namespace}, &requests); err != nil {
+				return fmt.Errorf("failed to list certificate requests: %w", err)
+			}
+
+			policyClient, err := clients.NewCertificateRequestPolicyClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("failed to create certificate request policy client: %w", err)
+			}
+
+			// approver-policy may not be installed, in which case no policies apply
+			var policies policyapi.CertificateRequestPolicyList
+			err = policyClient.List(ctx, &clients.GenericRequestOptions{}, &policies)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to list certificate request policies: %w", err)
+			}
+
+			summaries := make([]requestSummary, 0, len(requests.Items))
+			for _, request := range requests.Items {
+				summary := summarizeRequest(request, policies.Items)
+				if pending && summary.State != certificates.RequestPending {
+					continue
+				}
+				summaries = append(summaries, summary)
+			}
+
+			if outputFormat == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(summaries)
+			}
+
+			tbl := table.NewBuilder([]string{
+				"NAMESPACE",
+				"NAME",
+				"ISSUER",
+				"REQUESTER",
+				"STATE",
+				"AGE",
+				"POLICIES",
+			})
+
+			for _, summary := range summaries {
+				policyNames := summary.ApplicablePolicies
+				if len(summary.PolicyEvaluations) > 0 {
+					policyNames = nil
+					for _, evaluation := range summary.PolicyEvaluations {
+						policyNames = append(policyNames, evaluation.Policy)
+					}
+				}
+
+				tbl.AddRow(
+					summary.Namespace,
+					summary.Name,
+					summary.IssuerKind+"/"+summary.IssuerName,
+					summary.Username,
+					summary.State,
+					duration.HumanDuration(time.Since(summary.Created)),
+					strings.Join(policyNames, ","),
+				)
+			}
+
+			if err := tbl.Build(os.Stdout); err != nil {
+				return err
+			}
+
+			printPolicyDetails(summaries)
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "Only list CertificateRequests in this namespace")
+	flags.BoolVar(&pending, "pending", false, "Only list CertificateRequests that have been neither approved nor denied")
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, json")
+
+	return cmd
+}
+
+func summarizeRequest(request cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) requestSummary {
+	summary := requestSummary{
+		Namespace:  request.Namespace,
+		Name:       request.Name,
+		IssuerKind: request.Spec.IssuerRef.Kind,
+		IssuerName: request.Spec.IssuerRef.Name,
+		Username:   request.Spec.Username,
+		State:      certificates.RequestState(request),
+		Created:    request.CreationTimestamp.Time,
+	}
+	if summary.IssuerKind == "" {
+		summary.IssuerKind = cmapi.IssuerKind
+	}
+
+	switch summary.State {
+	case certificates.RequestPending:
+		summary.ApplicablePolicies = certificates.ApplicablePolicies(request, policies)
+	case certificates.RequestDenied:
+		summary.PolicyEvaluations = certificates.PolicyEvaluations(request)
+	}
+
+	return summary
+}
+
+// printPolicyDetails explains why each request that is not approved has not been approved by approver-policy.
+func printPolicyDetails(summaries []requestSummary) {
+	first := true
+	for _, summary := range summaries {
+		var lines []string
+		switch {
+		case len(summary.PolicyEvaluations) > 0:
+			for _, evaluation := range summary.PolicyEvaluations {
+				lines = append(lines, fmt.Sprintf("policy %s did not approve: %s", evaluation.Policy, evaluation.Message))
+			}
+		case summary.State == certificates.RequestPending && len(summary.ApplicablePolicies) == 0:
+			lines = append(lines, "no policy selects this request's issuer")
+		case summary.State == certificates.RequestPending:
+			for _, policy := range summary.ApplicablePolicies {
+				lines = append(lines, fmt.Sprintf("policy %s selects this request's issuer but was not evaluated, check that it is ready and bound to %s", policy, summary.Username))
+			}
+		default:
+			continue
+		}
+
+		if first {
+			fmt.Println("\nPolicy evaluations:")
+			first = false
+		}
+		fmt.Printf("%s/%s:\n", summary.Namespace, summary.Name)
+		for _, line := range lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// Approve returns a cobra.Command instance that approves a pending CertificateRequest.
+func Approve(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	return decide(run, kubeConfig, "approve", certificates.RequestApproved, certificates.ApproveRequest)
+}
+
+// Deny returns a cobra.Command instance that denies a pending CertificateRequest.
+func Deny(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	return decide(run, kubeConfig, "deny", certificates.RequestDenied, certificates.DenyRequest)
+}
+
+type decideFunc func(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], namespace, name, message string, now time.Time) error
+
+// decide returns a command that sets the condition of a pending CertificateRequest to approve or deny it, the
+// condition type being the past tense of the verb.
+func decide(run types.RunFunc, kubeConfig *string, verb, condition string, decideRequest decideFunc) *cobra.Command {
+	var (
+		namespace string
+		reason    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   verb + " name",
+		Short: fmt.Sprintf("Manually %s a pending CertificateRequest", verb),
+		Long: fmt.Sprintf(`Sets the %s condition of a pending CertificateRequest, with --reason as its message. cert-manager only
+allows this for users permitted to 'approve' the 'signers' resource of the request's issuer, and a request cannot be
+approved or denied once either has happened.`, condition),
+		Args: cobra.ExactArgs(1),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			client, err := clients.NewCertificateRequestClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("failed to create certificate request client: %w", err)
+			}
+
+			if err := decideRequest(ctx, client, namespace, args[0], reason, time.Now()); err != nil {
+				return fmt.Errorf("failed to %s certificate request: %w", verb, err)
+			}
+
+			fmt.Fprintf(os.Stderr, "%s certificate request %s/%s\n", condition, namespace, args[0])
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "default", "The namespace of the CertificateRequest")
+	flags.StringVar(&reason, "reason", condition+" manually with jsctl", fmt.Sprintf("Why the CertificateRequest is being %s, recorded on its condition", strings.ToLower(condition)))
+
+	return cmd
+}
@@ -0,0 +1,23 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/certificates"
+)
+
+// Certificates returns a cobra.Command instance that is the root for all "jsctl certificates" subcommands.
+func Certificates() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "certificates",
+		Aliases: []string{"certificate", "certs"},
+		Short:   "Subcommands for inspecting cert-manager Certificates in the current cluster",
+	}
+
+	cmd.AddCommand(
+		certificates.Report(run, &kubeConfig),
+		certificates.Renew(run, &kubeConfig),
+	)
+
+	return cmd
+}
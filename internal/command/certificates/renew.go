@@ -0,0 +1,214 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// waitInterval is how often Certificates are checked while waiting for them to become ready.
+const waitInterval = 2 * time.Second
+
+// Renew returns a cobra.Command instance that triggers the renewal of Certificates in the current cluster.
+func Renew(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		namespace   string
+		selector    string
+		all         bool
+		issuer      string
+		wait        bool
+		timeout     time.Duration
+		concurrency int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "renew [name...]",
+		Short: "Trigger the renewal of cert-manager Certificates",
+		Long: `Marks Certificates for renewal by setting their Issuing condition, in the same way as 'cmctl renew'.
+Certificates are chosen by name, by a label selector with --selector, or with --all. Names are looked up in --namespace,
+or the default namespace if it is not set, while --selector and --all span all namespaces unless --namespace is set.
+The chosen Certificates can be narrowed down to those using an issuer with --issuer, which takes the name of an issuer
+or its kind and name, such as 'ClusterIssuer/foo'. Kinds are taken to be in the cert-manager.io group unless qualified
+with their group, such as 'AWSPCAClusterIssuer.awspca.cert-manager.io/foo'.
+
+Certificates that are already being issued are skipped. With --wait, each Certificate is waited on until it is ready
+again.`,
+		Example: `  jsctl certificates renew --all --issuer ClusterIssuer/venafi --wait
+  jsctl certificates renew -n foo -l app=bar
+  jsctl certificates renew -n foo example-com`,
+		Args: cobra.ArbitraryArgs,
+		Run: run(func(ctx context.Context, args []string) error {
+			switch {
+			case len(args) > 0 && (selector != "" || all):
+				return errors.New("certificate names cannot be used with --selector or --all")
+			case selector != "" && all:
+				return errors.New("only one of --selector and --all can be used")
+			case len(args) == 0 && selector == "" && !all:
+				return errors.New("certificate names, --selector or --all must be provided")
+			case concurrency < 1:
+				return errors.New("--concurrency must be at least 1")
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			client, err := clients.NewCertificateClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("failed to create certificate client: %w", err)
+			}
+
+			var targets []cmapi.Certificate
+			if len(args) > 0 {
+				if namespace == "" {
+					namespace = "default"
+				}
+
+				for _, name := range args {
+					var certificate cmapi.Certificate
+					err := client.Get(ctx, &clients.GenericRequestOptions{Namespace: namespace, Name: name}, &certificate)
+					if err != nil {
+						return fmt.Errorf("failed to get certificate %s/%s: %w", namespace, name, err)
+					}
+					targets = append(targets, certificate)
+				}
+			} else {
+				var certificateList cmapi.CertificateList
+				err := client.List(ctx, &clients.GenericRequestOptions{Namespace: namespace, LabelSelector: selector}, &certificateList)
+				if err != nil {
+					return fmt.Errorf("failed to list certificates: %w", err)
+				}
+				targets = certificateList.Items
+			}
+
+			if issuer != "" {
+				targets = filterByIssuer(targets, issuer)
+			}
+			if len(targets) == 0 {
+				fmt.Fprintln(os.Stderr, "No certificates to renew")
+				return nil
+			}
+
+			failed := renewCertificates(ctx, client, targets, wait, timeout, concurrency)
+			if failed > 0 {
+				return fmt.Errorf("failed to renew %d of %d certificates", failed, len(targets))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "The namespace of the certificates to renew")
+	flags.StringVarP(&selector, "selector", "l", "", "Renew certificates matching this label selector")
+	flags.BoolVar(&all, "all", false, "Renew all certificates")
+	flags.StringVar(&issuer, "issuer", "", "Only renew certificates using this issuer, given as a name, kind/name or kind.group/name")
+	flags.BoolVar(&wait, "wait", false, "Wait for each certificate to be ready after renewal")
+	flags.DurationVar(&timeout, "timeout", 10*time.Minute, "How long to wait for each certificate to be ready when using --wait")
+	flags.IntVar(&concurrency, "concurrency", 5, "The number of certificates to renew at once")
+
+	return cmd
+}
+
+// filterByIssuer returns the Certificates that use the issuer, given as a name or as kind/name, where the kind can
+// be qualified with its API group as kind.group/name. Certificates without an issuer kind use an Issuer and those
+// without a group use cert-manager.io, as cert-manager defaults them, and a kind given without a group is also taken
+// to be in cert-manager.io. A name on its own matches issuers of any kind.
+func filterByIssuer(targets []cmapi.Certificate, issuer string) []cmapi.Certificate {
+	kind, name, hasKind := strings.Cut(issuer, "/")
+	if !hasKind {
+		kind, name = "", issuer
+	}
+	kind, group, hasGroup := strings.Cut(kind, ".")
+	if !hasGroup {
+		group = cmapi.SchemeGroupVersion.Group
+	}
+
+	var filtered []cmapi.Certificate
+	for _, certificate := range targets {
+		ref := certificate.Spec.IssuerRef
+		refKind := ref.Kind
+		if refKind == "" {
+			refKind = cmapi.IssuerKind
+		}
+		refGroup := ref.Group
+		if refGroup == "" {
+			refGroup = cmapi.SchemeGroupVersion.Group
+		}
+
+		if ref.Name == name && (kind == "" || strings.EqualFold(kind, refKind) && strings.EqualFold(group, refGroup)) {
+			filtered = append(filtered, certificate)
+		}
+	}
+
+	return filtered
+}
+
+// renewCertificates renews the Certificates, running up to concurrency renewals at once, and prints the result for
+// each Certificate as it completes. The number of Certificates that could not be renewed is returned.
+func renewCertificates(ctx context.Context, client clients.Generic[*cmapi.Certificate, *cmapi.CertificateList], targets []cmapi.Certificate, wait bool, timeout time.Duration, concurrency int) int {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed int
+	)
+
+	semaphore := make(chan struct{}, concurrency)
+	for _, certificate := range targets {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(namespace, name string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result, err := renewCertificate(ctx, client, namespace, name, wait, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				fmt.Printf("%s/%s: error: %s\n", namespace, name, err)
+				return
+			}
+			fmt.Printf("%s/%s: %s\n", namespace, name, result)
+		}(certificate.Namespace, certificate.Name)
+	}
+
+	wg.Wait()
+	return failed
+}
+
+func renewCertificate(ctx context.Context, client clients.Generic[*cmapi.Certificate, *cmapi.CertificateList], namespace, name string, wait bool, timeout time.Duration) (string, error) {
+	err := certificates.Renew(ctx, client, namespace, name, time.Now())
+	switch {
+	case errors.Is(err, certificates.ErrAlreadyIssuing):
+		return "already being issued, skipped", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to trigger renewal: %w", err)
+	case !wait:
+		return "renewal triggered", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := certificates.WaitForReady(ctx, client, namespace, name, waitInterval); err != nil {
+		return "", fmt.Errorf("renewal triggered but certificate did not become ready: %w", err)
+	}
+
+	return "renewed and ready", nil
+}
@@ -0,0 +1,47 @@
+package certificates
+
+import (
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilterByIssuer(t *testing.T) {
+	certificate := func(name string, ref cmmeta.ObjectReference) cmapi.Certificate {
+		return cmapi.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       cmapi.CertificateSpec{IssuerRef: ref},
+		}
+	}
+	targets := []cmapi.Certificate{
+		certificate("default", cmmeta.ObjectReference{Name: "foo"}),
+		certificate("issuer", cmmeta.ObjectReference{Name: "foo", Kind: "Issuer", Group: "cert-manager.io"}),
+		certificate("cluster-issuer", cmmeta.ObjectReference{Name: "foo", Kind: "ClusterIssuer"}),
+		certificate("external", cmmeta.ObjectReference{Name: "foo", Kind: "ClusterIssuer", Group: "example.com"}),
+		certificate("other", cmmeta.ObjectReference{Name: "bar", Kind: "ClusterIssuer"}),
+	}
+
+	tests := map[string]struct {
+		issuer   string
+		expected []string
+	}{
+		"name":                {issuer: "foo", expected: []string{"default", "issuer", "cluster-issuer", "external"}},
+		"kind and name":       {issuer: "clusterissuer/foo", expected: []string{"cluster-issuer"}},
+		"default kind":        {issuer: "Issuer/foo", expected: []string{"default", "issuer"}},
+		"group":               {issuer: "ClusterIssuer.example.com/foo", expected: []string{"external"}},
+		"cert-manager group":  {issuer: "ClusterIssuer.cert-manager.io/foo", expected: []string{"cluster-issuer"}},
+		"no matching issuers": {issuer: "Issuer.example.com/foo"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var names []string
+			for _, certificate := range filterByIssuer(targets, test.issuer) {
+				names = append(names, certificate.Name)
+			}
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package certificates
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+const (
+	groupByNone      = "none"
+	groupByNamespace = "namespace"
+	groupByIssuer    = "issuer"
+)
+
+type reportGroup struct {
+	Group        string                           `json:"group"`
+	Certificates []certificates.CertificateReport `json:"certificates"`
+}
+
+// Report returns a cobra.Command instance that reports on all Certificates in the current cluster.
+func Report(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		outputFormat string
+		groupBy      string
+		opts         certificates.ReportOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report on the Certificates in the current cluster and the X.509 certificates they have been issued",
+		Long: `Lists all cert-manager Certificates in the current cluster along with the key algorithm and size, subject
+alternative names and validity of the certificate chain read from each Certificate's Secret. Certificates that are not
+ready, are failing issuance, are due for renewal or expiry within the configured windows, or have a chain that cannot be
+verified are reported as having problems. Chains are verified against the Secret's ca.crt, or the system roots if
+there is none.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "csv", "json", "markdown":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, csv, json, markdown", outputFormat)
+			}
+			switch groupBy {
+			case groupByNone, groupByNamespace, groupByIssuer:
+			default:
+				return fmt.Errorf("invalid grouping %q, must be one of: none, namespace, issuer", groupBy)
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			reports, err := certificates.FetchReport(ctx, kubeCfg, opts, now)
+			if err != nil {
+				return err
+			}
+
+			groups := groupReports(reports, groupBy)
+
+			switch outputFormat {
+			case "csv":
+				return writeCSV(os.Stdout, groups, groupBy != groupByNone)
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if groupBy == groupByNone {
+					return encoder.Encode(reports)
+				}
+				return encoder.Encode(groups)
+			case "markdown":
+				return writeMarkdown(os.Stdout, groups, reports, now)
+			default:
+				return writeTable(os.Stdout, groups, now)
+			}
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, csv, json, markdown")
+	flags.StringVar(&groupBy, "group-by", groupByNone, "Group certificates by one of: none, namespace, issuer")
+	flags.DurationVar(&opts.RenewalWindow, "renewal-window", 7*24*time.Hour, "Report certificates due for renewal within this duration")
+	flags.DurationVar(&opts.ExpiryWindow, "expiry-window", 30*24*time.Hour, "Report certificates expiring within this duration")
+
+	return cmd
+}
+
+// groupReports groups the reports by namespace or issuer, groups are sorted by name and keep the order of the
+// reports within them. All reports are placed in a single unnamed group if they are not grouped.
+func groupReports(reports []certificates.CertificateReport, groupBy string) []reportGroup {
+	if groupBy == groupByNone {
+		return []reportGroup{{Certificates: reports}}
+	}
+
+	index := make(map[string]int)
+	var groups []reportGroup
+	for _, report := range reports {
+		name := report.Namespace
+		if groupBy == groupByIssuer {
+			name = issuerName(report)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, reportGroup{Group: name})
+		}
+		groups[i].Certificates = append(groups[i].Certificates, report)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Group < groups[j].Group
+	})
+
+	return groups
+}
+
+// issuerName returns the kind and name of the issuer of the Certificate, including its namespace for namespaced
+// issuers as issuers in different namespaces may share a name.
+func issuerName(report certificates.CertificateReport) string {
+	for _, issuer := range clients.AllIssuersList {
+		if issuer.Kind() == report.IssuerKind && issuer.ClusterScoped() {
+			return report.IssuerKind + "/" + report.IssuerName
+		}
+	}
+
+	return report.IssuerKind + "/" + report.Namespace + "/" + report.IssuerName
+}
+
+func writeTable(w io.Writer, groups []reportGroup, now time.Time) error {
+	for i, group := range groups {
+		if group.Group != "" {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", group.Group)
+		}
+
+		tbl := table.NewBuilder([]string{
+			"NAMESPACE",
+			"NAME",
+			"ISSUER",
+			"READY",
+			"EXPIRES",
+			"KEY",
+			"CHAIN",
+			"PROBLEMS",
+		})
+
+		for _, report := range group.Certificates {
+			tbl.AddRow(
+				report.Namespace,
+				report.Name,
+				report.IssuerKind+"/"+report.IssuerName,
+				report.Ready,
+				expiry(report, now),
+				keyDescription(report),
+				chainDescription(report),
+				strings.Join(report.Problems, ","),
+			)
+		}
+
+		if err := tbl.Build(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, groups []reportGroup, grouped bool) error {
+	header := []string{
+		"namespace",
+		"name",
+		"issuer_kind",
+		"issuer_name",
+		"secret_name",
+		"ready",
+		"not_before",
+		"not_after",
+		"renewal_time",
+		"subject",
+		"dns_names",
+		"ip_addresses",
+		"uris",
+		"email_addresses",
+		"key_algorithm",
+		"key_size",
+		"chain_valid",
+		"chain_error",
+		"problems",
+	}
+	if grouped {
+		header = append([]string{"group"}, header...)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		for _, report := range group.Certificates {
+			record := []string{
+				report.Namespace,
+				report.Name,
+				report.IssuerKind,
+				report.IssuerName,
+				report.SecretName,
+				report.Ready,
+				formatTime(report.NotBefore),
+				formatTime(report.NotAfter),
+				formatTime(report.RenewalTime),
+				report.Subject,
+				strings.Join(report.DNSNames, " "),
+				strings.Join(report.IPAddresses, " "),
+				strings.Join(report.URIs, " "),
+				strings.Join(report.EmailAddress, " "),
+				report.KeyAlgorithm,
+				strconv.Itoa(report.KeySize),
+				strconv.FormatBool(report.ChainValid),
+				report.ChainError,
+				strings.Join(report.Problems, " "),
+			}
+			if grouped {
+				record = append([]string{group.Group}, record...)
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeMarkdown(w io.Writer, groups []reportGroup, reports []certificates.CertificateReport, now time.Time) error {
+	problems := make(map[string]int)
+	withProblems := 0
+	for _, report := range reports {
+		if len(report.Problems) > 0 {
+			withProblems++
+		}
+		for _, problem := range report.Problems {
+			problems[problem]++
+		}
+	}
+
+	fmt.Fprintf(w, "# Certificate report\n\n")
+	fmt.Fprintf(w, "Generated at %s.\n\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "- Certificates: %d\n", len(reports))
+	fmt.Fprintf(w, "- Certificates with problems: %d\n", withProblems)
+
+	names := make([]string, 0, len(problems))
+	for name := range problems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  - %s: %d\n", name, problems[name])
+	}
+
+	for _, group := range groups {
+		fmt.Fprintln(w)
+		if group.Group != "" {
+			fmt.Fprintf(w, "## %s\n\n", markdownEscape(group.Group))
+		}
+
+		fmt.Fprintln(w, "| Namespace | Name | Issuer | Ready | Not After | Key | Subject Alternative Names | Chain | Problems |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- | --- | --- | --- | --- | --- |")
+		for _, report := range group.Certificates {
+			var sans []string
+			sans = append(sans, report.DNSNames...)
+			sans = append(sans, report.IPAddresses...)
+			sans = append(sans, report.URIs...)
+			sans = append(sans, report.EmailAddress...)
+
+			cells := []string{
+				report.Namespace,
+				report.Name,
+				report.IssuerKind + "/" + report.IssuerName,
+				report.Ready,
+				formatTime(report.NotAfter),
+				keyDescription(report),
+				strings.Join(sans, ", "),
+				chainDescription(report),
+				strings.Join(report.Problems, ", "),
+			}
+			for i := range cells {
+				cells[i] = markdownEscape(cells[i])
+			}
+
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+
+	return nil
+}
+
+func expiry(report certificates.CertificateReport, now time.Time) string {
+	if report.NotAfter == nil {
+		return ""
+	}
+	if report.NotAfter.Before(now) {
+		return "expired"
+	}
+
+	return report.NotAfter.Sub(now).Round(time.Hour).String()
+}
+
+func keyDescription(report certificates.CertificateReport) string {
+	if report.KeySize == 0 {
+		return report.KeyAlgorithm
+	}
+
+	return fmt.Sprintf("%s %d", report.KeyAlgorithm, report.KeySize)
+}
+
+func chainDescription(report certificates.CertificateReport) string {
+	switch {
+	case report.ChainValid:
+		return "valid"
+	case report.KeyAlgorithm == "":
+		return "missing"
+	default:
+		return "invalid"
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func markdownEscape(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,65 @@
+package certificates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
+)
+
+func TestGroupReports(t *testing.T) {
+	reports := []certificates.CertificateReport{
+		{Namespace: "foo", Name: "a", IssuerKind: "ClusterIssuer", IssuerName: "ca"},
+		{Namespace: "foo", Name: "b", IssuerKind: "Issuer", IssuerName: "ca"},
+		{Namespace: "bar", Name: "c", IssuerKind: "ClusterIssuer", IssuerName: "ca"},
+	}
+
+	byNamespace := groupReports(reports, groupByNamespace)
+	require.Len(t, byNamespace, 2)
+	assert.Equal(t, "bar", byNamespace[0].Group)
+	assert.Equal(t, []certificates.CertificateReport{reports[2]}, byNamespace[0].Certificates)
+	assert.Equal(t, "foo", byNamespace[1].Group)
+	assert.Equal(t, []certificates.CertificateReport{reports[0], reports[1]}, byNamespace[1].Certificates)
+
+	byIssuer := groupReports(reports, groupByIssuer)
+	require.Len(t, byIssuer, 2)
+	assert.Equal(t, "ClusterIssuer/ca", byIssuer[0].Group)
+	assert.Equal(t, []certificates.CertificateReport{reports[0], reports[2]}, byIssuer[0].Certificates)
+	assert.Equal(t, "Issuer/foo/ca", byIssuer[1].Group)
+
+	assert.Equal(t, []reportGroup{{Certificates: reports}}, groupReports(reports, groupByNone))
+}
+
+func TestWriteCSV(t *testing.T) {
+	groups := []reportGroup{
+		{
+			Group: "foo",
+			Certificates: []certificates.CertificateReport{
+				{
+					Namespace:    "foo",
+					Name:         "a",
+					IssuerKind:   "Issuer",
+					IssuerName:   "ca",
+					SecretName:   "a-tls",
+					Ready:        "True",
+					Subject:      "CN=a,O=Example, Inc.",
+					DNSNames:     []string{"a.example.com", "www.a.example.com"},
+					KeyAlgorithm: "RSA",
+					KeySize:      2048,
+					ChainValid:   true,
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeCSV(&out, groups, true))
+	assert.Equal(t,
+		"group,namespace,name,issuer_kind,issuer_name,secret_name,ready,not_before,not_after,renewal_time,subject,dns_names,ip_addresses,uris,email_addresses,key_algorithm,key_size,chain_valid,chain_error,problems\n"+
+			"foo,foo,a,Issuer,ca,a-tls,True,,,,\"CN=a,O=Example, Inc.\",a.example.com www.a.example.com,,,,RSA,2048,true,,\n",
+		out.String(),
+	)
+}
@@ -3,29 +3,112 @@ package clusters
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/kubernetes"
 	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/backup/sink"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 )
 
 func Backup(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 	var formatResources bool
 	var outputFormat string
+	var pageSize int64
 
 	var includeCertificates bool
 	var includeIssuers bool
 	var includeCertificateRequestPolicies bool
+	var includeShimIntent bool
+
+	var namespaces []string
+	var excludeNamespaces []string
+	var selector string
+
+	var includeSecrets bool
+	var secretsPassphraseFile string
+	var unencryptedSecrets bool
+	var clusterResourceNamespace string
+
+	var destination string
+	var clusterName string
+	var keepLast int
+	var keepDays int
 
 	cmd := &cobra.Command{
 		Use:   "backup",
 		Short: "This command outputs the YAML data of Jetstack Secure relevant resources in the cluster",
-		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		Long: `This command outputs the YAML data of Jetstack Secure relevant resources in the cluster.
+
+With --include-secrets, the Secrets of the backed up Certificates and CA issuers are included too. Their data is
+encrypted with a key derived from the passphrase in --secrets-passphrase-file, and they are stored as EncryptedSecret
+resources which cannot be applied to a cluster until they have been decrypted with 'decrypt-backup'. The 'restore'
+command decrypts them itself. Secrets are only stored unencrypted if --unencrypted-secrets is set.
+
+Certificates created by ingress-shim and gateway-shim for annotated Ingresses and Gateways are not backed up. With
+--include-shim-intent, the cert-manager annotations and TLS configuration of those Ingresses and Gateways are stored as
+ShimIntent resources instead. The 'restore' command applies them to the Ingresses and Gateways once they have been
+recreated, and cert-manager then recreates the Certificates.
+
+Backups can be limited to some namespaces with --namespace and --exclude-namespace, and to resources with matching
+labels with --selector. Issuers referenced by the backed up certificates are always included, even if they do not
+match the selector, but when the backup is limited to some namespaces other cluster scoped resources are not, nor are
+Secrets outside of those namespaces.
+
+With --destination, the backup is stored rather than written to stdout, which is suited to running it on a schedule.
+The destination is a local directory, file:///path, an S3 compatible bucket, s3://bucket/prefix, or a Google Cloud
+Storage bucket, gs://bucket/prefix. S3 and GCS destinations accept an endpoint query parameter to use a compatible
+service such as MinIO, and S3 destinations accept a region. S3 credentials are found by the default AWS credential
+chain, such as AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, a shared credentials file or the role of the instance or
+pod, and GCS credentials are found as Application Default Credentials, such as GOOGLE_APPLICATION_CREDENTIALS or the
+service account of the instance or pod.
+
+Stored backups are compressed with gzip and streamed to the destination as they are written, and are named after
+--cluster-name and the time they were made, for example prod/prod-20230203T040506Z.yaml.gz. They are accompanied by a
+manifest of the same name containing their checksums, which is only stored once the backup is complete. Older backups
+of the cluster are then deleted according to --keep-last and --keep-days.
+
+Certificates are listed in pages of --page-size, and resources are written as they are fetched rather than once the
+whole backup has been fetched, so that clusters with many certificates can be backed up without running out of
+memory or having requests time out. Progress is reported on stderr. If the backup fails part way through, the output
+is incomplete, and a non-zero exit code is returned.`,
+		Example: `  jsctl experimental clusters backup > backup.yaml
+  jsctl experimental clusters backup --include-secrets --secrets-passphrase-file passphrase.txt > backup.yaml
+  jsctl experimental clusters backup --namespace team-a --namespace team-b --selector app.kubernetes.io/part-of=payments > backup.yaml
+  jsctl experimental clusters backup --destination s3://backups/clusters --cluster-name prod --keep-days 30`,
+		Args: cobra.MatchAll(cobra.ExactArgs(0)),
 		Run: run(func(ctx context.Context, args []string) error {
+			if destination != "" && clusterName == "" {
+				return fmt.Errorf("--cluster-name must be set to store backups in --destination")
+			}
+			if destination == "" && (keepLast > 0 || keepDays > 0) {
+				return fmt.Errorf("--keep-last and --keep-days can only be used with --destination")
+			}
+
+			var encrypter *backup.Encrypter
+			if includeSecrets {
+				switch {
+				case secretsPassphraseFile != "" && unencryptedSecrets:
+					return fmt.Errorf("only one of --secrets-passphrase-file and --unencrypted-secrets can be set")
+				case secretsPassphraseFile != "":
+					passphrase, err := readPassphraseFile(secretsPassphraseFile)
+					if err != nil {
+						return err
+					}
+					encrypter, err = backup.NewEncrypter(passphrase)
+					if err != nil {
+						return fmt.Errorf("error creating secret encrypter: %s", err)
+					}
+				case !unencryptedSecrets:
+					return fmt.Errorf("--include-secrets requires --secrets-passphrase-file to encrypt secrets, or --unencrypted-secrets to store them unencrypted")
+				}
+			}
+
 			kubeCfg, err := kubernetes.NewConfig(*kubeConfigPath)
 			if err != nil {
 				return err
@@ -39,30 +122,71 @@ func Backup(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 				IncludeCertificates:               includeCertificates,
 				IncludeIssuers:                    includeIssuers,
 				IncludeCertificateRequestPolicies: includeCertificateRequestPolicies,
-			}
+				IncludeShimIntent:                 includeShimIntent,
 
-			clusterBackup, err := backup.FetchClusterBackup(context.Background(), opts)
-			if err != nil {
-				return fmt.Errorf("error backing up cluster: %s", err)
+				Namespaces:        namespaces,
+				ExcludeNamespaces: excludeNamespaces,
+				LabelSelector:     selector,
+
+				IncludeSecrets:           includeSecrets,
+				SecretsEncrypter:         encrypter,
+				UnencryptedSecrets:       unencryptedSecrets,
+				ClusterResourceNamespace: clusterResourceNamespace,
+
+				PageSize: pageSize,
+				Progress: os.Stderr,
 			}
 
-			var backupData []byte
-			switch outputFormat {
-			case "yaml":
-				backupData, err = clusterBackup.ToYAML()
+			// the backup is written as it is fetched, straight to stdout or compressed and streamed to the destination
+			writeBackup := func(w io.Writer) error {
+				writer, err := backup.NewStreamWriter(w, outputFormat)
 				if err != nil {
-					return fmt.Errorf("error converting backup to YAML: %s", err)
+					return err
 				}
-			case "json":
-				backupData, err = clusterBackup.ToJSON()
-				if err != nil {
-					return fmt.Errorf("error converting backup to JSON: %s", err)
+
+				if err := backup.StreamClusterBackup(ctx, opts, writer); err != nil {
+					return fmt.Errorf("error backing up cluster: %s", err)
 				}
-			default:
-				return fmt.Errorf("unknown output format: %s", outputFormat)
+				if err := writer.Close(); err != nil {
+					return fmt.Errorf("error writing backup: %s", err)
+				}
+				fmt.Fprintf(os.Stderr, "Backed up %d resources\n", writer.Count())
+
+				return nil
 			}
 
-			fmt.Fprintf(os.Stdout, "%s", string(backupData))
+			if destination == "" {
+				return writeBackup(os.Stdout)
+			}
+
+			backupSink, err := sink.New(ctx, destination)
+			if err != nil {
+				return fmt.Errorf("error creating backup destination: %s", err)
+			}
+
+			manifest, err := sink.Store(ctx, backupSink, sink.StoreOptions{
+				ClusterName: clusterName,
+				Format:      outputFormat,
+				Write:       writeBackup,
+				Now:         time.Now(),
+			})
+			if err != nil {
+				return fmt.Errorf("error storing backup: %s", err)
+			}
+			for _, object := range manifest.Objects {
+				fmt.Fprintf(os.Stdout, "Stored backup %s\n", object.Key)
+			}
+
+			deleted, err := sink.ApplyRetention(ctx, backupSink, clusterName, sink.Retention{
+				KeepLast: keepLast,
+				KeepFor:  time.Duration(keepDays) * 24 * time.Hour,
+			}, time.Now())
+			for _, key := range deleted {
+				fmt.Fprintf(os.Stdout, "Deleted old backup %s\n", key)
+			}
+			if err != nil {
+				return fmt.Errorf("error deleting old backups: %s", err)
+			}
 
 			return nil
 		}),
@@ -75,13 +199,32 @@ func Backup(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 	}
 	allIssuersString := allIssuers.String()
 
-	flags := cmd.PersistentFlags()
+	cmd.AddCommand(backupDiff(run, kubeConfigPath))
+
+	// the flags of the backup command are not inherited by its subcommands
+	flags := cmd.Flags()
 	flags.BoolVar(&formatResources, "format-resources", true, "if set, will remove some fields from resources such as status and metadata to allow them to be cleanly applied later")
 	flags.StringVar(&outputFormat, "format", "yaml", "output format, one of: yaml, json")
+	flags.Int64Var(&pageSize, "page-size", 500, "the number of certificates to list in each request, 0 lists them all at once")
 
-	flags.BoolVar(&includeCertificates, "include-certificates", true, "if set, certificate resources will be included in the backup. Note: ingress-shim managed certificates are not included since they are automatically generated.")
+	flags.BoolVar(&includeCertificates, "include-certificates", true, "if set, certificate resources will be included in the backup. Note: ingress-shim and gateway-shim managed certificates are not included since they are automatically generated.")
 	flags.BoolVar(&includeIssuers, "include-issuers", true, fmt.Sprintf("if set, issuer resources will be included in the backup (supports: %s)", allIssuersString))
 	flags.BoolVar(&includeCertificateRequestPolicies, "include-certificate-request-policies", true, "if set, certificate request policy resources will be included in the backup")
+	flags.BoolVar(&includeShimIntent, "include-shim-intent", false, "if set, the cert-manager annotations and TLS configuration of annotated ingresses and gateways will be included in the backup, so that ingress-shim and gateway-shim managed certificates can be recreated")
+
+	flags.StringSliceVarP(&namespaces, "namespace", "n", nil, "if set, only resources in these namespaces will be included in the backup, may be repeated")
+	flags.StringSliceVar(&excludeNamespaces, "exclude-namespace", nil, "if set, resources in these namespaces will not be included in the backup, may be repeated")
+	flags.StringVarP(&selector, "selector", "l", "", "if set, only resources matching this label selector will be included in the backup")
+
+	flags.BoolVar(&includeSecrets, "include-secrets", false, "if set, the secrets of backed up certificates and CA issuers will be included in the backup")
+	flags.StringVar(&secretsPassphraseFile, "secrets-passphrase-file", "", "path to a file containing the passphrase used to encrypt secrets")
+	flags.BoolVar(&unencryptedSecrets, "unencrypted-secrets", false, "if set, secrets will be included in the backup without being encrypted")
+	flags.StringVar(&clusterResourceNamespace, "cluster-resource-namespace", "cert-manager", "the namespace cert-manager reads the secrets of cluster issuers from")
+
+	flags.StringVar(&destination, "destination", "", "if set, the backup will be stored in this directory or bucket rather than written to stdout")
+	flags.StringVar(&clusterName, "cluster-name", "", "the name of the cluster, used to name backups stored in --destination")
+	flags.IntVar(&keepLast, "keep-last", 0, "if set, only this many of the newest complete backups of the cluster will be kept in --destination")
+	flags.IntVar(&keepDays, "keep-days", 0, "if set, backups of the cluster older than this many days will be deleted from --destination")
 
 	return cmd
 }
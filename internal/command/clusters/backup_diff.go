@@ -0,0 +1,232 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+// backupDiff returns a cobra.Command instance that compares two backups, or a backup and the current cluster.
+func backupDiff(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
+	var live bool
+	var secretsPassphraseFile string
+	var namespaces []string
+	var excludeNamespaces []string
+	var selector string
+	var clusterResourceNamespace string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "diff <backup> [<backup>]",
+		Short: "Compare two backups, or a backup and the current cluster",
+		Long: `Compares the resources in two backups, or with --live, in a backup and the current cluster. Resources are matched by
+API version, kind, namespace and name, and those which were added, removed or changed are reported along with the
+fields which changed. Fields set by the API server, such as the resource version and status, are ignored, and the
+values of Secret data are redacted.
+
+Encrypted Secrets in backups made with --include-secrets are decrypted with the passphrase in
+--secrets-passphrase-file before they are compared.
+
+With --live, the cluster is backed up in the same way as the backup command would, so --namespace,
+--exclude-namespace and --selector should match those the backup was made with. Secrets and the certificate intent
+of Ingresses and Gateways are only compared if the backup contains them.
+
+The command fails if the backups differ.`,
+		Example: `  jsctl experimental clusters backup diff before.yaml after.yaml
+  jsctl experimental clusters backup diff backup.yaml --live --secrets-passphrase-file passphrase.txt`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "json":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, json", outputFormat)
+			}
+
+			if live && len(args) != 1 {
+				return fmt.Errorf("only one backup can be compared with the current cluster")
+			}
+			if !live && len(args) != 2 {
+				return fmt.Errorf("two backups must be given, or one and --live to compare it with the current cluster")
+			}
+
+			var passphrase []byte
+			if secretsPassphraseFile != "" {
+				var err error
+				passphrase, err = readPassphraseFile(secretsPassphraseFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			loadBackup := func(path string) ([]*unstructured.Unstructured, error) {
+				resources, err := restore.LoadBackupFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("error loading backup %s: %s", path, err)
+				}
+				resources, err = restore.DecryptSecrets(resources, passphrase)
+				if err != nil {
+					return nil, fmt.Errorf("error decrypting backup %s: %s", path, err)
+				}
+				return resources, nil
+			}
+
+			a, err := loadBackup(args[0])
+			if err != nil {
+				return err
+			}
+
+			var b []*unstructured.Unstructured
+			if live {
+				includeSecrets := false
+				includeShimIntent := false
+				for _, resource := range a {
+					switch {
+					case resource.GetAPIVersion() == "v1" && resource.GetKind() == "Secret":
+						includeSecrets = true
+					case resource.GetAPIVersion() == backup.ShimIntentAPIVersion && resource.GetKind() == backup.ShimIntentKind:
+						includeShimIntent = true
+					}
+				}
+
+				kubeCfg, err := kubernetes.NewConfig(*kubeConfigPath)
+				if err != nil {
+					return err
+				}
+
+				// the secrets of the live backup are only compared in memory, so are not encrypted
+				clusterBackup, err := backup.FetchClusterBackup(ctx, backup.ClusterBackupOptions{
+					RestConfig: kubeCfg,
+
+					FormatResources: true,
+
+					IncludeCertificates:               true,
+					IncludeIssuers:                    true,
+					IncludeCertificateRequestPolicies: true,
+					IncludeShimIntent:                 includeShimIntent,
+
+					Namespaces:        namespaces,
+					ExcludeNamespaces: excludeNamespaces,
+					LabelSelector:     selector,
+
+					IncludeSecrets:           includeSecrets,
+					UnencryptedSecrets:       true,
+					ClusterResourceNamespace: clusterResourceNamespace,
+				})
+				if err != nil {
+					return fmt.Errorf("error backing up cluster: %s", err)
+				}
+
+				b, err = clusterBackup.ToUnstructured()
+				if err != nil {
+					return fmt.Errorf("error converting cluster backup: %s", err)
+				}
+			} else {
+				b, err = loadBackup(args[1])
+				if err != nil {
+					return err
+				}
+			}
+
+			diff, err := backup.Diff(a, b)
+			if err != nil {
+				return fmt.Errorf("error comparing backups: %s", err)
+			}
+
+			switch outputFormat {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(diff); err != nil {
+					return err
+				}
+			default:
+				if err := printBackupDiff(diff); err != nil {
+					return err
+				}
+			}
+
+			if len(diff.Objects) > 0 {
+				return fmt.Errorf("backups differ: %d added, %d removed, %d changed",
+					diff.Count(backup.ChangeAdded), diff.Count(backup.ChangeRemoved), diff.Count(backup.ChangeChanged))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&live, "live", false, "if set, the backup will be compared with the current cluster")
+	flags.StringVar(&secretsPassphraseFile, "secrets-passphrase-file", "", "path to a file containing the passphrase the secrets in the backups were encrypted with")
+	flags.StringSliceVarP(&namespaces, "namespace", "n", nil, "with --live, only resources in these namespaces will be compared, may be repeated")
+	flags.StringSliceVar(&excludeNamespaces, "exclude-namespace", nil, "with --live, resources in these namespaces will not be compared, may be repeated")
+	flags.StringVarP(&selector, "selector", "l", "", "with --live, only resources matching this label selector will be compared")
+	flags.StringVar(&clusterResourceNamespace, "cluster-resource-namespace", "cert-manager", "the namespace cert-manager reads the secrets of cluster issuers from")
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, json")
+
+	return cmd
+}
+
+func printBackupDiff(diff *backup.BackupDiff) error {
+	if len(diff.Objects) == 0 {
+		fmt.Println("No differences found")
+		return nil
+	}
+
+	tbl := table.NewBuilder([]string{"CHANGE", "KIND", "NAMESPACE", "NAME"})
+	for _, object := range diff.Objects {
+		tbl.AddRow(object.Change, object.Kind, object.Namespace, object.Name)
+	}
+
+	if err := tbl.Build(os.Stdout); err != nil {
+		return err
+	}
+
+	first := true
+	for _, object := range diff.Objects {
+		if len(object.Fields) == 0 {
+			continue
+		}
+
+		if first {
+			fmt.Println("\nChanges:")
+			first = false
+		}
+		if object.Namespace != "" {
+			fmt.Printf("%s %s/%s:\n", object.Kind, object.Namespace, object.Name)
+		} else {
+			fmt.Printf("%s %s:\n", object.Kind, object.Name)
+		}
+		for _, field := range object.Fields {
+			switch {
+			case field.Old == nil:
+				fmt.Printf("  %s: added %s\n", field.Path, diffValue(field.New))
+			case field.New == nil:
+				fmt.Printf("  %s: removed %s\n", field.Path, diffValue(field.Old))
+			default:
+				fmt.Printf("  %s: %s -> %s\n", field.Path, diffValue(field.Old), diffValue(field.New))
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffValue formats a field value on a single line.
+func diffValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(data)
+}
@@ -14,12 +14,16 @@ import (
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
 )
 
 // Connect returns a new cobra.Command that connects a cluster to the control plane.
 func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool) *cobra.Command {
 	const defaultRegistry = "quay.io/jetstack"
-	var registry string
+	var (
+		registry   string
+		patchPaths []string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "connect name",
@@ -36,6 +40,11 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 				return internalerrors.ErrNoOrganizationName
 			}
 
+			patches, err := patch.LoadFiles(patchPaths)
+			if err != nil {
+				return fmt.Errorf("failed to load patches: %w", err)
+			}
+
 			http := client.New(ctx, *apiURL)
 
 			serviceAccount, err := cluster.CreateServiceAccount(ctx, http, cnf.Organization, name)
@@ -58,6 +67,7 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 				Name:           name,
 				ServiceAccount: serviceAccount,
 				ImageRegistry:  registry,
+				Patches:        patches,
 			})
 
 			if err != nil {
@@ -73,6 +83,7 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 
 	flags := cmd.PersistentFlags()
 	flags.StringVar(&registry, "registry", defaultRegistry, "Specifies an alternative image registry to use for the agent image")
+	flags.StringArrayVar(&patchPaths, "patch", []string{}, "Path to a file of JSON6902 or strategic merge patches to apply to the generated agent resources before they are applied. Can be specified multiple times, patches are applied in order")
 
 	return cmd
 }
@@ -0,0 +1,102 @@
+package clusters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+)
+
+// DecryptBackup returns a cobra.Command instance that decrypts the Secrets in a backup made with --include-secrets so
+// that it can be applied to a cluster.
+func DecryptBackup(run types.RunFunc) *cobra.Command {
+	var inputFile string
+	var passphraseFile string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "decrypt-backup",
+		Short: "Decrypt the secrets in a backup made with --include-secrets",
+		Long: `Decrypts the EncryptedSecret resources in a backup made with 'backup --include-secrets', using the passphrase
+the backup was made with, and outputs the backup with them replaced by Secrets. The output contains private keys in
+plain text and should be handled with care.`,
+		Example: `  jsctl experimental clusters decrypt-backup -f backup.yaml --passphrase-file passphrase.txt | kubectl apply -f -`,
+		Args:    cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if inputFile == "" {
+				return fmt.Errorf("a backup file must be set with --file")
+			}
+			if passphraseFile == "" {
+				return fmt.Errorf("a passphrase file must be set with --passphrase-file")
+			}
+
+			passphrase, err := readPassphraseFile(passphraseFile)
+			if err != nil {
+				return err
+			}
+
+			resources, err := restore.LoadBackupFile(inputFile)
+			if err != nil {
+				return fmt.Errorf("error loading backup: %s", err)
+			}
+
+			resources, err = restore.DecryptSecrets(resources, passphrase)
+			if err != nil {
+				return fmt.Errorf("error decrypting backup: %s", err)
+			}
+
+			clusterBackup := make(backup.ClusterBackup, 0, len(resources))
+			for _, resource := range resources {
+				clusterBackup = append(clusterBackup, resource.Object)
+			}
+
+			var backupData []byte
+			switch outputFormat {
+			case "yaml":
+				backupData, err = clusterBackup.ToYAML()
+				if err != nil {
+					return fmt.Errorf("error converting backup to YAML: %s", err)
+				}
+			case "json":
+				backupData, err = clusterBackup.ToJSON()
+				if err != nil {
+					return fmt.Errorf("error converting backup to JSON: %s", err)
+				}
+			default:
+				return fmt.Errorf("unknown output format: %s", outputFormat)
+			}
+
+			fmt.Fprintf(os.Stdout, "%s", string(backupData))
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&inputFile, "file", "f", "", "path to the backup file to decrypt")
+	flags.StringVar(&passphraseFile, "passphrase-file", "", "path to a file containing the passphrase the backup was encrypted with")
+	flags.StringVar(&outputFormat, "format", "yaml", "output format, one of: yaml, json")
+
+	return cmd
+}
+
+// readPassphraseFile returns the passphrase in the file, without the trailing newline most editors add.
+func readPassphraseFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passphrase file: %s", err)
+	}
+
+	passphrase := bytes.TrimRight(data, "\r\n")
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase file %s is empty", path)
+	}
+
+	return passphrase, nil
+}
@@ -0,0 +1,537 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/clock"
+
+	operatorcommand "github.com/jetstack/jsctl/internal/command/operator"
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/migrate"
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/prompt"
+)
+
+// The steps of a migration, in the order they are run. Their names are recorded in the migration state file.
+const (
+	migrateStepCleanup           = "cleanup"
+	migrateStepVerify            = "verify"
+	migrateStepBackup            = "backup"
+	migrateStepUninstall         = "uninstall"
+	migrateStepDeployOperator    = "deploy-operator"
+	migrateStepApplyInstallation = "apply-installation"
+	migrateStepRestore           = "restore"
+)
+
+// migrateBackupPageSize is the number of certificates listed in each request when backing up the cluster
+const migrateBackupPageSize = 500
+
+// migrationStep is a step of a migration, which can be run again if it fails
+type migrationStep struct {
+	name        string
+	description string
+	run         func(ctx context.Context) error
+}
+
+// migrateOptions contains the flags of the migrate-to-operator command
+type migrateOptions struct {
+	kubeConfigPath string
+
+	releaseName      string
+	releaseNamespace string
+	stateFile        string
+	backupFile       string
+	yes              bool
+
+	operatorVersion         string
+	imageRegistry           string
+	registryCredentialsPath string
+}
+
+// MigrateToOperator returns a cobra.Command instance that migrates cert-manager installed with Helm to an
+// Installation managed by the Jetstack Secure operator.
+func MigrateToOperator(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
+	var opts migrateOptions
+
+	cmd := &cobra.Command{
+		Use:   "migrate-to-operator",
+		Short: "Migrate cert-manager installed with Helm to an Installation managed by the Jetstack Secure operator",
+		Long: `Migrates cert-manager installed with Helm to an Installation managed by the Jetstack Secure operator. The Helm
+release is detected through the Secrets Helm stores releases in, and the Installation is generated to match the
+cert-manager version and replicas it deployed. Flags set on the cert-manager controller which cannot be set on an
+Installation are listed before the migration starts, and are not carried over.
+
+The migration runs the following steps, each of which is confirmed before it is run unless --yes is set:
+* cleanup: removes Certificate owner references from Secrets, so that they are kept when cert-manager is uninstalled
+* verify: runs the checks of 'uninstall verify', confirming the migration should continue if any issues are found
+* backup: backs up the certificates, issuers, policies and ingress-shim and gateway-shim intent to --backup-file
+* uninstall: waits for the Helm release to be uninstalled with 'helm uninstall', which jsctl does not run itself
+* deploy-operator: deploys the Jetstack Secure operator
+* apply-installation: applies the Installation, with the issuers in the backup managed by the operator
+* restore: restores the rest of the backup once the Installation has installed cert-manager
+
+Progress is recorded in --state-file. If a step fails or is not confirmed, running the command again resumes the
+migration from that step, using the Helm release and Installation detected when the migration started. Delete the
+state file to start again.`,
+		Example: `  jsctl experimental clusters migrate-to-operator
+  jsctl experimental clusters migrate-to-operator --release cert-manager --release-namespace cert-manager --registry-credentials-path key.json`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			opts.kubeConfigPath = *kubeConfigPath
+
+			kubeCfg, err := kubernetes.NewConfig(opts.kubeConfigPath)
+			if err != nil {
+				return err
+			}
+
+			clientset, err := buildClients(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("error building required clients: %w", err)
+			}
+
+			state, err := migrate.LoadState(opts.stateFile)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				state, err = newMigrationState(ctx, clientset, opts)
+				if err != nil {
+					return err
+				}
+
+				printMigrationPlan(&state.Plan)
+				if !opts.yes {
+					confirmed, err := prompt.YesNo(os.Stdin, os.Stderr, "Migrate Helm release %s to an Installation?", state.Plan.Release.String())
+					if err != nil {
+						return err
+					}
+					if !confirmed {
+						fmt.Fprintf(os.Stderr, "No action taken\n")
+						return nil
+					}
+				}
+
+				if err := state.Save(opts.stateFile); err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			default:
+				fmt.Fprintf(os.Stderr, "Resuming the migration recorded in %s\n", opts.stateFile)
+				printMigrationPlan(&state.Plan)
+			}
+
+			steps := migrationSteps(kubeCfg, clientset, state, opts)
+			for i, step := range steps {
+				if state.IsCompleted(step.name) {
+					fmt.Fprintf(os.Stderr, "\nStep %d/%d: %s (already completed)\n", i+1, len(steps), step.description)
+					continue
+				}
+
+				fmt.Fprintf(os.Stderr, "\nStep %d/%d: %s\n", i+1, len(steps), step.description)
+				if !opts.yes {
+					confirmed, err := prompt.YesNo(os.Stdin, os.Stderr, "Run the %s step?", step.name)
+					if err != nil {
+						return err
+					}
+					if !confirmed {
+						fmt.Fprintf(os.Stderr, "Migration stopped, run this command again to resume from the %s step\n", step.name)
+						return nil
+					}
+				}
+
+				if err := step.run(ctx); err != nil {
+					return fmt.Errorf("the %s step failed, run this command again to resume from it once the problem has been fixed: %w", step.name, err)
+				}
+
+				state.Complete(step.name)
+				if err := state.Save(opts.stateFile); err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "\nHelm release %s has been migrated to an Installation managed by the operator\n", state.Plan.Release.String())
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.releaseName, "release", "", "the name of the cert-manager Helm release to migrate, required if there is more than one")
+	flags.StringVar(&opts.releaseNamespace, "release-namespace", "", "the namespace of the cert-manager Helm release to migrate")
+	flags.StringVar(&opts.stateFile, "state-file", "jsctl-migration.json", "path to the file the progress of the migration is recorded in, so that it can be resumed")
+	flags.StringVar(&opts.backupFile, "backup-file", "cert-manager-backup.yaml", "path to the file the cluster is backed up to before the Helm release is uninstalled")
+	flags.BoolVarP(&opts.yes, "yes", "y", false, "if set, steps are run without being confirmed first")
+	flags.StringVar(&opts.operatorVersion, "operator-version", "", "the version of the operator to deploy, defaults to latest")
+	flags.StringVar(&opts.imageRegistry, "registry", "", "Specifies an alternative image registry to use for the operator and its components")
+	flags.StringVar(&opts.registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for image pull secrets")
+
+	return cmd
+}
+
+// newMigrationState detects the Helm release of cert-manager and the cert-manager it deployed, and returns the state
+// of a new migration of it.
+func newMigrationState(ctx context.Context, clientset allClients, opts migrateOptions) (*migrate.State, error) {
+	releases, err := migrate.FindHelmReleases(ctx, clientset.secrets, migrate.CertManagerChart)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*migrate.HelmRelease
+	for _, release := range releases {
+		if opts.releaseName != "" && release.Name != opts.releaseName {
+			continue
+		}
+		if opts.releaseNamespace != "" && release.Namespace != opts.releaseNamespace {
+			continue
+		}
+		matching = append(matching, release)
+	}
+
+	switch len(matching) {
+	case 0:
+		return nil, fmt.Errorf("no deployed Helm release of the %s chart was found", migrate.CertManagerChart)
+	case 1:
+	default:
+		names := make([]string, 0, len(matching))
+		for _, release := range matching {
+			names = append(names, release.String())
+		}
+		return nil, fmt.Errorf("found Helm releases %s, select one with --release and --release-namespace", strings.Join(names, ", "))
+	}
+	release := matching[0]
+
+	var pods corev1.PodList
+	if err := clientset.pods.List(ctx, &clients.GenericRequestOptions{Namespace: release.Namespace}, &pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var certManagerStatus components.CertManagerStatus
+	found, err := certManagerStatus.Match(&components.MatchData{Pods: pods.Items})
+	if err != nil {
+		return nil, fmt.Errorf("error matching cert-manager status: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("cert-manager is not running in the namespace of Helm release %s", release)
+	}
+
+	plan, err := migrate.NewPlan(release, &certManagerStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrate.State{
+		Plan:       *plan,
+		BackupFile: opts.backupFile,
+	}, nil
+}
+
+func printMigrationPlan(plan *migrate.Plan) {
+	fmt.Fprintf(os.Stderr, "Helm release %s (revision %d, chart version %s) will be migrated to an Installation of cert-manager %s with %d replicas\n",
+		plan.Release.String(),
+		plan.Release.Revision,
+		plan.Release.ChartVersion,
+		plan.CertManagerVersion,
+		plan.CertManagerReplicas,
+	)
+
+	if len(plan.UnsupportedFlags) > 0 {
+		fmt.Fprintf(os.Stderr, "The following cert-manager controller flags cannot be set on an Installation and will not be carried over:\n")
+		for _, flag := range plan.UnsupportedFlags {
+			fmt.Fprintf(os.Stderr, "	* %s\n", flag)
+		}
+	}
+}
+
+// migrationSteps returns the steps of the migration in the order they are run.
+func migrationSteps(kubeCfg *rest.Config, clientset allClients, state *migrate.State, opts migrateOptions) []migrationStep {
+	release := state.Plan.Release
+
+	return []migrationStep{
+		{
+			name:        migrateStepCleanup,
+			description: "Remove Certificate owner references from Secrets",
+			run: func(ctx context.Context) error {
+				// the step has been confirmed already, so the Secrets are updated without asking again
+				safe, err := removeCertificateOwnerRefs(ctx, kubeCfg, func(int) (bool, error) { return true, nil })
+				if err != nil {
+					return err
+				}
+				if !safe {
+					return fmt.Errorf("secrets still have owner references to Certificates, if Helm release %s sets enableCertificateOwnerRef, upgrade it with enableCertificateOwnerRef=false first", release.String())
+				}
+				return nil
+			},
+		},
+		{
+			name:        migrateStepVerify,
+			description: "Check that the cluster is ready to have cert-manager uninstalled",
+			run: func(ctx context.Context) error {
+				notifications, err := findIssues(ctx, clientset, clock.RealClock{})
+				if err != nil {
+					return fmt.Errorf("error investigating cluster state: %w", err)
+				}
+				printNotifications(notifications)
+
+				for _, n := range notifications {
+					if n.header == hasOwnerRefHeader {
+						return errors.New("secrets with Certificate owner references would be deleted when cert-manager is uninstalled")
+					}
+				}
+				if len(notifications) == 0 || opts.yes {
+					return nil
+				}
+
+				confirmed, err := prompt.YesNo(os.Stdin, os.Stderr, "Continue the migration despite the issues found?")
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					return errors.New("the migration was stopped because of the issues found")
+				}
+				return nil
+			},
+		},
+		{
+			name:        migrateStepBackup,
+			description: fmt.Sprintf("Back up the cluster to %s", state.BackupFile),
+			run: func(ctx context.Context) error {
+				return backupForMigration(ctx, kubeCfg, state.BackupFile)
+			},
+		},
+		{
+			name:        migrateStepUninstall,
+			description: fmt.Sprintf("Uninstall Helm release %s", release.String()),
+			run: func(ctx context.Context) error {
+				fmt.Fprintf(os.Stderr, "Uninstall the Helm release with:\n\n	helm uninstall %s --namespace %s\n\n", release.Name, release.Namespace)
+				if !opts.yes {
+					uninstalled, err := prompt.YesNo(os.Stdin, os.Stderr, "Has the Helm release been uninstalled?")
+					if err != nil {
+						return err
+					}
+					if !uninstalled {
+						return fmt.Errorf("Helm release %s has not been uninstalled", release.String())
+					}
+				}
+
+				return checkUninstalled(ctx, clientset, &release)
+			},
+		},
+		{
+			name:        migrateStepDeployOperator,
+			description: "Deploy the Jetstack Secure operator",
+			run: func(ctx context.Context) error {
+				return deployOperatorForMigration(ctx, opts)
+			},
+		},
+		{
+			name:        migrateStepApplyInstallation,
+			description: fmt.Sprintf("Apply an Installation of cert-manager %s", state.Plan.CertManagerVersion),
+			run: func(ctx context.Context) error {
+				return applyInstallationForMigration(ctx, kubeCfg, state, opts)
+			},
+		},
+		{
+			name:        migrateStepRestore,
+			description: fmt.Sprintf("Restore the resources in %s which are not managed by the operator", state.BackupFile),
+			run: func(ctx context.Context) error {
+				return restoreForMigration(ctx, kubeCfg, state.BackupFile)
+			},
+		},
+	}
+}
+
+// backupForMigration backs up the resources the migration restores once the Installation has been applied. Secrets
+// are not backed up, as the cleanup step ensures they are kept when cert-manager is uninstalled.
+func backupForMigration(ctx context.Context, kubeCfg *rest.Config, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+
+	writer, err := backup.NewStreamWriter(file, "yaml")
+	if err != nil {
+		return err
+	}
+
+	err = backup.StreamClusterBackup(ctx, backup.ClusterBackupOptions{
+		RestConfig:                        kubeCfg,
+		FormatResources:                   true,
+		IncludeCertificates:               true,
+		IncludeIssuers:                    true,
+		IncludeCertificateRequestPolicies: true,
+		IncludeShimIntent:                 true,
+		PageSize:                          migrateBackupPageSize,
+		Progress:                          os.Stderr,
+	}, writer)
+	if err != nil {
+		return fmt.Errorf("error backing up cluster: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Backed up %d resources to %s\n", writer.Count(), path)
+
+	return nil
+}
+
+// checkUninstalled returns an error if the Helm release or the cert-manager it deployed are still present.
+func checkUninstalled(ctx context.Context, clientset allClients, release *migrate.HelmRelease) error {
+	releases, err := migrate.FindHelmReleases(ctx, clientset.secrets, migrate.CertManagerChart)
+	if err != nil {
+		return err
+	}
+	for _, r := range releases {
+		if r.Name == release.Name && r.Namespace == release.Namespace {
+			return fmt.Errorf("Helm release %s is still installed", release)
+		}
+	}
+
+	var pods corev1.PodList
+	if err := clientset.pods.List(ctx, &clients.GenericRequestOptions{Namespace: release.Namespace}, &pods); err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	found, err := (&components.CertManagerStatus{}).Match(&components.MatchData{Pods: pods.Items})
+	if err != nil {
+		return fmt.Errorf("error matching cert-manager status: %w", err)
+	}
+	if found {
+		return fmt.Errorf("cert-manager pods are still running in namespace %s, wait for them to be deleted", release.Namespace)
+	}
+
+	return nil
+}
+
+func deployOperatorForMigration(ctx context.Context, opts migrateOptions) error {
+	applier, err := kubernetes.NewKubeConfigApplier(opts.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+
+	return operatorcommand.DeployOperator(ctx, applier, operatorcommand.DeployOptions{
+		Version:                 opts.operatorVersion,
+		ImageRegistry:           opts.imageRegistry,
+		RegistryCredentialsPath: opts.registryCredentialsPath,
+	})
+}
+
+func applyInstallationForMigration(ctx context.Context, kubeCfg *rest.Config, state *migrate.State, opts migrateOptions) error {
+	installationClient, err := clients.NewInstallationClient(kubeCfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = installationClient.Status(ctx)
+	switch {
+	case errors.Is(err, clients.ErrNoInstallationCRD):
+		return errors.New("the installations.operator.jetstack.io CRD has not been installed yet, wait for the operator to start")
+	case err != nil && !errors.Is(err, clients.ErrNoInstallation):
+		return fmt.Errorf("failed to check cluster status before applying the Installation: %w", err)
+	}
+
+	issuers, err := restore.ExtractOperatorManageableIssuersFromBackupFile(state.BackupFile)
+	if err != nil {
+		return fmt.Errorf("error extracting issuers from backup file: %w", err)
+	}
+	if len(issuers.Missed) != 0 {
+		fmt.Fprintf(os.Stderr, "The following issuers cannot be managed by the operator and must be restored manually: %s\n", strings.Join(issuers.Missed, ", "))
+	}
+	if len(issuers.NeedsConversion) != 0 {
+		fmt.Fprintf(os.Stderr, "The following issuers need to be converted to cert-manager v1 resources: %s\n", strings.Join(issuers.NeedsConversion, ", "))
+	}
+
+	options := state.Plan.InstallationOptions()
+	options.ImageRegistry = opts.imageRegistry
+	options.RegistryCredentialsPath = opts.registryCredentialsPath
+	options.ImportedCertManagerIssuers = issuers.CertManagerIssuers
+	options.ImportedCertManagerClusterIssuers = issuers.CertManagerClusterIssuers
+	options.ImportedVenafiIssuers = issuers.VenafiIssuers
+	options.ImportedVenafiClusterIssuers = issuers.VenafiClusterIssuers
+
+	applier, err := kubernetes.NewKubeConfigApplier(opts.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if err := operator.ApplyInstallationYAML(ctx, applier, options); err != nil {
+		return fmt.Errorf("failed to apply component manifests: %w", err)
+	}
+
+	return nil
+}
+
+// restoreForMigration restores the resources in the backup, other than the issuers managed by the operator, which
+// are part of the Installation.
+func restoreForMigration(ctx context.Context, kubeCfg *rest.Config, path string) error {
+	resources, err := restore.LoadBackupFile(path)
+	if err != nil {
+		return fmt.Errorf("error loading backup: %w", err)
+	}
+
+	resources, _, err = restore.ConvertLegacyResources(resources)
+	if err != nil {
+		return fmt.Errorf("error converting backup: %w", err)
+	}
+
+	var unmanaged []*unstructured.Unstructured
+	for _, resource := range resources {
+		if !isOperatorManagedIssuer(resource) {
+			unmanaged = append(unmanaged, resource)
+		}
+	}
+
+	restorer, err := restore.NewClusterRestorer(kubeCfg)
+	if err != nil {
+		return err
+	}
+
+	report, err := restorer.Restore(ctx, unmanaged, restore.ClusterRestoreOptions{})
+	var missingTypes *restore.MissingResourceTypesError
+	switch {
+	case errors.As(err, &missingTypes):
+		return fmt.Errorf("%w, wait for the Installation to install cert-manager", err)
+	case err != nil:
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+
+	if err := printRestoreReport(report); err != nil {
+		return err
+	}
+	if failed := report.Count(restore.ResultFailed); failed > 0 {
+		return fmt.Errorf("%d of %d resources could not be restored", failed, len(report.Resources))
+	}
+
+	return nil
+}
+
+// isOperatorManagedIssuer returns true if the resource is an issuer the operator manages through the Installation.
+func isOperatorManagedIssuer(resource *unstructured.Unstructured) bool {
+	gvk := resource.GroupVersionKind()
+	for _, issuer := range []clients.AnyIssuer{
+		clients.CertManagerIssuer,
+		clients.CertManagerClusterIssuer,
+		clients.VenafiEnhancedIssuer,
+		clients.VenafiEnhancedClusterIssuer,
+	} {
+		if gvk.Group == issuer.Group() && gvk.Kind == issuer.Kind() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package clusters
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/migrate"
+)
+
+func Test_checkUninstalled(t *testing.T) {
+	release := &migrate.HelmRelease{Name: "cert-manager", Namespace: "cert-manager"}
+	releaseSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.cert-manager.v1", Namespace: "cert-manager"},
+		Data: map[string][]byte{
+			"release": []byte(base64.StdEncoding.EncodeToString([]byte(`{"name": "cert-manager", "namespace": "cert-manager", "chart": {"metadata": {"name": "cert-manager"}}}`))),
+		},
+	}
+	controllerPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-manager", Namespace: "cert-manager"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "cert-manager", Image: "quay.io/jetstack/cert-manager-controller:v1.11.0"},
+			},
+		},
+	}
+
+	scenarios := map[string]struct {
+		secrets   []corev1.Secret
+		pods      []corev1.Pod
+		expectErr bool
+	}{
+		"uninstalled": {},
+		"release still installed": {
+			secrets:   []corev1.Secret{releaseSecret},
+			expectErr: true,
+		},
+		"pods still running": {
+			pods:      []corev1.Pod{controllerPod},
+			expectErr: true,
+		},
+	}
+
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			clientset := allClients{
+				secrets: &clients.FakeGeneric[*corev1.Secret, *corev1.SecretList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *corev1.SecretList) error {
+						result.Items = scenario.secrets
+						return nil
+					},
+				},
+				pods: &clients.FakeGeneric[*corev1.Pod, *corev1.PodList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *corev1.PodList) error {
+						result.Items = scenario.pods
+						return nil
+					},
+				},
+			}
+
+			err := checkUninstalled(context.Background(), clientset, release)
+			if scenario.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", scenario.expectErr, err)
+			}
+		})
+	}
+}
+
+func Test_isOperatorManagedIssuer(t *testing.T) {
+	scenarios := map[string]struct {
+		apiVersion string
+		kind       string
+		expected   bool
+	}{
+		"cert-manager issuer":        {apiVersion: "cert-manager.io/v1", kind: "Issuer", expected: true},
+		"cert-manager clusterissuer": {apiVersion: "cert-manager.io/v1", kind: "ClusterIssuer", expected: true},
+		"venafi issuer":              {apiVersion: "jetstack.io/v1alpha1", kind: "VenafiIssuer", expected: true},
+		"certificate":                {apiVersion: "cert-manager.io/v1", kind: "Certificate", expected: false},
+		"external issuer":            {apiVersion: "awspca.cert-manager.io/v1beta1", kind: "AWSPCAIssuer", expected: false},
+	}
+
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion(scenario.apiVersion)
+			resource.SetKind(scenario.kind)
+
+			if got := isOperatorManagedIssuer(resource); got != scenario.expected {
+				t.Fatalf("expected %t, got %t", scenario.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+// Restore returns a cobra.Command instance that creates the resources in a backup in the current cluster.
+func Restore(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
+	var inputFile string
+	var overwrite bool
+	var secretsPassphraseFile string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a backup made with the backup command to the current cluster",
+		Long: `Creates the resources in a backup made with the backup command in the current cluster. The cluster must serve the
+types of all resources in the backup, so cert-manager and any external issuers must be installed first. Resources are
+created in dependency order: namespaces, issuers, Secrets, Certificates and then policies. Namespaces that resources
+are in are created if they do not exist.
+
+Resources that already exist are skipped unless --overwrite is set, in which case they are replaced with the
+resources in the backup. Secrets in backups made with --include-secrets are decrypted with the passphrase in
+--secrets-passphrase-file. cert-manager.io/v1alpha2, v1alpha3 and v1beta1 Issuers, ClusterIssuers and Certificates are
+converted to cert-manager.io/v1, which is the only version current cert-manager releases serve.
+
+The cert-manager annotations and TLS configuration of Ingresses and Gateways in backups made with --include-shim-intent
+are applied last, to Ingresses and Gateways which exist in the cluster already. Those which do not exist yet are
+skipped, so the command can be run again once applications have been deployed. Ingresses and Gateways with different
+cert-manager annotations or TLS configuration are skipped unless --overwrite is set.
+
+The command fails if any resource could not be restored.`,
+		Example: `  jsctl experimental clusters restore -f backup.yaml
+  jsctl experimental clusters restore -f backup.yaml --secrets-passphrase-file passphrase.txt --overwrite`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "json":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, json", outputFormat)
+			}
+
+			if inputFile == "" {
+				return fmt.Errorf("a backup file must be set with --file")
+			}
+
+			var passphrase []byte
+			if secretsPassphraseFile != "" {
+				var err error
+				passphrase, err = readPassphraseFile(secretsPassphraseFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			resources, err := restore.LoadBackupFile(inputFile)
+			if err != nil {
+				return fmt.Errorf("error loading backup: %s", err)
+			}
+
+			resources, err = restore.DecryptSecrets(resources, passphrase)
+			if err != nil {
+				return fmt.Errorf("error decrypting backup: %s", err)
+			}
+
+			resources, _, err = restore.ConvertLegacyResources(resources)
+			if err != nil {
+				return fmt.Errorf("error converting backup: %s", err)
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfigPath)
+			if err != nil {
+				return err
+			}
+
+			restorer, err := restore.NewClusterRestorer(kubeCfg)
+			if err != nil {
+				return err
+			}
+
+			report, err := restorer.Restore(ctx, resources, restore.ClusterRestoreOptions{Overwrite: overwrite})
+			if err != nil {
+				return fmt.Errorf("error restoring backup: %s", err)
+			}
+
+			switch outputFormat {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			default:
+				if err := printRestoreReport(report); err != nil {
+					return err
+				}
+			}
+
+			if failed := report.Count(restore.ResultFailed); failed > 0 {
+				return fmt.Errorf("%d of %d resources could not be restored", failed, len(report.Resources))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&inputFile, "file", "f", "", "path to the backup file to restore")
+	flags.BoolVar(&overwrite, "overwrite", false, "if set, resources that already exist will be replaced with those in the backup")
+	flags.StringVar(&secretsPassphraseFile, "secrets-passphrase-file", "", "path to a file containing the passphrase the secrets in the backup were encrypted with")
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, json")
+
+	return cmd
+}
+
+func printRestoreReport(report *restore.ClusterRestoreReport) error {
+	tbl := table.NewBuilder([]string{"KIND", "NAMESPACE", "NAME", "RESULT", "MESSAGE"})
+	for _, resource := range report.Resources {
+		tbl.AddRow(resource.Kind, resource.Namespace, resource.Name, resource.Result, resource.Message)
+	}
+
+	if err := tbl.Build(os.Stdout); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d created, %d updated, %d skipped, %d failed\n",
+		report.Count(restore.ResultCreated),
+		report.Count(restore.ResultUpdated),
+		report.Count(restore.ResultSkipped),
+		report.Count(restore.ResultFailed),
+	)
+
+	return nil
+}
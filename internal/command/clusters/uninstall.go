@@ -7,7 +7,6 @@ import (
 	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
 )
@@ -78,17 +78,7 @@ func verify(run types.RunFunc, kubeConfigPath string) *cobra.Command {
 			}
 
 			// print out any suggested next steps
-			if len(notifications) > 0 {
-				fmt.Fprintf(os.Stdout, "\nResults:\n")
-				for _, n := range notifications {
-					fmt.Fprintf(os.Stdout, "%s\n", n.header)
-					for _, ri := range n.resourceInfos {
-						fmt.Fprintf(os.Stdout, "	* %s\n", ri)
-					}
-				}
-			} else {
-				fmt.Fprintf(os.Stdout, "\nNothing to do before uninstalling\n")
-			}
+			printNotifications(notifications)
 			return nil
 		}),
 	}
@@ -135,8 +125,8 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 	}
 
 	{
-		var certificates cmapi.CertificateList
-		if err := clientset.certificates.List(ctx, &clients.GenericRequestOptions{}, &certificates); err != nil {
+		var certificateList cmapi.CertificateList
+		if err := clientset.certificates.List(ctx, &clients.GenericRequestOptions{}, &certificateList); err != nil {
 			return nil, fmt.Errorf("error listing certificates: %s", err)
 		}
 
@@ -161,11 +151,11 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 		fmt.Fprintf(os.Stdout, "	* Checking for upcoming expiries\n")
 		fmt.Fprintf(os.Stdout, "	* Checking for currently failing issuances\n")
 		fmt.Fprintf(os.Stdout, "	* Checking for unready Certificates\n")
-		for _, cert := range certificates.Items {
-			if isUnready(cert) {
+		for _, cert := range certificateList.Items {
+			if certificates.IsUnready(cert) {
 				unreadyResourceInfos = append(unreadyResourceInfos, fmt.Sprintf(unreadyInfoTemplate, cert.Namespace, cert.Name))
 			}
-			if willBeRenewedSoon(cert, renewalWarnBuffer, nowTime) {
+			if certificates.WillBeRenewedSoon(cert, renewalWarnBuffer, nowTime) {
 				upcomingRenewalsResourceInfos = append(
 					upcomingRenewalsResourceInfos,
 					fmt.Sprintf(
@@ -176,7 +166,7 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 					),
 				)
 			}
-			if willExpireSoon(cert, expiryWarnBuffer, nowTime) {
+			if certificates.WillExpireSoon(cert, expiryWarnBuffer, nowTime) {
 				upcomingExpiriesResourceInfos = append(
 					upcomingExpiriesResourceInfos,
 					fmt.Sprintf(
@@ -187,11 +177,11 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 					),
 				)
 			}
-			if isCurrentlyBeingIssued(cert) {
+			if certificates.IsCurrentlyBeingIssued(cert) {
 				currentIssuancesResourceInfos = append(currentIssuancesResourceInfos, fmt.Sprintf(currentIssuancesInfoTemplate, cert.Namespace, cert.Name))
 
 			}
-			if isCurrentlyFailingIssuance(cert) {
+			if certificates.IsCurrentlyFailingIssuance(cert) {
 				failedAttempts := cert.Status.FailedIssuanceAttempts
 				failedResourceInfos = append(failedResourceInfos, fmt.Sprintf(failedInfoTemplate, cert.Namespace, cert.Name, *failedAttempts))
 			}
@@ -284,6 +274,22 @@ type notification struct {
 	resourceInfos []string
 }
 
+// printNotifications prints the issues found by findIssues
+func printNotifications(notifications []notification) {
+	if len(notifications) == 0 {
+		fmt.Fprintf(os.Stdout, "\nNothing to do before uninstalling\n")
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "\nResults:\n")
+	for _, n := range notifications {
+		fmt.Fprintf(os.Stdout, "%s\n", n.header)
+		for _, ri := range n.resourceInfos {
+			fmt.Fprintf(os.Stdout, "	* %s\n", ri)
+		}
+	}
+}
+
 func buildClients(kubeconfig *rest.Config) (allClients, error) {
 	secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
 		&clients.GenericClientOptions{
@@ -326,38 +332,3 @@ type allClients struct {
 	certificates clients.Generic[*cmapi.Certificate, *cmapi.CertificateList]
 	pods         clients.Generic[*corev1.Pod, *corev1.PodList]
 }
-
-func isUnready(cert cmapi.Certificate) bool {
-	hasReady := false
-	for _, cond := range cert.Status.Conditions {
-		if cond.Type == cmapi.CertificateConditionReady {
-			hasReady = true
-			if cond.Status == cmmeta.ConditionFalse {
-				return true
-			}
-		}
-	}
-	return !hasReady
-}
-
-func willBeRenewedSoon(cert cmapi.Certificate, buffer time.Duration, nowTime time.Time) bool {
-	return cert.Status.RenewalTime != nil && nowTime.Add(buffer).After(cert.Status.RenewalTime.Time)
-}
-
-func willExpireSoon(cert cmapi.Certificate, buffer time.Duration, nowTime time.Time) bool {
-	return cert.Status.NotAfter != nil && nowTime.Add(buffer).After(cert.Status.NotAfter.Time)
-}
-
-func isCurrentlyBeingIssued(cert cmapi.Certificate) bool {
-	for _, cond := range cert.Status.Conditions {
-		if cond.Type == cmapi.CertificateConditionIssuing && cond.Status == cmmeta.ConditionTrue {
-			return true
-		}
-	}
-	return false
-}
-
-func isCurrentlyFailingIssuance(cert cmapi.Certificate) bool {
-	failedAttempts := cert.Status.FailedIssuanceAttempts
-	return failedAttempts != nil && *failedAttempts > 0
-}
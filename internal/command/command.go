@@ -49,10 +49,15 @@ func Command() *cobra.Command {
 		Clusters(),
 		Config(),
 		Experimental(),
+		Issuers(),
+		Certificates(),
+		CertificateRequests(),
 		Operator(),
 		Organizations(),
+		Policy(),
 		Registry(),
 		Users(),
+		Venafi(),
 		Version(&cmd.Version),
 	)
 
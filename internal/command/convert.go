@@ -0,0 +1,83 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+)
+
+// Convert returns a cobra.Command instance that converts the cert-manager resources of legacy API versions in a backup
+// to cert-manager.io/v1.
+func Convert() *cobra.Command {
+	var inputFile string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert legacy cert-manager resources in a backup to cert-manager.io/v1",
+		Long: `Converts the cert-manager.io/v1alpha2, v1alpha3 and v1beta1 Issuers, ClusterIssuers and Certificates in a backup
+or file of manifests to cert-manager.io/v1, in the same way as the cert-manager conversion webhook, and outputs the
+result. Other resources are output as they are. Current versions of cert-manager no longer serve the legacy API
+versions, so resources backed up from old clusters must be converted before they can be applied.
+
+The restore command converts legacy resources itself, so this is only needed to apply backups by other means.`,
+		Example: `  jsctl experimental convert -f backup.yaml > backup-v1.yaml`,
+		Args:    cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if inputFile == "" {
+				return fmt.Errorf("a file must be set with --file")
+			}
+
+			resources, err := restore.LoadBackupFile(inputFile)
+			if err != nil {
+				return fmt.Errorf("error loading backup: %s", err)
+			}
+
+			resources, converted, err := restore.ConvertLegacyResources(resources)
+			if err != nil {
+				return fmt.Errorf("error converting backup: %s", err)
+			}
+
+			clusterBackup := make(backup.ClusterBackup, 0, len(resources))
+			for _, resource := range resources {
+				clusterBackup = append(clusterBackup, resource.Object)
+			}
+
+			var backupData []byte
+			switch outputFormat {
+			case "yaml":
+				backupData, err = clusterBackup.ToYAML()
+				if err != nil {
+					return fmt.Errorf("error converting backup to YAML: %s", err)
+				}
+			case "json":
+				backupData, err = clusterBackup.ToJSON()
+				if err != nil {
+					return fmt.Errorf("error converting backup to JSON: %s", err)
+				}
+			default:
+				return fmt.Errorf("unknown output format: %s", outputFormat)
+			}
+
+			fmt.Fprintf(os.Stdout, "%s", string(backupData))
+
+			if len(converted) > 0 {
+				fmt.Fprintf(os.Stderr, "Converted to cert-manager.io/v1: %s\n", strings.Join(converted, ", "))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&inputFile, "file", "f", "", "path to the backup file to convert")
+	flags.StringVar(&outputFormat, "format", "yaml", "output format, one of: yaml, json")
+
+	return cmd
+}
@@ -23,10 +23,14 @@ func Experimental() *cobra.Command {
 	experimentalClustersCommands.AddCommand(
 		clusters.CleanUp(run, &kubeConfig),
 		clusters.Backup(run, &kubeConfig),
+		clusters.DecryptBackup(run),
+		clusters.Restore(run, &kubeConfig),
 		clusters.Uninstall(run, &kubeConfig),
+		clusters.MigrateToOperator(run, &kubeConfig),
 	)
 
 	cmd.AddCommand(experimentalClustersCommands)
+	cmd.AddCommand(Convert())
 
 	return cmd
 }
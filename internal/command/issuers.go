@@ -0,0 +1,24 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/issuers"
+)
+
+// Issuers returns a cobra.Command instance that is the root for all "jsctl issuers" subcommands.
+func Issuers() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "issuers",
+		Aliases: []string{"issuer"},
+		Short:   "Subcommands for managing certificate issuers in the current cluster",
+	}
+
+	cmd.AddCommand(
+		issuers.List(run, &kubeConfig),
+		issuers.Check(run, &kubeConfig),
+		issuers.Venafi(run, &kubeConfig, &useStdout),
+	)
+
+	return cmd
+}
@@ -0,0 +1,210 @@
+package issuers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/inventory"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+const (
+	// issuanceInterval is how often test CertificateRequests are checked while waiting for them to be issued
+	issuanceInterval = time.Second
+
+	// defaultDNSName is used in test CertificateRequests if --dns-name is not set
+	defaultDNSName = "jsctl-issuer-check.example.com"
+)
+
+// Check returns a cobra.Command instance that checks whether the issuers in the current cluster are able to issue
+// certificates.
+func Check(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		outputFormat             string
+		clusterResourceNamespace string
+		testIssuance             bool
+		namespace                string
+		dnsName                  string
+		timeout                  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check that the issuers in the current cluster are able to issue certificates",
+		Long: `Checks issuers of all supported kinds for problems that would stop them issuing certificates: that they are not
+ready, that the Secrets they reference, such as ACME account keys, CA key pairs and AWS or Google credentials, are
+missing or incomplete, and that their CA certificates are missing, are not CAs or have expired. Secrets of cluster
+scoped cert-manager and Google CAS issuers are looked up in --cluster-resource-namespace.
+
+With --test-issuance, a throwaway CertificateRequest for --dns-name is created for each issuer, in the issuer's
+namespace or in --namespace for cluster scoped issuers, and the time taken to issue it is reported. Test requests are
+deleted once they complete or --timeout passes. As the requests must be approved, approver-policy may need a policy
+allowing them. ACME issuers are only tested if --dns-name is set, as its domain must be validated.
+
+The command fails if any issuer has a problem or fails to issue a test certificate.`,
+		Example: `  jsctl issuers check
+  jsctl issuers check --test-issuance --namespace jetstack-secure`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "json", "yaml":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, json, yaml", outputFormat)
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			checks, err := inventory.CheckIssuers(ctx, kubeCfg, inventory.CheckOptions{
+				ClusterResourceNamespace: clusterResourceNamespace,
+				Now:                      time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			if testIssuance {
+				client, err := clients.NewCertificateRequestClient(kubeCfg)
+				if err != nil {
+					return fmt.Errorf("failed to create certificate request client: %w", err)
+				}
+
+				// ACME issuers must validate the domain, which cannot be done for the default DNS name
+				testACME := dnsName != ""
+				if dnsName == "" {
+					dnsName = defaultDNSName
+				}
+
+				for i := range checks {
+					if checks[i].Type == inventory.TypeACME && !testACME {
+						checks[i].Issuance = &inventory.Issuance{Skipped: true, Message: "ACME issuers are only tested with --dns-name"}
+						continue
+					}
+
+					issuanceCtx, cancel := context.WithTimeout(ctx, timeout)
+					issuance := inventory.CheckIssuance(issuanceCtx, client, checks[i].Issuer, namespace, dnsName, issuanceInterval)
+					cancel()
+					checks[i].Issuance = &issuance
+				}
+			}
+
+			failed := 0
+			for _, check := range checks {
+				if len(check.Problems) > 0 || (check.Issuance != nil && !check.Issuance.Succeeded && !check.Issuance.Skipped) {
+					failed++
+				}
+			}
+
+			switch outputFormat {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(checks); err != nil {
+					return err
+				}
+			case "yaml":
+				data, err := yaml.Marshal(checks)
+				if err != nil {
+					return fmt.Errorf("failed to marshal issuer checks: %w", err)
+				}
+				if _, err := os.Stdout.Write(data); err != nil {
+					return err
+				}
+			default:
+				if err := printChecks(checks, testIssuance); err != nil {
+					return err
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d issuers have problems", failed, len(checks))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, json, yaml")
+	flags.StringVar(&clusterResourceNamespace, "cluster-resource-namespace", "cert-manager", "The namespace cert-manager reads the Secrets of ClusterIssuers from")
+	flags.BoolVar(&testIssuance, "test-issuance", false, "Issue a test certificate with each issuer and report how long it took")
+	flags.StringVarP(&namespace, "namespace", "n", "default", "The namespace to create test CertificateRequests for cluster scoped issuers in")
+	flags.StringVar(&dnsName, "dns-name", "", fmt.Sprintf("The DNS name of test certificates, %s if not set", defaultDNSName))
+	flags.DurationVar(&timeout, "timeout", 2*time.Minute, "How long to wait for each test certificate to be issued")
+
+	return cmd
+}
+
+func printChecks(checks []inventory.IssuerCheck, testIssuance bool) error {
+	columns := []string{"KIND", "NAMESPACE", "NAME", "TYPE", "STATUS"}
+	if testIssuance {
+		columns = append(columns, "ISSUANCE")
+	}
+	tbl := table.NewBuilder(columns)
+
+	for _, check := range checks {
+		status := "ok"
+		if len(check.Problems) > 0 {
+			status = "error"
+		}
+
+		row := []interface{}{check.Kind, check.Namespace, check.Name, check.Type, status}
+		if check.Issuance != nil {
+			row = append(row, issuanceSummary(*check.Issuance))
+		}
+		tbl.AddRow(row...)
+	}
+
+	if err := tbl.Build(os.Stdout); err != nil {
+		return err
+	}
+
+	first := true
+	for _, check := range checks {
+		var lines []string
+		lines = append(lines, check.Problems...)
+		if check.Issuance != nil && !check.Issuance.Succeeded && !check.Issuance.Skipped {
+			lines = append(lines, "test issuance: "+check.Issuance.Message)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		if first {
+			fmt.Println("\nProblems:")
+			first = false
+		}
+		if check.Namespace != "" {
+			fmt.Printf("%s %s/%s:\n", check.Kind, check.Namespace, check.Name)
+		} else {
+			fmt.Printf("%s %s:\n", check.Kind, check.Name)
+		}
+		for _, line := range lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+func issuanceSummary(issuance inventory.Issuance) string {
+	switch {
+	case issuance.Skipped:
+		return "skipped"
+	case issuance.Succeeded:
+		return issuance.Latency
+	default:
+		return "failed"
+	}
+}
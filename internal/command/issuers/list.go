@@ -0,0 +1,107 @@
+package issuers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/inventory"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+// List returns a cobra.Command instance that lists issuers of all supported kinds in the current cluster.
+func List(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		outputFormat string
+		notReady     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List issuers of all supported kinds in all namespaces of the current cluster",
+		Long: `Lists cert-manager issuers and the external issuers supported by jsctl, showing whether each is ready and
+how many Certificates reference it.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			switch outputFormat {
+			case "table", "json", "yaml":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of: table, json, yaml", outputFormat)
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			issuers, err := inventory.ListIssuers(ctx, kubeCfg)
+			if err != nil {
+				return err
+			}
+
+			if notReady {
+				filtered := make([]inventory.Issuer, 0, len(issuers))
+				for _, issuer := range issuers {
+					if !issuer.IsReady() {
+						filtered = append(filtered, issuer)
+					}
+				}
+				issuers = filtered
+			}
+
+			switch outputFormat {
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(issuers)
+			case "yaml":
+				data, err := yaml.Marshal(issuers)
+				if err != nil {
+					return fmt.Errorf("failed to marshal issuers: %w", err)
+				}
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+
+			tbl := table.NewBuilder([]string{
+				"KIND",
+				"SCOPE",
+				"NAMESPACE",
+				"NAME",
+				"READY",
+				"REASON",
+				"AGE",
+				"CERTIFICATES",
+			})
+
+			for _, issuer := range issuers {
+				tbl.AddRow(
+					issuer.Kind,
+					issuer.Scope,
+					issuer.Namespace,
+					issuer.Name,
+					issuer.Ready,
+					issuer.Reason,
+					duration.HumanDuration(time.Since(issuer.Created)),
+					issuer.Certificates,
+				)
+			}
+
+			return tbl.Build(os.Stdout)
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&outputFormat, "output", "o", "table", "Output format, one of: table, json, yaml")
+	flags.BoolVar(&notReady, "not-ready", false, "Only list issuers that are not ready")
+
+	return cmd
+}
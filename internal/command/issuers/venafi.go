@@ -0,0 +1,569 @@
+package issuers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/table"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+// recentEvents is the number of events shown when describing an issuer.
+const recentEvents = 10
+
+// Venafi returns a cobra.Command instance that is the root for all "jsctl issuers venafi" subcommands.
+func Venafi(run types.RunFunc, kubeConfig *string, useStdout *bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "venafi",
+		Short: "Subcommands for managing venafi-enhanced-issuer VenafiIssuers and VenafiClusterIssuers",
+	}
+
+	cmd.AddCommand(
+		venafiCreate(run, kubeConfig, useStdout),
+		venafiList(run, kubeConfig),
+		venafiDescribe(run, kubeConfig),
+		venafiDelete(run, kubeConfig),
+	)
+
+	return cmd
+}
+
+func venafiCreate(run types.RunFunc, kubeConfig *string, useStdout *bool) *cobra.Command {
+	var (
+		namespace         string
+		issuerType        string
+		connection        string
+		connectionsConfig string
+		file              string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a VenafiIssuer, or a VenafiClusterIssuer if no namespace is given",
+		Long: `Creates a venafi-enhanced-issuer issuer that uses the server, zone and credentials of a Venafi connection, as
+used by --experimental-venafi-connections-config. The connection's credentials are stored in a Secret named
+'<name>-jsctl', in the issuer's namespace or in jetstack-secure for a VenafiClusterIssuer.
+
+Alternatively, a VenafiIssuer or VenafiClusterIssuer can be read from a file with --file, for issuers that obtain their
+credentials in ways a connection cannot describe, such as from HashiCorp Vault. Issuers are validated before they are
+created.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: run(func(ctx context.Context, args []string) error {
+			var issuer *unstructured.Unstructured
+			var secret *corev1.Secret
+			var err error
+
+			switch {
+			case file != "":
+				if len(args) > 0 || connection != "" {
+					return errors.New("a name and --connection cannot be used with --file, the issuer is read from the file")
+				}
+
+				var data []byte
+				data, err = os.ReadFile(file)
+				if err != nil {
+					return fmt.Errorf("failed to read issuer file: %w", err)
+				}
+
+				issuer, err = venafi.ParseEnhancedIssuer(data)
+				if err != nil {
+					return fmt.Errorf("invalid issuer in %s: %w", file, err)
+				}
+			case len(args) == 1:
+				if connection == "" || connectionsConfig == "" {
+					return errors.New("--connection and --connections-config must be provided")
+				}
+
+				var vcs map[string]*venafi.VenafiConnection
+				vcs, err = venafi.LoadConnections(connectionsConfig)
+				if err != nil {
+					return fmt.Errorf("error parsing Venafi connection config: %w", err)
+				}
+				vc, ok := vcs[connection]
+				if !ok || vc == nil {
+					return fmt.Errorf("connection %s not found in %s", connection, connectionsConfig)
+				}
+
+				issuer, secret, err = venafi.GenerateEnhancedIssuerManifests(venafi.EnhancedIssuerOptions{
+					Name:       args[0],
+					Namespace:  namespace,
+					IssuerType: issuerType,
+					Connection: vc,
+				})
+				if err != nil {
+					return fmt.Errorf("error generating issuer: %w", err)
+				}
+			default:
+				return errors.New("either an issuer name or --file must be provided")
+			}
+
+			manifests := bytes.NewBuffer([]byte{})
+			// Write the Secret first, so that it exists by the time the issuer reads it. Issuers read from a file
+			// reference their credentials themselves, so have no Secret.
+			objects := []interface{}{issuer}
+			if secret != nil {
+				objects = []interface{}{secret, issuer}
+			}
+			for _, object := range objects {
+				data, err := yaml.Marshal(object)
+				if err != nil {
+					return fmt.Errorf("failed to marshal manifests: %w", err)
+				}
+				manifests.WriteString("---\n")
+				manifests.Write(data)
+			}
+
+			var applier operator.Applier
+			if *useStdout {
+				applier = kubernetes.NewStdOutApplier()
+			} else {
+				applier, err = kubernetes.NewKubeConfigApplier(*kubeConfig)
+				if err != nil {
+					return fmt.Errorf("failed to set up Kubernetes client: %w", err)
+				}
+			}
+
+			if err := applier.Apply(ctx, manifests); err != nil {
+				return fmt.Errorf("failed to create issuer: %w", err)
+			}
+			if *useStdout {
+				return nil
+			}
+
+			fmt.Fprintf(os.Stderr, "Created %s %s\n", issuer.GetKind(), describeName(issuer.GetNamespace(), issuer.GetName()))
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "The namespace to create a VenafiIssuer in, a VenafiClusterIssuer is created if not set")
+	flags.StringVar(&issuerType, "type", "tpp", "The type of Venafi server the issuer uses, either 'tpp' or 'vaas'")
+	flags.StringVar(&connection, "connection", "", "The name of the Venafi connection in --connections-config for the issuer to use")
+	flags.StringVar(&connectionsConfig, "connections-config", "", "Specifies a path to a file with yaml formatted Venafi connection details, as passed to --experimental-venafi-connections-config")
+	flags.StringVarP(&file, "file", "f", "", "Specifies a path to a file containing a VenafiIssuer or VenafiClusterIssuer to create")
+
+	return cmd
+}
+
+func venafiList(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		namespace string
+		jsonOut   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List VenafiIssuers and VenafiClusterIssuers along with whether they are ready",
+		Args:  cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			issuers, err := listEnhancedIssuers(ctx, kubeCfg, namespace)
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(issuers)
+			}
+
+			tbl := table.NewBuilder([]string{
+				"KIND",
+				"NAMESPACE",
+				"NAME",
+				"SOURCE",
+				"READY",
+				"REASON",
+			})
+
+			for _, issuer := range issuers {
+				ready := readyCondition(issuer)
+				tbl.AddRow(issuer.GetKind(), issuer.GetNamespace(), issuer.GetName(), enhancedIssuerSource(issuer), ready.Status, ready.Reason)
+			}
+
+			return tbl.Build(os.Stdout)
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "Only list VenafiIssuers in this namespace, VenafiClusterIssuers are always listed")
+	flags.BoolVar(&jsonOut, "json", false, "Output issuers in JSON format")
+
+	return cmd
+}
+
+func venafiDescribe(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var namespace string
+
+	cmd := &cobra.Command{
+		Use:   "describe name",
+		Short: "Show the configuration, Ready condition and recent issuance events of a VenafiIssuer or VenafiClusterIssuer",
+		Args:  cobra.ExactArgs(1),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			issuer, err := getEnhancedIssuer(ctx, kubeCfg, namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Kind:       %s\n", issuer.GetKind())
+			fmt.Printf("Name:       %s\n", issuer.GetName())
+			if issuer.GetNamespace() != "" {
+				fmt.Printf("Namespace:  %s\n", issuer.GetNamespace())
+			}
+			fmt.Printf("Source:     %s\n", enhancedIssuerSource(issuer))
+			for _, field := range [][]string{
+				{"URL", "url"},
+				{"Policy DN", "policyDN"},
+				{"Application", "application"},
+				{"Template", "template"},
+			} {
+				value, _, _ := unstructured.NestedString(issuer.Object, "spec", enhancedIssuerSource(issuer), field[1])
+				if value != "" {
+					fmt.Printf("%-11s %s\n", field[0]+":", value)
+				}
+			}
+
+			ready := readyCondition(issuer)
+			fmt.Printf("Ready:      %s\n", ready.Status)
+			if ready.Reason != "" {
+				fmt.Printf("Reason:     %s\n", ready.Reason)
+			}
+			if ready.Message != "" {
+				fmt.Printf("Message:    %s\n", ready.Message)
+			}
+
+			events, err := issuanceEvents(ctx, kubeCfg, issuer)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Events:")
+			if len(events) == 0 {
+				fmt.Println("  <none>")
+				return nil
+			}
+
+			tbl := table.NewBuilder([]string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"})
+			for _, event := range events {
+				tbl.AddRow(
+					eventTime(event).Format(time.RFC3339),
+					event.Type,
+					event.Reason,
+					fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+					strings.TrimSpace(event.Message),
+				)
+			}
+
+			return tbl.Build(os.Stdout)
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "The namespace of the VenafiIssuer, a VenafiClusterIssuer is described if not set")
+
+	return cmd
+}
+
+func venafiDelete(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var namespace string
+
+	cmd := &cobra.Command{
+		Use:   "delete name",
+		Short: "Delete a VenafiIssuer or VenafiClusterIssuer, along with the credentials Secret jsctl created for it",
+		Args:  cobra.ExactArgs(1),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			issuerClient, err := enhancedIssuerClient(kubeCfg, namespace == "")
+			if err != nil {
+				return err
+			}
+
+			kind := venafi.EnhancedClusterIssuerKind
+			secretNamespace := "jetstack-secure"
+			if namespace != "" {
+				kind = venafi.EnhancedIssuerKind
+				secretNamespace = namespace
+			}
+
+			err = issuerClient.Delete(ctx, &clients.GenericRequestOptions{Name: args[0], Namespace: namespace})
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%s %s not found", kind, describeName(namespace, args[0]))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to delete %s: %w", kind, err)
+			}
+			fmt.Fprintf(os.Stderr, "Deleted %s %s\n", kind, describeName(namespace, args[0]))
+
+			secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+				&clients.GenericClientOptions{
+					RestConfig: kubeCfg,
+					APIPath:    "/api/",
+					Group:      corev1.GroupName,
+					Version:    corev1.SchemeGroupVersion.Version,
+					Kind:       "secrets",
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("error creating secrets client: %w", err)
+			}
+
+			// Only the Secret generated by jsctl is removed, Secrets created by users may be shared with other issuers
+			secretName := args[0] + "-jsctl"
+			var secret corev1.Secret
+			err = secretsClient.Get(ctx, &clients.GenericRequestOptions{Name: secretName, Namespace: secretNamespace}, &secret)
+			switch {
+			case apierrors.IsNotFound(err):
+				return nil
+			case err != nil:
+				return fmt.Errorf("failed to get credentials Secret: %w", err)
+			case secret.Labels[venafi.ManagedByLabel] != "jsctl":
+				return nil
+			}
+
+			err = secretsClient.Delete(ctx, &clients.GenericRequestOptions{Name: secretName, Namespace: secretNamespace})
+			if err != nil {
+				return fmt.Errorf("failed to delete credentials Secret: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Deleted Secret %s/%s\n", secretNamespace, secretName)
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&namespace, "namespace", "n", "", "The namespace of the VenafiIssuer, a VenafiClusterIssuer is deleted if not set")
+
+	return cmd
+}
+
+// enhancedIssuerClient returns a client for VenafiClusterIssuers or VenafiIssuers. Issuers are read as unstructured
+// objects, as only their spec and conditions are needed.
+func enhancedIssuerClient(kubeCfg *rest.Config, clusterScope bool) (clients.Generic[*unstructured.Unstructured, *unstructured.UnstructuredList], error) {
+	kind := "venafiissuers"
+	if clusterScope {
+		kind = "venaficlusterissuers"
+	}
+
+	client, err := clients.NewGenericClient[*unstructured.Unstructured, *unstructured.UnstructuredList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeCfg,
+			APIPath:    "/apis",
+			Group:      veiv1alpha1.SchemeGroupVersion.Group,
+			Version:    veiv1alpha1.SchemeGroupVersion.Version,
+			Kind:       kind,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating venafi enhanced issuer client: %w", err)
+	}
+
+	return client, nil
+}
+
+func getEnhancedIssuer(ctx context.Context, kubeCfg *rest.Config, namespace, name string) (*unstructured.Unstructured, error) {
+	client, err := enhancedIssuerClient(kubeCfg, namespace == "")
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &unstructured.Unstructured{}
+	err = client.Get(ctx, &clients.GenericRequestOptions{Name: name, Namespace: namespace}, issuer)
+	if apierrors.IsNotFound(err) {
+		kind := venafi.EnhancedClusterIssuerKind
+		if namespace != "" {
+			kind = venafi.EnhancedIssuerKind
+		}
+		return nil, fmt.Errorf("%s %s not found", kind, describeName(namespace, name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issuer: %w", err)
+	}
+
+	return issuer, nil
+}
+
+func listEnhancedIssuers(ctx context.Context, kubeCfg *rest.Config, namespace string) ([]*unstructured.Unstructured, error) {
+	var issuers []*unstructured.Unstructured
+
+	// VenafiIssuers are listed across all namespaces unless a namespace is given
+	for _, clusterScope := range []bool{true, false} {
+		client, err := enhancedIssuerClient(kubeCfg, clusterScope)
+		if err != nil {
+			return nil, err
+		}
+
+		listNamespace := ""
+		if !clusterScope {
+			listNamespace = namespace
+		}
+
+		var list unstructured.UnstructuredList
+		if err := client.List(ctx, &clients.GenericRequestOptions{Namespace: listNamespace}, &list); err != nil {
+			return nil, fmt.Errorf("failed to list issuers: %w", err)
+		}
+
+		for i := range list.Items {
+			issuers = append(issuers, &list.Items[i])
+		}
+	}
+
+	return issuers, nil
+}
+
+// enhancedIssuerSource returns the type of Venafi server the issuer uses, either 'tpp' or 'vaas'.
+func enhancedIssuerSource(issuer *unstructured.Unstructured) string {
+	for _, source := range []string{"tpp", "vaas"} {
+		if _, ok, _ := unstructured.NestedMap(issuer.Object, "spec", source); ok {
+			return source
+		}
+	}
+
+	return "unknown"
+}
+
+type condition struct {
+	Status  string
+	Reason  string
+	Message string
+}
+
+// readyCondition returns the Ready condition of the object, or an Unknown status if it has not been set.
+func readyCondition(object *unstructured.Unstructured) condition {
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, raw := range conditions {
+		c, ok := raw.(map[string]interface{})
+		if !ok || c["type"] != "Ready" {
+			continue
+		}
+
+		ready := condition{Status: "Unknown"}
+		if status, ok := c["status"].(string); ok {
+			ready.Status = status
+		}
+		ready.Reason, _ = c["reason"].(string)
+		ready.Message, _ = c["message"].(string)
+		return ready
+	}
+
+	return condition{Status: "Unknown"}
+}
+
+// issuanceEvents returns the most recent events for the issuer itself and for the CertificateRequests it has
+// handled, newest last.
+func issuanceEvents(ctx context.Context, kubeCfg *rest.Config, issuer *unstructured.Unstructured) ([]corev1.Event, error) {
+	requestClient, err := clients.NewCertificateRequestClient(kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating certificate request client: %w", err)
+	}
+
+	var requests cmapi.CertificateRequestList
+	err = requestClient.List(ctx, &clients.GenericRequestOptions{Namespace: issuer.GetNamespace()}, &requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificate requests: %w", err)
+	}
+
+	involved := map[string]bool{
+		issuer.GetKind() + "/" + issuer.GetNamespace() + "/" + issuer.GetName(): true,
+	}
+	for _, request := range requests.Items {
+		ref := request.Spec.IssuerRef
+		if ref.Group == veiv1alpha1.SchemeGroupVersion.Group && ref.Kind == issuer.GetKind() && ref.Name == issuer.GetName() {
+			involved["CertificateRequest/"+request.Namespace+"/"+request.Name] = true
+		}
+	}
+
+	eventsClient, err := clients.NewGenericClient[*corev1.Event, *corev1.EventList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeCfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "events",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating events client: %w", err)
+	}
+
+	var events []corev1.Event
+	for _, kind := range []string{issuer.GetKind(), "CertificateRequest"} {
+		var list corev1.EventList
+		err = eventsClient.List(ctx, &clients.GenericRequestOptions{
+			Namespace:     issuer.GetNamespace(),
+			FieldSelector: "involvedObject.kind=" + kind,
+		}, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range list.Items {
+			object := event.InvolvedObject
+			if involved[object.Kind+"/"+object.Namespace+"/"+object.Name] {
+				events = append(events, event)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > recentEvents {
+		events = events[len(events)-recentEvents:]
+	}
+
+	return events, nil
+}
+
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func describeName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "/" + name
+}
@@ -21,6 +21,7 @@ the operator with "jsctl operator deploy --help"`,
 	cmd.AddCommand(
 		operator.Deploy(run, &useStdout, &apiURL, &kubeConfig),
 		operator.Versions(run),
+		operator.Bundle(run),
 		operatorInstallations(),
 	)
 
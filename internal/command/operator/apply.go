@@ -1,14 +1,17 @@
 package operator
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
 
 	"github.com/jetstack/jsctl/internal/client"
 	internalerrors "github.com/jetstack/jsctl/internal/command/errors"
@@ -16,10 +19,12 @@ import (
 	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
 	"github.com/jetstack/jsctl/internal/kubernetes/restore"
 	"github.com/jetstack/jsctl/internal/operator"
 	"github.com/jetstack/jsctl/internal/prompt"
 	"github.com/jetstack/jsctl/internal/registry"
+	"github.com/jetstack/jsctl/internal/table"
 	"github.com/jetstack/jsctl/internal/venafi"
 )
 
@@ -46,6 +51,10 @@ func InstallationsApply(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *
 		venafiIssuers                 []string
 		venafiOauthHelper             bool
 		backupFilePath                string
+		externalIssuersTimeout        time.Duration
+		componentOverridesPath        string
+		componentOverrides            map[string]operator.ComponentOverride
+		patchPaths                    []string
 	)
 
 	validator := func() error {
@@ -70,6 +79,38 @@ func InstallationsApply(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *
 			}
 		}
 
+		// replica counts are only checked for the components that are installed
+		replicas := map[string]int{
+			"--cert-manager-replicas": certManagerReplicas,
+		}
+		if csiDriverSpiffe {
+			replicas["--csi-driver-spiffe-replicas"] = csiDriverSpiffeReplicas
+		}
+		if istioCSR {
+			replicas["--istio-csr-replicas"] = istioCSRReplicas
+		}
+		for flag, count := range replicas {
+			if count < 1 {
+				return fmt.Errorf("%s must be at least 1, got %d", flag, count)
+			}
+		}
+
+		if componentOverridesPath != "" {
+			data, err := os.ReadFile(componentOverridesPath)
+			if err != nil {
+				return fmt.Errorf("failed to read component overrides file: %w", err)
+			}
+
+			componentOverrides, err = operator.ParseComponentOverrides(data)
+			if err != nil {
+				return err
+			}
+
+			if err := operator.ValidateComponentOverrides(componentOverrides); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}
 
@@ -119,6 +160,11 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				fmt.Fprintf(os.Stderr, "This can be done using cmctl convert, see here for more information: https://cert-manager.io/docs/reference/cmctl/#convert\n")
 			}
 
+			patches, err := patch.LoadFiles(patchPaths)
+			if err != nil {
+				return fmt.Errorf("failed to load patches: %w", err)
+			}
+
 			options := operator.ApplyInstallationYAMLOptions{
 				ImageRegistry:           operatorImageRegistry,
 				RegistryCredentialsPath: registryCredentialsPath,
@@ -142,6 +188,12 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				// Approver Policy configuration
 				InstallApproverPolicyEnterprise: false,
 
+				// Per-component settings, taking precedence over the flags above
+				ComponentOverrides: componentOverrides,
+
+				// Patches to the generated resources
+				Patches: patches,
+
 				// Restored Issuers
 				ImportedCertManagerIssuers:        issuers.CertManagerIssuers,
 				ImportedCertManagerClusterIssuers: issuers.CertManagerClusterIssuers,
@@ -153,7 +205,7 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				options.InstallApproverPolicyEnterprise = true
 			}
 
-			vcs, err := parseVenafiConnections(venafiConnections)
+			vcs, err := venafi.LoadConnections(venafiConnections)
 			if err != nil {
 				return fmt.Errorf("error parsing Venafi connection config: %w", err)
 			}
@@ -171,12 +223,13 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 			options.CertDiscoveryVenafi = cdv
 
 			var applier operator.Applier
+			var kubeCfg *rest.Config
 			if *useStdout {
 				applier = kubernetes.NewStdOutApplier()
 			} else {
 				// before starting the application of the installation instance,
 				// we can check if the installation CRD is present
-				kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+				kubeCfg, err = kubernetes.NewConfig(*kubeConfig)
 				if err != nil {
 					return err
 				}
@@ -205,6 +258,12 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				return fmt.Errorf("failed to apply component manifests: %w", err)
 			}
 
+			if len(issuers.ExternalIssuers) != 0 {
+				if err := applyExternalIssuers(ctx, applier, kubeCfg, issuers.ExternalIssuers, externalIssuersTimeout); err != nil {
+					return err
+				}
+			}
+
 			suggestions := operator.SuggestedActions(options)
 			if len(suggestions) == 0 {
 				return nil
@@ -224,30 +283,90 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 	flags.IntVar(&certManagerReplicas, "cert-manager-replicas", 2, "Specifies the number of replicas for the cert-manager deployment")
 	flags.IntVar(&csiDriverSpiffeReplicas, "csi-driver-spiffe-replicas", 2, "Specifies the number of replicas for the csi-driver-spiffe deployment")
 	flags.IntVar(&istioCSRReplicas, "istio-csr-replicas", 2, "Specifies the number of replicas for the istio-csr deployment")
-	flags.StringSliceVar(&venafiIssuers, "experimental-venafi-issuers", []string{}, "Specifies a list of Venafi issuers to configure. Issuer names should be in form 'type:connection:name:[namespace]'. Type can be 'tpp', connection refers to a Venafi connection (see --experimental-venafi-connection flag), name is the name of the issuer and namespace is the namespace in which to create the issuer. Leave out namepsace to create a cluster scoped issuer. This flag is experimental and is likely to change.")
+	flags.StringSliceVar(&venafiIssuers, "experimental-venafi-issuers", []string{}, "Specifies a list of Venafi issuers to configure. Issuer names should be in form 'type:connection:name:[namespace]'. Type can be 'tpp' or 'vaas', connection refers to a Venafi connection (see --experimental-venafi-connection flag), name is the name of the issuer and namespace is the namespace in which to create the issuer. Leave out namepsace to create a cluster scoped issuer. This flag is experimental and is likely to change.")
 	flags.StringVar(&certDiscoveryVenafiConnection, "experimental-cert-discovery-venafi-connection", "", "The name of the Venafi connection provided via --experimental-venafi-connections-config flag, to be used to configure cert-discovery-venafi")
 	flags.StringVar(&certManagerVersion, "cert-manager-version", "", "Specifies the version of cert-manager deployment. Defaults to latest")
 	flags.StringVar(&istioCSRIssuer, "istio-csr-issuer", "", "Specifies the cert-manager issuer that the Istio CSR should use")
 	flags.StringVar(&operatorImageRegistry, "registry", "", "Specifies the image registry to use for the operator's components")
 	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for image pull secrets")
-	flags.StringVar(&venafiConnections, "experimental-venafi-connections-config", "", "Specifies a path to a file with yaml formatted Venafi connection details")
+	flags.StringVar(&venafiConnections, "experimental-venafi-connections-config", "", "Specifies a path to a file with yaml formatted Venafi connection details. Credentials can be read from elsewhere by writing them as 'env:VAR', 'file:/path' or 'exec:command args' (with shell style quoting), or an existing Secret containing them can be named with 'credentials-secret'")
 	flags.StringVar(&tier, "tier", "", "For users with access to enterprise tier functionality, setting this flag will enable enterprise defaults instead. Valid values are 'enterprise', 'enterprise-plus' or blank")
-	flags.StringVar(&backupFilePath, "experimental-issuers-backup-file", "", "Provide a file containing cert-manager.io/v1 Issuers or ClusterIssuers definitions to be added to Installation and to be managed by the operator. Note: only cert-manager.io/v1 Issuers and ClusterIssuers are currently supported. Support for other issuer groups and versions will be added in future.")
+	flags.StringVar(&componentOverridesPath, "component-overrides", "", "Specifies a path to a file with yaml formatted settings for individual components, keyed by component name. Each component supports 'replicas' and 'version', as far as the operator's Installation resource allows. Resources, node selectors, tolerations, affinity, priority classes and pod disruption budgets are not supported by the Installation resource and are rejected")
+	flags.StringArrayVar(&patchPaths, "patch", []string{}, "Path to a file of JSON6902 or strategic merge patches to apply to the generated resources before they are applied. Can be specified multiple times, patches are applied in order")
+	flags.DurationVar(&externalIssuersTimeout, "external-issuers-timeout", 5*time.Minute, "How long to wait for the CRDs and controllers of issuers in --experimental-issuers-backup-file which are not managed by the operator to be ready before restoring them")
+	flags.StringVar(&backupFilePath, "experimental-issuers-backup-file", "", "Provide a file containing cert-manager.io/v1 Issuers or ClusterIssuers definitions to be added to Installation and to be managed by the operator. Note: only cert-manager.io/v1 and Venafi Issuers and ClusterIssuers can be managed by the operator. Issuers of other supported external issuer types are restored separately once their CRDs and controllers are ready, see --external-issuers-timeout.")
 
 	return cmd
 }
 
-func parseVenafiConnections(configPath string) (map[string]*venafi.VenafiConnection, error) {
-	if configPath == "" {
-		return nil, nil
+// applyExternalIssuers applies the issuers that the operator does not manage. When writing to stdout, they are written
+// after the Installation so that they can be applied once their controllers are installed. Otherwise, they are
+// restored to the cluster once the CRDs and controllers of their types are ready, or the timeout expires, and the
+// result for each issuer is reported. Issuers which could not be restored, including those whose types are still not
+// served, are an error.
+func applyExternalIssuers(ctx context.Context, applier operator.Applier, kubeCfg *rest.Config, issuers []*unstructured.Unstructured, timeout time.Duration) error {
+	if kubeCfg == nil {
+		data, err := restore.ManifestsYAML(issuers)
+		if err != nil {
+			return fmt.Errorf("failed to marshal external issuers: %w", err)
+		}
+
+		names := make([]string, 0, len(issuers))
+		for _, issuer := range issuers {
+			names = append(names, fmt.Sprintf("%s/%s", issuer.GetKind(), issuer.GetName()))
+		}
+		fmt.Fprintf(os.Stderr, "The following issuers are not managed by the operator and are output after the Installation, apply them once their controllers are installed: %s\n", strings.Join(names, ", "))
+
+		return applier.Apply(ctx, bytes.NewReader(data))
+	}
+
+	restorer, err := restore.NewClusterRestorer(kubeCfg)
+	if err != nil {
+		return err
+	}
+
+	waiter, err := restore.NewIssuerTypesWaiter(kubeCfg)
+	if err != nil {
+		return err
 	}
-	vcs := make(map[string]*venafi.VenafiConnection)
-	file, err := os.Open(configPath)
+
+	fmt.Fprintf(os.Stderr, "Waiting up to %s for the CRDs and controllers of the issuers not managed by the operator to be ready\n", timeout)
+	notReady, err := waiter.Wait(ctx, issuers, timeout)
 	if err != nil {
-		return nil, fmt.Errorf("error opening config file: %w", err)
+		return fmt.Errorf("failed to wait for external issuer types: %w", err)
 	}
-	if err = yaml.NewDecoder(file).Decode(&vcs); err != nil {
-		return nil, fmt.Errorf("error decoding connection configuration: %w", err)
+	for _, reason := range notReady {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", reason)
 	}
-	return vcs, nil
+
+	// the CRDs may have been installed since the restorer discovered the served resource types
+	if err := restorer.Refresh(); err != nil {
+		return err
+	}
+
+	report, err := restorer.Restore(ctx, issuers, restore.ClusterRestoreOptions{SkipMissingTypes: true})
+	if err != nil {
+		return fmt.Errorf("failed to restore external issuers: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "The following issuers are not managed by the operator and were restored separately:\n")
+	tbl := table.NewBuilder([]string{"KIND", "NAMESPACE", "NAME", "RESULT", "MESSAGE"})
+	for _, resource := range report.Resources {
+		tbl.AddRow(resource.Kind, resource.Namespace, resource.Name, resource.Result, resource.Message)
+	}
+	if err := tbl.Build(os.Stderr); err != nil {
+		return err
+	}
+
+	skipped, failed := report.Count(restore.ResultSkipped), report.Count(restore.ResultFailed)
+	switch {
+	case skipped != 0 && failed != 0:
+		return fmt.Errorf("%d external issuers could not be restored and %d were skipped as their types are not served by the cluster, install their controllers and run this command again", failed, skipped)
+	case skipped != 0:
+		return fmt.Errorf("%d external issuers were skipped as their types are not served by the cluster, install their controllers and run this command again", skipped)
+	case failed != 0:
+		return fmt.Errorf("%d external issuers could not be restored", failed)
+	}
+
+	return nil
 }
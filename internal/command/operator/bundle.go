@@ -0,0 +1,167 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+func Bundle(run types.RunFunc) *cobra.Command {
+	var (
+		certDiscoveryVenafi bool
+		certManagerVersion  string
+		componentVersions   map[string]string
+		csiDriver           bool
+		csiDriverSpiffe     bool
+		imageRegistry       string
+		istioCSR            bool
+		mirrorRegistry      string
+		outputPath          string
+		scriptFormat        string
+		scriptPath          string
+		tier                string
+		venafiOauthHelper   bool
+		version             string
+		manifestSource      manifestSourceFlags
+	)
+
+	validator := func() error {
+		if tier != "" && tier != tierEnterprise && tier != tierEnterprisePlus {
+			return fmt.Errorf("invalid tier %q, must be either %q, %q or blank", tier, tierEnterprise, tierEnterprisePlus)
+		}
+
+		if scriptFormat != "" && mirrorRegistry == "" {
+			return errors.New("--mirror-registry must be set when generating a sync script with --script-format")
+		}
+
+		if scriptFormat != "" && scriptFormat != operator.ScriptFormatSkopeo && scriptFormat != operator.ScriptFormatCrane {
+			return fmt.Errorf("invalid script format %q, must be either %q or %q", scriptFormat, operator.ScriptFormatSkopeo, operator.ScriptFormatCrane)
+		}
+
+		if scriptPath != "" && scriptFormat == "" {
+			return errors.New("--script-format must be set when --script-output is provided")
+		}
+
+		return nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Lists all container images needed to install the operator and its components, for mirroring to an air-gapped registry",
+		Long: `Lists all container images needed to install the operator and its components, for mirroring to an air-gapped registry
+
+The images of the operator itself are read from the installer manifest of the given version. Component images are
+determined from the Installation that would be generated by 'jsctl operator installations apply' with the same flags.
+
+Component versions default to those documented by the operator's Installation CRD for the given operator version, use
+--component-versions to mirror a different version of a component.
+
+Once the images have been mirrored, use --registry with 'jsctl operator deploy' and 'jsctl operator installations apply'
+to use them.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if err := validator(); err != nil {
+				return fmt.Errorf("error validating provided flags: %w", err)
+			}
+
+			source, err := manifestSource.source("")
+			if err != nil {
+				return fmt.Errorf("failed to configure manifest source: %w", err)
+			}
+
+			options := operator.BundleImagesOptions{
+				Version:           version,
+				ImageRegistry:     imageRegistry,
+				ComponentVersions: componentVersions,
+				Source:            source,
+				Installation: operator.ApplyInstallationYAMLOptions{
+					CertManagerVersion:              certManagerVersion,
+					InstallCSIDriver:                csiDriver,
+					InstallSpiffeCSIDriver:          csiDriverSpiffe,
+					InstallIstioCSR:                 istioCSR,
+					InstallVenafiOauthHelper:        venafiOauthHelper,
+					InstallApproverPolicyEnterprise: tier == tierEnterprisePlus,
+				},
+			}
+
+			// the connection details are not needed to determine the images, only that the component is enabled
+			if certDiscoveryVenafi {
+				options.Installation.CertDiscoveryVenafi = &venafi.VenafiConnection{}
+			}
+
+			images, err := operator.BundleImages(ctx, options)
+			switch {
+			case errors.Is(err, operator.ErrNoManifest):
+				return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", version)
+			case err != nil:
+				return fmt.Errorf("failed to determine bundle images: %w", err)
+			}
+
+			var output io.Writer = os.Stdout
+			if outputPath != "" {
+				file, err := os.Create(outputPath)
+				if err != nil {
+					return fmt.Errorf("failed to create image list file: %w", err)
+				}
+				defer file.Close()
+				output = file
+			}
+
+			if err := operator.WriteImageList(output, images); err != nil {
+				return fmt.Errorf("failed to write image list: %w", err)
+			}
+
+			if scriptFormat == "" {
+				return nil
+			}
+
+			var script io.Writer = os.Stdout
+			if scriptPath != "" {
+				file, err := os.OpenFile(scriptPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+				if err != nil {
+					return fmt.Errorf("failed to create sync script file: %w", err)
+				}
+				defer file.Close()
+				script = file
+			}
+
+			if err := operator.WriteSyncScript(script, scriptFormat, images, mirrorRegistry); err != nil {
+				return fmt.Errorf("failed to write sync script: %w", err)
+			}
+
+			if scriptPath != "" {
+				fmt.Fprintf(os.Stderr, "Sync script written to %s\n", scriptPath)
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&version, "version", "", "Specifies the version of the operator to bundle, defaults to latest")
+	flags.StringVar(&imageRegistry, "registry", operator.DefaultImageRegistry, "Specifies the image registry that the images are mirrored from")
+	flags.StringVar(&mirrorRegistry, "mirror-registry", "", "Specifies the image registry that the images are mirrored to, required when generating a sync script")
+	flags.StringVar(&outputPath, "output", "", "Specifies a file to write the image list to, defaults to stdout")
+	flags.StringVar(&scriptFormat, "script-format", "", "If set, a script that copies the images to --mirror-registry is generated. Valid values are 'skopeo' or 'crane'")
+	flags.StringVar(&scriptPath, "script-output", "", "Specifies a file to write the sync script to, defaults to stdout")
+	flags.StringToStringVar(&componentVersions, "component-versions", map[string]string{}, fmt.Sprintf("Pins the image versions of components, in the form component=version. Valid components are: %s", strings.Join(operator.Components(), ", ")))
+	flags.BoolVar(&certDiscoveryVenafi, "cert-discovery-venafi", false, "Include cert-discovery-venafi images")
+	flags.BoolVar(&csiDriver, "csi-driver", false, "Include cert-manager CSI driver images")
+	flags.BoolVar(&csiDriverSpiffe, "csi-driver-spiffe", false, "Include cert-manager spiffe CSI driver images")
+	flags.BoolVar(&istioCSR, "istio-csr", false, "Include cert-manager Istio CSR images")
+	flags.BoolVar(&venafiOauthHelper, "venafi-oauth-helper", false, "Include venafi-oauth-helper images")
+	flags.StringVar(&certManagerVersion, "cert-manager-version", "", "Specifies the version of cert-manager images to bundle")
+	manifestSource.register(flags)
+	flags.StringVar(&tier, "tier", "", "For users with access to enterprise tier functionality, setting this flag will bundle the images for enterprise defaults instead. Valid values are 'enterprise', 'enterprise-plus' or blank")
+
+	return cmd
+}
@@ -13,18 +13,19 @@ import (
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
 	"github.com/jetstack/jsctl/internal/operator"
 	"github.com/jetstack/jsctl/internal/registry"
 )
 
 func Deploy(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *string) *cobra.Command {
-	const defaultRegistry = "eu.gcr.io/jetstack-secure-enterprise"
-
 	var (
 		operatorImageRegistry        string
 		registryCredentialsPath      string
 		autoFetchRegistryCredentials bool
 		version                      string
+		manifestSource               manifestSourceFlags
+		patchPaths                   []string
 	)
 
 	validator := func() error {
@@ -58,58 +59,117 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				}
 			}
 
-			var registryCredentials string
-			if registryCredentialsPath == "" && autoFetchRegistryCredentials {
-				cnf, ok := config.FromContext(ctx)
-				if !ok || cnf.Organization == "" {
-					return internalerrors.ErrNoOrganizationName
-				}
-
-				http := client.New(ctx, *apiURL)
-
-				registryCredentialsBytes, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, http)
-				if err != nil {
-					return fmt.Errorf("failed to fetch or load registry credentials: %s", err)
-				}
-
-				registryCredentials = string(registryCredentialsBytes)
-			}
-			if registryCredentials == "" && registryCredentialsPath != "" {
-				registryCredentialsBytes, err := os.ReadFile(registryCredentialsPath)
-				if err != nil {
-					return fmt.Errorf("failed to read registry credentials file: %s", err)
-				}
-				registryCredentials = string(registryCredentialsBytes)
-			}
-			// warn the user if no credentials are set by this point
-			if registryCredentials == "" {
-				fmt.Fprint(os.Stderr, "Note: no image pull credentials specified, the operator will be deployed without an image pull secret. If operator images are not present or accessible then the operator will be unable to start.\n")
+			patches, err := patch.LoadFiles(patchPaths)
+			if err != nil {
+				return fmt.Errorf("failed to load patches: %w", err)
 			}
 
-			err = operator.ApplyOperatorYAML(ctx, applier, operator.ApplyOperatorYAMLOptions{
-				Version:             version,
-				ImageRegistry:       operatorImageRegistry,
-				RegistryCredentials: registryCredentials,
+			return DeployOperator(ctx, applier, DeployOptions{
+				Version:                      version,
+				ImageRegistry:                operatorImageRegistry,
+				RegistryCredentialsPath:      registryCredentialsPath,
+				AutoFetchRegistryCredentials: autoFetchRegistryCredentials,
+				APIURL:                       *apiURL,
+				Source:                       manifestSource.source,
+				Patches:                      patches,
 			})
-
-			switch {
-			case errors.Is(err, operator.ErrNoManifest):
-				return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", version)
-			case errors.Is(err, operator.ErrNoKeyFile):
-				return fmt.Errorf("no key file exists at %s", registryCredentialsPath)
-			case err != nil:
-				return fmt.Errorf("failed to apply operator manifests: %s", err)
-			}
-
-			return nil
 		}),
 	}
 
 	flags := cmd.PersistentFlags()
 	flags.BoolVar(&autoFetchRegistryCredentials, "auto-registry-credentials", false, "If set, then credentials to pull images from the Jetstack Secure Enterprise registry will be automatically fetched")
-	flags.StringVar(&operatorImageRegistry, "registry", defaultRegistry, "Specifies an alternative image registry to use for js-operator and cainjector images")
+	flags.StringVar(&operatorImageRegistry, "registry", operator.DefaultImageRegistry, "Specifies an alternative image registry to use for js-operator and cainjector images")
 	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for docker image pull secrets")
 	flags.StringVar(&version, "version", "", "Specifies a specific version of the operator to install, defaults to latest")
+	manifestSource.register(flags)
+	flags.StringArrayVar(&patchPaths, "patch", []string{}, "Path to a file of JSON6902 or strategic merge patches to apply to the generated resources before they are applied. Can be specified multiple times, patches are applied in order")
 
 	return cmd
 }
+
+// DeployOptions configure how DeployOperator deploys the operator.
+type DeployOptions struct {
+	// Version is the version of the operator to deploy, defaults to the latest
+	Version string
+	// ImageRegistry is the registry of the operator's images, defaults to operator.DefaultImageRegistry
+	ImageRegistry string
+
+	// RegistryCredentialsPath is the path of the registry credentials to create an image pull secret from. If it is
+	// not set and AutoFetchRegistryCredentials is, the credentials are fetched from the API at APIURL instead.
+	RegistryCredentialsPath      string
+	AutoFetchRegistryCredentials bool
+	APIURL                       string
+
+	// Source, if set, returns the source of the installer manifests given the registry credentials. If it is not set
+	// or returns nil, the manifests embedded in jsctl are used.
+	Source func(registryCredentials string) (operator.ManifestSource, error)
+	// Patches are applied to the generated resources before they are applied.
+	Patches []*patch.Patch
+}
+
+// DeployOperator resolves the registry credentials and applies the operator's manifests, reporting errors in terms of
+// the flags of the commands that deploy the operator.
+func DeployOperator(ctx context.Context, applier operator.Applier, opts DeployOptions) error {
+	var registryCredentials string
+	if opts.RegistryCredentialsPath == "" && opts.AutoFetchRegistryCredentials {
+		cnf, ok := config.FromContext(ctx)
+		if !ok || cnf.Organization == "" {
+			return internalerrors.ErrNoOrganizationName
+		}
+
+		http := client.New(ctx, opts.APIURL)
+
+		registryCredentialsBytes, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, http)
+		if err != nil {
+			return fmt.Errorf("failed to fetch or load registry credentials: %s", err)
+		}
+
+		registryCredentials = string(registryCredentialsBytes)
+	}
+	if registryCredentials == "" && opts.RegistryCredentialsPath != "" {
+		registryCredentialsBytes, err := os.ReadFile(opts.RegistryCredentialsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read registry credentials file: %s", err)
+		}
+		registryCredentials = string(registryCredentialsBytes)
+	}
+	// warn the user if no credentials are set by this point
+	if registryCredentials == "" {
+		fmt.Fprint(os.Stderr, "Note: no image pull credentials specified, the operator will be deployed without an image pull secret. If operator images are not present or accessible then the operator will be unable to start.\n")
+	}
+
+	var source operator.ManifestSource
+	if opts.Source != nil {
+		var err error
+		source, err = opts.Source(registryCredentials)
+		if err != nil {
+			return fmt.Errorf("failed to configure manifest source: %w", err)
+		}
+	}
+
+	imageRegistry := opts.ImageRegistry
+	if imageRegistry == "" {
+		imageRegistry = operator.DefaultImageRegistry
+	}
+
+	err := operator.ApplyOperatorYAML(ctx, applier, operator.ApplyOperatorYAMLOptions{
+		Version:             opts.Version,
+		ImageRegistry:       imageRegistry,
+		RegistryCredentials: registryCredentials,
+		Source:              source,
+		Patches:             opts.Patches,
+	})
+
+	switch {
+	case errors.Is(err, operator.ErrNoManifest):
+		return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", opts.Version)
+	case errors.Is(err, operator.ErrManifestVerification):
+		return fmt.Errorf("failed to verify operator manifests: %w", err)
+	case errors.Is(err, operator.ErrNoKeyFile):
+		return fmt.Errorf("no key file exists at %s", opts.RegistryCredentialsPath)
+	case err != nil:
+		return fmt.Errorf("failed to apply operator manifests: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/registry"
+)
+
+// manifestSourceFlags contains the flags used by commands that read the operator's installer manifests.
+type manifestSourceFlags struct {
+	location        string
+	publicKeyPath   string
+	credentialsPath string
+	insecure        bool
+}
+
+func (f *manifestSourceFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&f.location, "manifest-source", "", "Location of operator installer manifests to use instead of those built into jsctl. Can be a local directory, an https:// URL or an oci:// artifact reference, which must contain a SHA256SUMS file listing each <version>.yaml manifest")
+	flags.StringVar(&f.publicKeyPath, "manifest-public-key", "", "Path to a PEM encoded ed25519 public key used to verify the SHA256SUMS.sig signature of --manifest-source. Required for https:// and oci:// sources unless --insecure-skip-manifest-verification is set")
+	flags.StringVar(&f.credentialsPath, "manifest-source-credentials-path", "", "Path to a registry credentials file used to pull an oci:// --manifest-source. Defaults to the registry credentials of the command, if it has any")
+	flags.BoolVar(&f.insecure, "insecure-skip-manifest-verification", false, "If set, https:// and oci:// manifest sources can be used without --manifest-public-key. Their manifests are then only checked against the SHA256SUMS file fetched from the same location, which does not prove where they came from")
+}
+
+// source returns the ManifestSource described by the flags, or nil if the embedded manifests should be used.
+// registryCredentials are the registry credentials of the command, if it has any, which are used to pull oci://
+// sources unless --manifest-source-credentials-path is set.
+func (f *manifestSourceFlags) source(registryCredentials string) (operator.ManifestSource, error) {
+	if f.location == "" {
+		if f.publicKeyPath != "" {
+			return nil, fmt.Errorf("--manifest-public-key can only be used with --manifest-source")
+		}
+		return nil, nil
+	}
+
+	options := operator.ManifestSourceOptions{InsecureSkipVerification: f.insecure}
+	if f.publicKeyPath != "" {
+		data, err := os.ReadFile(f.publicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest public key: %w", err)
+		}
+
+		options.PublicKey, err = operator.ParsePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+	} else if f.insecure {
+		fmt.Fprintf(os.Stderr, "Warning: --insecure-skip-manifest-verification is set, manifests from %s are not verified to come from a trusted publisher\n", f.location)
+	}
+
+	if f.credentialsPath != "" {
+		data, err := os.ReadFile(f.credentialsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest source credentials: %w", err)
+		}
+		registryCredentials = string(data)
+	}
+	if registryCredentials != "" {
+		credentials := registry.JetstackSecureEnterpriseCredentials([]byte(registryCredentials))
+		options.RegistryCredentials = &credentials
+	}
+
+	source, err := operator.NewManifestSource(f.location, options)
+	if errors.Is(err, operator.ErrNoPublicKey) {
+		return nil, fmt.Errorf("--manifest-public-key must be set to verify manifests from %s, or --insecure-skip-manifest-verification to use them unverified", f.location)
+	}
+
+	return source, err
+}
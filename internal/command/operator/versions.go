@@ -11,12 +11,22 @@ import (
 )
 
 func Versions(run types.RunFunc) *cobra.Command {
-	return &cobra.Command{
+	var manifestSource manifestSourceFlags
+
+	cmd := &cobra.Command{
 		Use:   "versions",
 		Short: "Outputs all available versions of the jetstack operator",
 		Args:  cobra.ExactArgs(0),
 		Run: run(func(ctx context.Context, args []string) error {
-			versions, err := operator.Versions()
+			source, err := manifestSource.source("")
+			if err != nil {
+				return fmt.Errorf("failed to configure manifest source: %w", err)
+			}
+			if source == nil {
+				source = operator.EmbeddedManifests()
+			}
+
+			versions, err := source.Versions(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to get operator versions: %w", err)
 			}
@@ -28,4 +38,8 @@ func Versions(run types.RunFunc) *cobra.Command {
 			return nil
 		}),
 	}
+
+	manifestSource.register(cmd.PersistentFlags())
+
+	return cmd
 }
@@ -0,0 +1,22 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/policy"
+)
+
+// Policy returns a cobra.Command instance that is the root for all "jsctl policy" subcommands.
+func Policy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "policy",
+		Aliases: []string{"policies"},
+		Short:   "Subcommands for authoring approver-policy CertificateRequestPolicies",
+	}
+
+	cmd.AddCommand(
+		policy.Test(run, &kubeConfig),
+	)
+
+	return cmd
+}
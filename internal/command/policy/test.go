@@ -0,0 +1,202 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/certificates"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+// Test returns a cobra.Command instance that evaluates CertificateRequestPolicies against a request locally.
+func Test(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		file        string
+		policyFiles []string
+		jsonOut     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Check which CertificateRequestPolicies would approve a CertificateRequest or Certificate",
+		Long: `Evaluates approver-policy CertificateRequestPolicies against the CertificateRequest or Certificate in --file,
+without creating anything in the cluster. For a Certificate, the request cert-manager would create for it is
+evaluated, using cert-manager's default private key if none is set. Policies are read from --policy-file, which can be
+given more than once, or from the current cluster if it is not set.
+
+Each policy is shown along with whether its issuerRef selector matches the request and, if it does, every allowed
+attribute or constraint that the request does not satisfy. Plugins such as rego cannot be evaluated locally, nor can
+whether a policy is bound to the requester by RBAC, so policies using plugins are reported as approving only if the
+rest of the policy does. The command fails if no policy would approve the request.`,
+		Example: `  jsctl policy test -f certificate.yaml
+  jsctl policy test -f request.yaml --policy-file policy.yaml`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if file == "" {
+				return errors.New("a CertificateRequest or Certificate must be provided with --file")
+			}
+
+			request, err := loadRequest(file)
+			if err != nil {
+				return err
+			}
+
+			var policies []policyapi.CertificateRequestPolicy
+			if len(policyFiles) > 0 {
+				for _, policyFile := range policyFiles {
+					filePolicies, err := loadPolicies(policyFile)
+					if err != nil {
+						return err
+					}
+					policies = append(policies, filePolicies...)
+				}
+			} else {
+				kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+				if err != nil {
+					return err
+				}
+
+				client, err := clients.NewCertificateRequestPolicyClient(kubeCfg)
+				if err != nil {
+					return fmt.Errorf("failed to create certificate request policy client: %w", err)
+				}
+
+				var policyList policyapi.CertificateRequestPolicyList
+				if err := client.List(ctx, &clients.GenericRequestOptions{}, &policyList); err != nil {
+					return fmt.Errorf("failed to list certificate request policies: %w", err)
+				}
+				policies = policyList.Items
+			}
+
+			if len(policies) == 0 {
+				return errors.New("no certificate request policies found")
+			}
+
+			simulations := certificates.SimulatePolicies(request, policies)
+
+			if jsonOut {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(simulations); err != nil {
+					return err
+				}
+			} else {
+				printSimulations(simulations)
+			}
+
+			for _, simulation := range simulations {
+				if simulation.Approved {
+					return nil
+				}
+			}
+
+			return errors.New("no policy would approve the request")
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&file, "file", "f", "", "Specifies a path to a file containing the CertificateRequest or Certificate to evaluate")
+	flags.StringSliceVar(&policyFiles, "policy-file", nil, "Specifies a path to a file containing CertificateRequestPolicies to evaluate, policies in the cluster are used if not set")
+	flags.BoolVar(&jsonOut, "json", false, "Output the evaluation of each policy in JSON format")
+
+	return cmd
+}
+
+// loadRequest reads the first CertificateRequest or Certificate in the file.
+func loadRequest(file string) (certificates.PolicyRequest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return certificates.PolicyRequest{}, fmt.Errorf("failed to read request file: %w", err)
+	}
+
+	objects, err := yaml.Load(bytes.NewReader(data))
+	if err != nil {
+		return certificates.PolicyRequest{}, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	for _, object := range objects {
+		switch object.GroupVersionKind() {
+		case cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateRequestKind):
+			var request cmapi.CertificateRequest
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &request); err != nil {
+				return certificates.PolicyRequest{}, fmt.Errorf("invalid certificate request in %s: %w", file, err)
+			}
+
+			policyRequest, err := certificates.PolicyRequestFromCertificateRequest(request)
+			if err != nil {
+				return certificates.PolicyRequest{}, fmt.Errorf("invalid certificate request in %s: %w", file, err)
+			}
+			return policyRequest, nil
+		case cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateKind):
+			var certificate cmapi.Certificate
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &certificate); err != nil {
+				return certificates.PolicyRequest{}, fmt.Errorf("invalid certificate in %s: %w", file, err)
+			}
+			return certificates.PolicyRequestFromCertificate(certificate), nil
+		}
+	}
+
+	return certificates.PolicyRequest{}, fmt.Errorf("no CertificateRequest or Certificate found in %s", file)
+}
+
+// loadPolicies reads all CertificateRequestPolicies in the file, ignoring other kinds of resource.
+func loadPolicies(file string) ([]policyapi.CertificateRequestPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	objects, err := yaml.Load(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	var policies []policyapi.CertificateRequestPolicy
+	for _, object := range objects {
+		if object.GroupVersionKind() != policyapi.SchemeGroupVersion.WithKind("CertificateRequestPolicy") {
+			continue
+		}
+
+		var policy policyapi.CertificateRequestPolicy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &policy); err != nil {
+			return nil, fmt.Errorf("invalid certificate request policy in %s: %w", file, err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func printSimulations(simulations []certificates.PolicySimulation) {
+	for _, simulation := range simulations {
+		switch {
+		case !simulation.Selected:
+			fmt.Printf("%s: not selected, its issuerRef selector does not match the request\n", simulation.Policy)
+			continue
+		case simulation.Approved:
+			fmt.Printf("%s: ok: would approve the request\n", simulation.Policy)
+		default:
+			fmt.Printf("%s: error: would not approve the request\n", simulation.Policy)
+			for _, violation := range simulation.Violations {
+				fmt.Printf("  %s\n", violation)
+			}
+		}
+
+		if len(simulation.UnevaluatedPlugins) > 0 {
+			fmt.Printf("  plugins not evaluated: %s\n", strings.Join(simulation.UnevaluatedPlugins, ", "))
+		}
+	}
+}
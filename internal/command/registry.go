@@ -1,9 +1,13 @@
 package command
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,6 +16,7 @@ import (
 	"github.com/jetstack/jsctl/internal/auth"
 	"github.com/jetstack/jsctl/internal/client"
 	"github.com/jetstack/jsctl/internal/config"
+	"github.com/jetstack/jsctl/internal/operator"
 	"github.com/jetstack/jsctl/internal/registry"
 )
 
@@ -22,6 +27,7 @@ func Registry() *cobra.Command {
 	}
 
 	cmd.AddCommand(registryAuth())
+	cmd.AddCommand(registryMirror())
 
 	return cmd
 }
@@ -162,3 +168,166 @@ func registryAuthOutput() *cobra.Command {
 
 	return cmd
 }
+
+func registryMirror() *cobra.Command {
+	var (
+		componentVersions map[string]string
+		destination       string
+		imagesPath        string
+		plainHTTP         bool
+		sourceRegistry    string
+		toPasswordStdin   bool
+		toUsername        string
+		version           string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Copy the Jetstack Secure images into a private registry",
+		Long: `Copy the Jetstack Secure images into a private registry
+
+By default, the images of the operator and the components it installs by default are copied, at the component versions
+the operator deploys by default. Use --component-versions to copy other versions, or 'jsctl operator bundle' to generate
+a list of images for other components and pass it using --images.
+
+All platforms of multi-arch images are copied, content that already exists in the destination registry is skipped.
+Images are copied to the same name and tag beneath the destination, so it can be used with --registry when deploying
+the operator and applying installations.`,
+		Example: `  jsctl registry mirror --to registry.internal/jetstack
+  jsctl operator bundle --istio-csr --component-versions istio-csr=v0.6.0 --output images.txt
+  jsctl registry mirror --to registry.internal/jetstack --images images.txt`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			destination = strings.TrimSuffix(destination, "/")
+			if destination == "" {
+				return errors.New("a destination registry must be provided with --to")
+			}
+
+			var images []string
+			if imagesPath != "" {
+				file, err := os.Open(imagesPath)
+				if err != nil {
+					return fmt.Errorf("failed to open image list: %w", err)
+				}
+				defer file.Close()
+
+				images, err = readImageList(file)
+				if err != nil {
+					return fmt.Errorf("failed to read image list: %w", err)
+				}
+			} else {
+				bundle, err := operator.BundleImages(ctx, operator.BundleImagesOptions{
+					Version:           version,
+					ImageRegistry:     sourceRegistry,
+					ComponentVersions: componentVersions,
+				})
+				switch {
+				case errors.Is(err, operator.ErrNoManifest):
+					return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", version)
+				case err != nil:
+					return fmt.Errorf("failed to determine images: %w", err)
+				}
+
+				for _, image := range bundle {
+					if image.Tag == "" {
+						return fmt.Errorf("no version known for %s, pin one with --component-versions %s=<version>", image.Repository, image.Component)
+					}
+					images = append(images, image.String())
+				}
+			}
+
+			credentials := make(map[string]registry.Credentials)
+			for _, image := range images {
+				if !strings.HasPrefix(image, registry.JetstackSecureEnterpriseRegistryHost+"/") {
+					continue
+				}
+
+				if _, ok := auth.TokenFromContext(ctx); !ok {
+					return fmt.Errorf("you must be logged in to mirror images from %s, run jsctl auth login", registry.JetstackSecureEnterpriseRegistryHost)
+				}
+
+				keyData, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, client.New(ctx, apiURL))
+				if err != nil {
+					return err
+				}
+
+				credentials[registry.JetstackSecureEnterpriseRegistryHost] = registry.JetstackSecureEnterpriseCredentials(keyData)
+				break
+			}
+
+			destinationHost, _, _ := strings.Cut(destination, "/")
+			if toUsername != "" {
+				if !toPasswordStdin {
+					return errors.New("--to-password-stdin must be set when --to-username is provided")
+				}
+
+				password, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("failed to read destination registry password: %w", err)
+				}
+
+				credentials[destinationHost] = registry.Credentials{
+					Username: toUsername,
+					Password: strings.TrimSpace(string(password)),
+				}
+			}
+
+			options := registry.OCIClientOptions{Credentials: credentials}
+			if plainHTTP {
+				options.PlainHTTP = []string{destinationHost}
+			}
+			ociClient := registry.NewOCIClient(options)
+
+			var failed int
+			for _, image := range images {
+				// the last path element contains the image name and its tag or digest
+				target := destination + "/" + path.Base(image)
+
+				result, err := ociClient.Copy(ctx, image, target)
+				switch {
+				case err != nil:
+					failed++
+					fmt.Fprintf(os.Stderr, "Failed to copy %s: %s\n", image, err)
+				case result.Skipped:
+					fmt.Fprintf(os.Stderr, "Skipped %s, already present as %s\n", image, target)
+				default:
+					fmt.Fprintf(os.Stderr, "Copied %s to %s (%s)\n", image, target, result.Digest)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to copy %d of %d images", failed, len(images))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&destination, "to", "", "The registry and path to copy images to, e.g. registry.internal/jetstack")
+	flags.StringVar(&imagesPath, "images", "", "Path to a file listing the images to copy, one per line, as generated by 'jsctl operator bundle'")
+	flags.StringVar(&version, "version", "", "The version of the operator to copy images for when --images is not set, defaults to latest")
+	flags.StringVar(&sourceRegistry, "registry", operator.DefaultImageRegistry, "The registry to copy images from when --images is not set")
+	flags.StringToStringVar(&componentVersions, "component-versions", map[string]string{}, "Pins the image versions of components when --images is not set, in the form component=version")
+	flags.BoolVar(&plainHTTP, "plain-http", false, "Access the destination registry over HTTP rather than HTTPS")
+	flags.StringVar(&toUsername, "to-username", "", "Username used to authenticate with the destination registry")
+	flags.BoolVar(&toPasswordStdin, "to-password-stdin", false, "Read the password used to authenticate with the destination registry from stdin")
+
+	return cmd
+}
+
+// readImageList reads image references from r, one per line. Blank lines and comments are ignored.
+func readImageList(r io.Reader) ([]string, error) {
+	images := make([]string, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		images = append(images, line)
+	}
+
+	return images, scanner.Err()
+}
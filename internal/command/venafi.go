@@ -0,0 +1,173 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+// tokenExpiryWarning is how soon before its expiry an access token is reported as due for renewal.
+const tokenExpiryWarning = 7 * 24 * time.Hour
+
+// Venafi returns a cobra.Command instance that is the root for all "jsctl venafi" subcommands.
+func Venafi() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "venafi",
+		Short: "Subcommands for working with Venafi",
+	}
+
+	cmd.AddCommand(venafiConnections())
+
+	return cmd
+}
+
+func venafiConnections() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connections",
+		Short: "Subcommands for Venafi connections, as used by --experimental-venafi-connections-config",
+	}
+
+	cmd.AddCommand(venafiConnectionsVerify())
+
+	return cmd
+}
+
+func venafiConnectionsVerify() *cobra.Command {
+	var connectionsConfig string
+
+	cmd := &cobra.Command{
+		Use:   "verify [connection...]",
+		Short: "Check that Venafi TPP connections can be used by issuers before installing them",
+		Long: `Authenticates against the TPP REST API with the credentials of each connection, checks that the token
+can be used to issue certificates and has not expired, confirms that the zone exists and lists the zones available
+to the connection. All TPP connections in the file are verified unless connection names are provided, Venafi as a
+Service connections are skipped. Connections using credentials-secret are also skipped, as their credentials are only
+available within the cluster.`,
+		Args: cobra.ArbitraryArgs,
+		Run: run(func(ctx context.Context, args []string) error {
+			if connectionsConfig == "" {
+				return errors.New("--connections-config must be provided")
+			}
+
+			vcs, err := venafi.LoadConnections(connectionsConfig)
+			if err != nil {
+				return fmt.Errorf("error parsing Venafi connection config: %w", err)
+			}
+
+			// only TPP connections can be verified, VaaS connections are those with an api-key
+			names := args
+			if len(names) == 0 {
+				all := make([]string, 0, len(vcs))
+				for name := range vcs {
+					all = append(all, name)
+				}
+				sort.Strings(all)
+
+				for _, name := range all {
+					if vc := vcs[name]; vc == nil || vc.APIKey != "" {
+						fmt.Printf("Skipping connection %s, only TPP connections can be verified\n", name)
+						continue
+					}
+					names = append(names, name)
+				}
+			}
+
+			for _, name := range names {
+				vc, ok := vcs[name]
+				if !ok || vc == nil {
+					return fmt.Errorf("connection %s not found in %s", name, connectionsConfig)
+				}
+				if vc.APIKey != "" {
+					return fmt.Errorf("connection %s has an unsupported connection type: it is a Venafi as a Service connection, only TPP connections can be verified", name)
+				}
+			}
+
+			var failed []string
+			for _, name := range names {
+				if !verifyVenafiConnection(ctx, name, vcs[name]) {
+					failed = append(failed, name)
+				}
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("verification failed for connections: %s", strings.Join(failed, ", "))
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&connectionsConfig, "connections-config", "", "Specifies a path to a file with yaml formatted Venafi connection details, as passed to --experimental-venafi-connections-config")
+
+	return cmd
+}
+
+// verifyVenafiConnection prints the result of verifying the connection, returning true if it can be used by an
+// issuer.
+func verifyVenafiConnection(ctx context.Context, name string, vc *venafi.VenafiConnection) bool {
+	fmt.Printf("Connection %s (%s)\n", name, vc.URL)
+
+	verification, err := venafi.VerifyTPPConnection(ctx, nil, vc)
+	if errors.Is(err, venafi.ErrTPPCredentialsSecret) {
+		fmt.Printf("  skipped: %s, the credentials in Secret %s cannot be verified by jsctl\n", err, vc.CredentialsSecret)
+		return true
+	}
+	if err != nil {
+		fmt.Printf("  error: %s\n", err)
+		return false
+	}
+
+	method := "access token"
+	if vc.AccessToken == "" {
+		method = "username and password"
+	}
+	fmt.Printf("  ok: authenticated as %s with %s\n", verification.Identity, method)
+
+	var scopes []string
+	for scope, privileges := range verification.Scopes {
+		if len(privileges) > 0 {
+			scope += ":" + strings.Join(privileges, ",")
+		}
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	fmt.Printf("  info: token scope: %s\n", strings.Join(scopes, ";"))
+
+	// tokens obtained from a username and password are revoked after verification, so their expiry does not matter
+	if vc.AccessToken != "" && !verification.Expires.IsZero() {
+		remaining := time.Until(verification.Expires).Round(time.Hour)
+		if remaining < tokenExpiryWarning {
+			fmt.Printf("  warning: token expires %s (in %s), it should be renewed\n", verification.Expires.Format(time.RFC3339), remaining)
+		} else {
+			fmt.Printf("  ok: token expires %s (in %s)\n", verification.Expires.Format(time.RFC3339), remaining)
+		}
+	}
+
+	if verification.ZoneError == "" {
+		fmt.Printf("  ok: zone %s exists\n", verification.Zone)
+	}
+
+	problems := verification.Problems()
+	for _, problem := range problems {
+		fmt.Printf("  error: %s\n", problem)
+	}
+
+	if verification.ZonesError != nil {
+		fmt.Printf("  warning: unable to list available zones: %s\n", verification.ZonesError)
+	} else {
+		fmt.Println("  Available zones:")
+		for _, zone := range verification.Zones {
+			fmt.Printf("    %s\n", zone)
+		}
+	}
+
+	return len(problems) == 0
+}
@@ -1,12 +1,10 @@
 package backup
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"strings"
 
 	v1alpha1kmsissuer "github.com/Skyscanner/kms-issuer/apis/certmanager/v1alpha1"
 	v1alpha1approverpolicy "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
@@ -16,9 +14,11 @@ import (
 	v1beta1googlecasissuer "github.com/jetstack/google-cas-issuer/api/v1beta1"
 	v1alpha1vei "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
 	v1beta1stepissuer "github.com/smallstep/step-issuer/api/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/yaml"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 )
@@ -35,42 +35,84 @@ type ClusterBackupOptions struct {
 	IncludeCertificates               bool
 	IncludeIssuers                    bool
 	IncludeCertificateRequestPolicies bool
-}
 
-type ClusterBackup []interface{}
+	// IncludeShimIntent, if set, will include the cert-manager annotations and TLS configuration of Ingresses and
+	// Gateways which ingress-shim and gateway-shim create Certificates for, as ShimIntents. The Certificates they
+	// create are not backed up, so this is needed to have them recreated on restore.
+	IncludeShimIntent bool
 
-func (c *ClusterBackup) ToYAML() ([]byte, error) {
-	buf := new(bytes.Buffer)
+	// Namespaces and ExcludeNamespaces limit the namespaced resources that are backed up. If either is set, cluster
+	// scoped issuers are only included if a backed up Certificate references them, and certificate request policies
+	// are not included.
+	Namespaces        []string
+	ExcludeNamespaces []string
+	// LabelSelector limits the backed up resources to those with matching labels. Issuers referenced by backed up
+	// Certificates are included even if they do not match.
+	LabelSelector string
 
-	for _, r := range *c {
-		buf.Write([]byte("---\n"))
-		yamlBytes, err := yaml.Marshal(r)
-		if err != nil {
-			return nil, err
-		}
-		buf.Write(yamlBytes)
-	}
+	// IncludeSecrets, if set, will include the Secrets of the backed up Certificates and CA issuers. Secrets are
+	// encrypted with SecretsEncrypter, which must be set unless UnencryptedSecrets is set.
+	IncludeSecrets     bool
+	SecretsEncrypter   *Encrypter
+	UnencryptedSecrets bool
+
+	// ClusterResourceNamespace is the namespace the Secrets of ClusterIssuers are in
+	ClusterResourceNamespace string
 
-	return []byte(strings.TrimSpace(buf.String()) + "\n"), nil
+	// PageSize, if set, is the number of certificates listed in each request. Certificates are written as each page
+	// is listed, so that clusters with many certificates can be backed up with StreamClusterBackup without holding
+	// them all in memory.
+	PageSize int64
+	// Progress, if set, receives messages about the progress of the backup
+	Progress io.Writer
 }
 
-func (c *ClusterBackup) ToJSON() ([]byte, error) {
-	listWrapper := map[string]interface{}{
-		"apiVersion": "v1",
-		"kind":       "List",
-		"items":      *c,
+// progress writes a line about the progress of the backup to opts.Progress, if it is set.
+func (opts ClusterBackupOptions) progress(format string, args ...interface{}) {
+	if opts.Progress != nil {
+		fmt.Fprintf(opts.Progress, format+"\n", args...)
 	}
+}
+
+type ClusterBackup []interface{}
+
+// ToYAML returns the backup as a stream of YAML documents.
+func (c *ClusterBackup) ToYAML() ([]byte, error) {
+	return writeBackup(c, "yaml")
+}
 
-	bytes, err := json.MarshalIndent(listWrapper, "", "  ")
+// ToJSON returns the backup as a v1 List.
+func (c *ClusterBackup) ToJSON() ([]byte, error) {
+	data, err := writeBackup(c, "json")
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling cluster backup to JSON: %s", err)
 	}
 
-	return bytes, nil
+	return data, nil
 }
 
+// FetchClusterBackup returns the resources StreamClusterBackup writes.
 func FetchClusterBackup(ctx context.Context, opts ClusterBackupOptions) (*ClusterBackup, error) {
 	var clusterBackup ClusterBackup
+	if err := StreamClusterBackup(ctx, opts, &clusterBackup); err != nil {
+		return nil, err
+	}
+
+	return &clusterBackup, nil
+}
+
+// StreamClusterBackup fetches the resources to back up and writes them to w: issuers, certificates, the intent of
+// annotated Ingresses and Gateways, certificate request policies and then Secrets. When the backup is limited to
+// some namespaces or labels, the issuers referenced by certificates are only known once all certificates have been
+// listed, so cluster scoped issuers, and with a label selector namespaced issuers too, are written after the
+// certificates.
+func StreamClusterBackup(ctx context.Context, opts ClusterBackupOptions, w ResourceWriter) error {
+	if opts.IncludeSecrets && opts.SecretsEncrypter == nil && !opts.UnencryptedSecrets {
+		return fmt.Errorf("secrets must be encrypted unless unencrypted secrets are explicitly allowed")
+	}
+
+	// secretRefs are the Secrets of the backed up resources, included if opts.IncludeSecrets is set
+	var secretRefs []secretReference
 
 	// these fields should be excluded from the backup
 	var dropFields []string
@@ -89,12 +131,12 @@ func FetchClusterBackup(ctx context.Context, opts ClusterBackupOptions) (*Cluste
 	// check that the cert-manager API versions are supported
 	crdClient, err := clients.NewCRDClient(opts.RestConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CRD client: %s", err)
+		return fmt.Errorf("failed to create CRD client: %s", err)
 	}
 	var crds apiextensionsv1.CustomResourceDefinitionList
 	err = crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list CRDs to determine the cert-manager API version in use: %s", err)
+		return fmt.Errorf("failed to list CRDs to determine the cert-manager API version in use: %s", err)
 	}
 	policyCRDsFound := false
 	for _, crd := range crds.Items {
@@ -105,7 +147,7 @@ func FetchClusterBackup(ctx context.Context, opts ClusterBackupOptions) (*Cluste
 			continue
 		}
 		if len(crd.Spec.Versions) == 0 {
-			return nil, fmt.Errorf("unexpectedly found no versions on cert-manager.io CRD %s", crd.Name)
+			return fmt.Errorf("unexpectedly found no versions on cert-manager.io CRD %s", crd.Name)
 		}
 		v1FoundAndServed := false
 		for _, v := range crd.Spec.Versions {
@@ -115,86 +157,230 @@ func FetchClusterBackup(ctx context.Context, opts ClusterBackupOptions) (*Cluste
 			}
 		}
 		if !v1FoundAndServed {
-			return nil, fmt.Errorf("backup only supports cert-manager.io API version v1. v1 must be present and served")
+			return fmt.Errorf("backup only supports cert-manager.io API version v1. v1 must be present and served")
 		}
 	}
 
+	// namespaced resources are listed in each of the namespaces in scope, while cluster scoped issuers are listed in
+	// full and filtered once the certificates referencing them are known
+	scoped := opts.namespaceScoped() || opts.LabelSelector != ""
+	namespacedOptions := opts.namespacedRequestOptions(dropFields)
+
 	// fetch all configured issuers and external issuers
+	var issuers []interface{}
 	if opts.IncludeIssuers {
-		issuers, err := fetchAllIssuers(ctx, opts.RestConfig, dropFields)
+		// with a label selector, namespaced issuers are listed without it, as the selected certificates may reference
+		// issuers which do not match it
+		issuerOptions := namespacedOptions
+		if opts.LabelSelector != "" {
+			issuerOptions = opts.namespacedRequestOptions(dropFields)
+			for _, requestOptions := range issuerOptions {
+				requestOptions.LabelSelector = ""
+			}
+		}
+
+		issuers, err = fetchAllIssuers(ctx, opts.RestConfig, issuerOptions, &clients.GenericRequestOptions{DropFields: dropFields})
 		if err != nil {
-			return &ClusterBackup{}, fmt.Errorf("failed to backup issuers: %w", err)
+			return fmt.Errorf("failed to backup issuers: %w", err)
 		}
-		clusterBackup = append(clusterBackup, issuers...)
+		opts.progress("Fetched %d issuers", len(issuers))
+
+		// issuers which are only included if certificates reference them are filtered once the certificates are known
+		var referencedIssuers []interface{}
+		switch {
+		case opts.LabelSelector != "":
+			issuers, referencedIssuers = nil, issuers
+		case scoped:
+			issuers, referencedIssuers, err = splitClusterIssuers(issuers)
+			if err != nil {
+				return fmt.Errorf("failed to backup issuers: %w", err)
+			}
+		}
+		if err := writeResources(w, issuers); err != nil {
+			return err
+		}
+		secretRefs = append(secretRefs, caSecretReferences(issuers, opts.ClusterResourceNamespace)...)
+		issuers = referencedIssuers
 	}
 
-	// fetch certifcates
+	// fetch certifcates, writing each page as it is listed
+	issuerRefs := make(map[issuerReference]bool)
 	if opts.IncludeCertificates {
 		certificateClient, err := clients.NewCertificateClient(opts.RestConfig)
 		if err != nil {
-			return &ClusterBackup{}, fmt.Errorf("failed to create client for certificates: %w", err)
+			return fmt.Errorf("failed to create client for certificates: %w", err)
 		}
 
-		var certificates v1certmanager.CertificateList
-		err = certificateClient.List(
-			ctx,
-			&clients.GenericRequestOptions{DropFields: dropFields},
-			&certificates,
-		)
-		if err != nil {
-			return &ClusterBackup{}, fmt.Errorf("failed to list certificates: %w", err)
-		}
-		for _, c := range certificates.Items {
-			// we do not include ingress certs, skip them
-			skip := false
-			if len(c.OwnerReferences) > 0 {
-				for _, owner := range c.OwnerReferences {
-					if owner.Kind == "Ingress" {
-						fmt.Fprintf(os.Stderr, "skipping ingress-shim managed certificate %s/%s\n", c.Namespace, c.Name)
-						skip = true
-						break
+		count := 0
+		for _, requestOptions := range namespacedOptions {
+			pageOptions := *requestOptions
+			pageOptions.Limit = opts.PageSize
+
+			var page v1certmanager.CertificateList
+			err = certificateClient.ListPages(ctx, &pageOptions, &page, func() error {
+				for ref := range certificateIssuerReferences(page.Items) {
+					issuerRefs[ref] = true
+				}
+				// the Secrets of shim managed certificates are included, as restoring them avoids
+				// re-issuance when the certificates are recreated
+				secretRefs = append(secretRefs, certificateSecretReferences(page.Items)...)
+
+				for _, c := range page.Items {
+					if shimManaged(c) {
+						fmt.Fprintf(os.Stderr, "skipping ingress-shim or gateway-shim managed certificate %s/%s\n", c.Namespace, c.Name)
+						continue
+					}
+					if err := w.WriteResource(c); err != nil {
+						return err
 					}
 				}
-			}
-			if !skip {
-				clusterBackup = append(clusterBackup, c)
+
+				count += len(page.Items)
+				opts.progress("Fetched %d certificates", count)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list certificates: %w", err)
 			}
 		}
 	}
 
+	if opts.IncludeIssuers && scoped {
+		// issuers referenced by shim managed certificates are kept too, they are needed when those are recreated
+		issuers, err = filterIssuers(issuers, issuerRefs, opts)
+		if err != nil {
+			return fmt.Errorf("failed to backup issuers: %w", err)
+		}
+		if err := writeResources(w, issuers); err != nil {
+			return err
+		}
+		secretRefs = append(secretRefs, caSecretReferences(issuers, opts.ClusterResourceNamespace)...)
+	}
+
+	if opts.IncludeShimIntent {
+		intents, err := fetchShimIntents(ctx, opts.RestConfig, namespacedOptions, gatewayCRDServed(crds.Items))
+		if err != nil {
+			return fmt.Errorf("failed to backup ingress and gateway certificate intent: %w", err)
+		}
+		if err := writeResources(w, intents); err != nil {
+			return err
+		}
+		opts.progress("Fetched %d ingress and gateway certificate intents", len(intents))
+	}
+
 	// fetch certificate request policies
 	// Note: this back up data is not used in the migration to an operator managed installation.
 	// These resourcse are only included for disaster recovery purposes.
-	if policyCRDsFound && opts.IncludeCertificateRequestPolicies {
+	if policyCRDsFound && opts.IncludeCertificateRequestPolicies && !opts.namespaceScoped() {
 		certificateRequestPolicyClient, err := clients.NewCertificateRequestPolicyClient(opts.RestConfig)
 		if err != nil {
-			return &ClusterBackup{}, fmt.Errorf("failed to create client for certificate request policies: %w", err)
+			return fmt.Errorf("failed to create client for certificate request policies: %w", err)
 		}
 
 		var certificateRequestPolicies v1alpha1approverpolicy.CertificateRequestPolicyList
 		err = certificateRequestPolicyClient.List(
 			ctx,
-			&clients.GenericRequestOptions{DropFields: dropFields},
+			&clients.GenericRequestOptions{LabelSelector: opts.LabelSelector, DropFields: dropFields},
 			&certificateRequestPolicies,
 		)
 		if err != nil {
-			return &ClusterBackup{}, fmt.Errorf("failed to list certificate request policies: %w", err)
+			return fmt.Errorf("failed to list certificate request policies: %w", err)
 		}
 		for _, p := range certificateRequestPolicies.Items {
-			clusterBackup = append(clusterBackup, p)
+			if err := w.WriteResource(p); err != nil {
+				return err
+			}
 		}
+		opts.progress("Fetched %d certificate request policies", len(certificateRequestPolicies.Items))
 	}
 
-	return &clusterBackup, nil
+	if opts.IncludeSecrets {
+		// Secrets must not be restored with the owner references of the old cluster, which would lead to them being
+		// garbage collected. The last applied configuration and managed fields are dropped even when resources are not
+		// formatted, as they can contain the Secret's data in plaintext.
+		secretDropFields := append([]string{}, dropFields...)
+		secretDropFields = append(secretDropFields,
+			"/metadata/ownerReferences",
+			"/metadata/managedFields",
+			"/metadata/annotations/kubectl.kubernetes.io~1last-applied-configuration",
+		)
+
+		// the Secrets of referenced cluster scoped issuers may be outside of the namespaces in scope
+		var scopedSecretRefs []secretReference
+		for _, ref := range secretRefs {
+			if opts.inNamespaceScope(ref.namespace) {
+				scopedSecretRefs = append(scopedSecretRefs, ref)
+			}
+		}
+
+		secretWriter := &progressWriter{ResourceWriter: w, opts: opts, kind: "secrets"}
+		count, err := fetchSecrets(ctx, opts.RestConfig, scopedSecretRefs, secretDropFields, opts.SecretsEncrypter, secretWriter)
+		if err != nil {
+			return fmt.Errorf("failed to backup secrets: %w", err)
+		}
+		opts.progress("Fetched %d secrets", count)
+	}
+
+	return nil
 }
 
-// TODO: this is similar to the logic in status.go, however with the types it's
-// a pain to share functionality.
-func fetchAllIssuers(ctx context.Context, cfg *rest.Config, dropFields []string) ([]interface{}, error) {
-	requestOptions := &clients.GenericRequestOptions{
-		DropFields: dropFields,
+// secretsProgressInterval is the number of Secrets fetched between progress messages. Secrets are fetched one at a
+// time, so there is no page to report on.
+const secretsProgressInterval = 500
+
+// progressWriter reports the number of resources of a kind written every secretsProgressInterval resources.
+type progressWriter struct {
+	ResourceWriter
+	opts  ClusterBackupOptions
+	kind  string
+	count int
+}
+
+func (p *progressWriter) WriteResource(resource interface{}) error {
+	if err := p.ResourceWriter.WriteResource(resource); err != nil {
+		return err
+	}
+	p.count++
+	if p.count%secretsProgressInterval == 0 {
+		p.opts.progress("Fetched %d %s", p.count, p.kind)
 	}
 
+	return nil
+}
+
+func writeResources(w ResourceWriter, resources []interface{}) error {
+	for _, resource := range resources {
+		if err := w.WriteResource(resource); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shimManaged returns true if the certificate is owned by an Ingress or a Gateway. These are not backed up, as
+// ingress-shim and gateway-shim create them from the Ingress or Gateway.
+func shimManaged(certificate v1certmanager.Certificate) bool {
+	for _, owner := range certificate.OwnerReferences {
+		gv, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err != nil {
+			continue
+		}
+		switch {
+		case owner.Kind == "Ingress" && gv.Group == networkingv1.GroupName,
+			owner.Kind == "Gateway" && gv.Group == gatewayv1beta1.GroupName:
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchAllIssuers lists namespaced issuers once for each of namespacedOptions and cluster scoped issuers with
+// clusterOptions, skipping them if it is nil.
+// TODO: this is similar to the logic in status.go, however with the types it's
+// a pain to share functionality.
+func fetchAllIssuers(ctx context.Context, cfg *rest.Config, namespacedOptions []*clients.GenericRequestOptions, clusterOptions *clients.GenericRequestOptions) ([]interface{}, error) {
 	issuerClient, err := clients.NewAllIssuers(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create issuer client: %s", err)
@@ -206,165 +392,184 @@ func fetchAllIssuers(ctx context.Context, cfg *rest.Config, dropFields []string)
 
 	var allIssuers []interface{}
 	for _, kind := range issuerKinds {
-		switch kind {
-		case clients.CertManagerIssuer:
-			client, err := clients.NewCertManagerIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create clusterissuer client: %s", err)
-			}
-			var issuers v1certmanager.IssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list clusterissuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.CertManagerClusterIssuer:
-			client, err := clients.NewCertManagerClusterIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create clusterissuer client: %s", err)
-			}
-			var clusterIssuers v1certmanager.ClusterIssuerList
-			err = client.List(ctx, requestOptions, &clusterIssuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list clusterissuers: %s", err)
-			}
-			for _, issuer := range clusterIssuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.GoogleCASIssuer:
-			client, err := clients.NewGoogleCASIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create cas client: %s", err)
-			}
-			var issuers v1beta1googlecasissuer.GoogleCASIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list cas issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.GoogleCASClusterIssuer:
-			client, err := clients.NewGoogleCASClusterIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create cas cluster issuer client: %s", err)
-			}
-			var issuers v1beta1googlecasissuer.GoogleCASClusterIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list cas cluster issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
+		kindOptions := namespacedOptions
+		if kind.ClusterScoped() {
+			kindOptions = nil
+			if clusterOptions != nil {
+				kindOptions = []*clients.GenericRequestOptions{clusterOptions}
 			}
-		case clients.AWSPCAIssuer:
-			client, err := clients.NewAWSPCAIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create aws pca issuer client: %s", err)
-			}
-			var issuers v1beta1awspcaissuer.AWSPCAIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list pca issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.AWSPCAClusterIssuer:
-			client, err := clients.NewAWSPCAClusterIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create aws pca cluster issuer client: %s", err)
-			}
-			var issuers v1beta1awspcaissuer.AWSPCAClusterIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list pca cluster issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.KMSIssuer:
-			client, err := clients.NewKMSIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create kms issuer client: %s", err)
-			}
-			var issuers v1alpha1kmsissuer.KMSIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list kms issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.VenafiEnhancedIssuer:
-			client, err := clients.NewVenafiEnhancedIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create venafi enhanced issuer client: %s", err)
-			}
-			var issuers v1alpha1vei.VenafiIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list venafi issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.VenafiEnhancedClusterIssuer:
-			client, err := clients.NewVenafiEnhancedClusterIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create venafi enhanced cluster issuer client: %s", err)
-			}
-			var issuers v1alpha1vei.VenafiClusterIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list venafi cluster issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.OriginCAIssuer:
-			client, err := clients.NewOriginCAIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create origin ca issuer client: %s", err)
-			}
-			var issuers v1origincaissuer.OriginIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list origin ca issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.SmallStepIssuer:
-			client, err := clients.NewSmallStepIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create smallstep issuer client: %s", err)
-			}
-			var issuers v1beta1stepissuer.StepIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list smallstep issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
-			}
-		case clients.SmallStepClusterIssuer:
-			client, err := clients.NewSmallStepClusterIssuerClient(cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create smallstep cluster issuer client: %s", err)
-			}
-			var issuers v1beta1stepissuer.StepClusterIssuerList
-			err = client.List(ctx, requestOptions, &issuers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list smallstep cluster issuers: %s", err)
-			}
-			for _, issuer := range issuers.Items {
-				allIssuers = append(allIssuers, issuer)
+		}
+
+		for _, requestOptions := range kindOptions {
+			if err := fetchIssuers(ctx, cfg, kind, requestOptions, &allIssuers); err != nil {
+				return nil, err
 			}
 		}
 	}
 
 	return allIssuers, nil
 }
+
+// fetchIssuers appends the issuers of the kind listed with the request options to allIssuers.
+func fetchIssuers(ctx context.Context, cfg *rest.Config, kind clients.AnyIssuer, requestOptions *clients.GenericRequestOptions, allIssuers *[]interface{}) error {
+	switch kind {
+	case clients.CertManagerIssuer:
+		client, err := clients.NewCertManagerIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create clusterissuer client: %s", err)
+		}
+		var issuers v1certmanager.IssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list clusterissuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.CertManagerClusterIssuer:
+		client, err := clients.NewCertManagerClusterIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create clusterissuer client: %s", err)
+		}
+		var clusterIssuers v1certmanager.ClusterIssuerList
+		err = client.List(ctx, requestOptions, &clusterIssuers)
+		if err != nil {
+			return fmt.Errorf("failed to list clusterissuers: %s", err)
+		}
+		for _, issuer := range clusterIssuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.GoogleCASIssuer:
+		client, err := clients.NewGoogleCASIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create cas client: %s", err)
+		}
+		var issuers v1beta1googlecasissuer.GoogleCASIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list cas issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.GoogleCASClusterIssuer:
+		client, err := clients.NewGoogleCASClusterIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create cas cluster issuer client: %s", err)
+		}
+		var issuers v1beta1googlecasissuer.GoogleCASClusterIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list cas cluster issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.AWSPCAIssuer:
+		client, err := clients.NewAWSPCAIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create aws pca issuer client: %s", err)
+		}
+		var issuers v1beta1awspcaissuer.AWSPCAIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list pca issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.AWSPCAClusterIssuer:
+		client, err := clients.NewAWSPCAClusterIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create aws pca cluster issuer client: %s", err)
+		}
+		var issuers v1beta1awspcaissuer.AWSPCAClusterIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list pca cluster issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.KMSIssuer:
+		client, err := clients.NewKMSIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create kms issuer client: %s", err)
+		}
+		var issuers v1alpha1kmsissuer.KMSIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list kms issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.VenafiEnhancedIssuer:
+		client, err := clients.NewVenafiEnhancedIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create venafi enhanced issuer client: %s", err)
+		}
+		var issuers v1alpha1vei.VenafiIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list venafi issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.VenafiEnhancedClusterIssuer:
+		client, err := clients.NewVenafiEnhancedClusterIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create venafi enhanced cluster issuer client: %s", err)
+		}
+		var issuers v1alpha1vei.VenafiClusterIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list venafi cluster issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.OriginCAIssuer:
+		client, err := clients.NewOriginCAIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create origin ca issuer client: %s", err)
+		}
+		var issuers v1origincaissuer.OriginIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list origin ca issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.SmallStepIssuer:
+		client, err := clients.NewSmallStepIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create smallstep issuer client: %s", err)
+		}
+		var issuers v1beta1stepissuer.StepIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list smallstep issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	case clients.SmallStepClusterIssuer:
+		client, err := clients.NewSmallStepClusterIssuerClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create smallstep cluster issuer client: %s", err)
+		}
+		var issuers v1beta1stepissuer.StepClusterIssuerList
+		err = client.List(ctx, requestOptions, &issuers)
+		if err != nil {
+			return fmt.Errorf("failed to list smallstep cluster issuers: %s", err)
+		}
+		for _, issuer := range issuers.Items {
+			*allIssuers = append(*allIssuers, issuer)
+		}
+	}
+
+	return nil
+}
@@ -1,14 +1,20 @@
 package backup
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
+	v1certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
 
@@ -83,6 +89,50 @@ func TestBackup(t *testing.T) {
 	assert.Equal(t, string(expectedBackupJSON), string(backupJSON))
 }
 
+func TestStreamClusterBackup_Paginated(t *testing.T) {
+	certificate := func(name string) string {
+		return fmt.Sprintf(`{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": %q, "namespace": "team-a"}, "spec": {"secretName": %q, "issuerRef": {"name": "ca"}}}`, name, name+"-tls")
+	}
+	// the pages of certificates served for each continue token
+	pages := map[string]string{
+		"":       `{"metadata": {"continue": "page-2"}, "items": [` + certificate("app-1") + `,` + certificate("app-2") + `]}`,
+		"page-2": `{"items": [` + certificate("app-3") + `]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+			w.Write([]byte(`{"items": [{"metadata": {"name": "certificates.cert-manager.io"}, "spec": {"group": "cert-manager.io", "versions": [{"name": "v1", "served": true}]}}]}`))
+		case "/apis/cert-manager.io/v1/certificates":
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+			page, ok := pages[r.URL.Query().Get("continue")]
+			require.True(t, ok)
+			w.Write([]byte(page))
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	var output, progress bytes.Buffer
+	writer, err := NewStreamWriter(&output, "yaml")
+	require.NoError(t, err)
+
+	err = StreamClusterBackup(context.Background(), ClusterBackupOptions{
+		RestConfig:          &rest.Config{Host: server.URL},
+		IncludeCertificates: true,
+		PageSize:            2,
+		Progress:            &progress,
+	}, writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, 3, writer.Count())
+	assert.Equal(t, 3, strings.Count(output.String(), "kind: Certificate\n"))
+	assert.Equal(t, "Fetched 2 certificates\nFetched 3 certificates\n", progress.String())
+}
+
 func TestBackup_LegacyAPIVersions(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var err error
@@ -113,3 +163,384 @@ func TestBackup_LegacyAPIVersions(t *testing.T) {
 	_, err := FetchClusterBackup(context.Background(), opts)
 	require.ErrorContains(t, err, "backup only supports cert-manager.io API version v1. v1 must be present and served")
 }
+
+func TestBackup_Secrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		w.Header().Set("Content-Type", "application/json")
+
+		var data []byte
+		switch r.URL.Path {
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+			data, err = os.ReadFile("fixtures/crd-list.json")
+			require.NoError(t, err)
+		case "/apis/cert-manager.io/v1/certificates":
+			data, err = os.ReadFile("fixtures/certificate-list.json")
+			require.NoError(t, err)
+		case "/api/v1/namespaces/jetstack-secure/secrets/example-com-tls":
+			data = []byte(`{
+				"apiVersion": "v1",
+				"kind": "Secret",
+				"metadata": {
+					"name": "example-com-tls",
+					"namespace": "jetstack-secure",
+					"ownerReferences": [{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "name": "example-com", "uid": "1"}],
+					"resourceVersion": "1"
+				},
+				"type": "kubernetes.io/tls",
+				"data": {"tls.crt": "Y2VydGlmaWNhdGU=", "tls.key": "a2V5"}
+			}`)
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+
+		w.Write(data)
+	}))
+
+	opts := ClusterBackupOptions{
+		RestConfig: &rest.Config{Host: server.URL},
+
+		FormatResources: true,
+
+		IncludeCertificates: true,
+		IncludeSecrets:      true,
+	}
+
+	_, err := FetchClusterBackup(context.Background(), opts)
+	require.EqualError(t, err, "secrets must be encrypted unless unencrypted secrets are explicitly allowed")
+
+	opts.SecretsEncrypter, err = NewEncrypter([]byte("passphrase"))
+	require.NoError(t, err)
+
+	backup, err := FetchClusterBackup(context.Background(), opts)
+	require.NoError(t, err)
+
+	// the secret referenced by both certificates is included once, after the certificates, even though the
+	// ingress-shim managed certificate itself is skipped
+	require.Len(t, *backup, 2)
+	encrypted, ok := (*backup)[1].(*EncryptedSecret)
+	require.True(t, ok)
+
+	secret, err := NewDecrypter([]byte("passphrase")).Decrypt(*encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "example-com-tls", secret.Name)
+	assert.Empty(t, secret.OwnerReferences)
+	assert.Empty(t, secret.ResourceVersion)
+	assert.Equal(t, map[string][]byte{"tls.crt": []byte("certificate"), "tls.key": []byte("key")}, secret.Data)
+}
+
+func TestBackup_SecretsUnformatted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+			w.Write([]byte(`{"items": [{"metadata": {"name": "certificates.cert-manager.io"}, "spec": {"group": "cert-manager.io", "versions": [{"name": "v1", "served": true}]}}]}`))
+		case "/apis/cert-manager.io/v1/certificates":
+			w.Write([]byte(`{"items": [{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "app", "namespace": "team-a"}, "spec": {"secretName": "app-tls", "issuerRef": {"name": "ca"}}}]}`))
+		case "/api/v1/namespaces/team-a/secrets/app-tls":
+			// kubectl apply stores the data of the Secret in the last applied configuration and managed fields
+			w.Write([]byte(`{
+				"apiVersion": "v1",
+				"kind": "Secret",
+				"metadata": {
+					"name": "app-tls",
+					"namespace": "team-a",
+					"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{\"data\":{\"tls.key\":\"c2VjcmV0LWtleQ==\"}}"},
+					"managedFields": [{"manager": "kubectl", "operation": "Apply", "fieldsV1": {"f:data": {"f:tls.key": {}}}}],
+					"ownerReferences": [{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "name": "app", "uid": "1"}]
+				},
+				"type": "kubernetes.io/tls",
+				"data": {"tls.key": "c2VjcmV0LWtleQ=="}
+			}`))
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	encrypter, err := NewEncrypter([]byte("passphrase"))
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	writer, err := NewStreamWriter(&output, "yaml")
+	require.NoError(t, err)
+
+	err = StreamClusterBackup(context.Background(), ClusterBackupOptions{
+		RestConfig:          &rest.Config{Host: server.URL},
+		IncludeCertificates: true,
+		IncludeSecrets:      true,
+		SecretsEncrypter:    encrypter,
+	}, writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.Contains(t, output.String(), "kind: EncryptedSecret\n")
+	assert.NotContains(t, output.String(), "c2VjcmV0LWtleQ")
+	assert.NotContains(t, output.String(), "last-applied-configuration")
+	assert.NotContains(t, output.String(), "managedFields")
+	assert.NotContains(t, output.String(), "ownerReferences")
+}
+
+func TestBackup_Scoped(t *testing.T) {
+	crd := func(name, group, version string) string {
+		return fmt.Sprintf(`{"metadata": {"name": %q}, "spec": {"group": %q, "versions": [{"name": %q, "served": true}]}}`, name, group, version)
+	}
+	crdList := `{"items": [` + strings.Join([]string{
+		crd("issuers.cert-manager.io", "cert-manager.io", "v1"),
+		crd("clusterissuers.cert-manager.io", "cert-manager.io", "v1"),
+		crd("certificates.cert-manager.io", "cert-manager.io", "v1"),
+		crd("certificaterequestpolicies.policy.cert-manager.io", "policy.cert-manager.io", "v1alpha1"),
+	}, ",") + `]}`
+
+	clusterIssuer := func(name, secretName string) string {
+		return fmt.Sprintf(`{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": {"name": %q, "labels": {"team": "b"}}, "spec": {"ca": {"secretName": %q}}}`, name, secretName)
+	}
+	issuer := func(name, secretName, labels string) string {
+		return fmt.Sprintf(`{"apiVersion": "cert-manager.io/v1", "kind": "Issuer", "metadata": {"name": %q, "namespace": "team-a", "labels": %s}, "spec": {"ca": {"secretName": %q}}}`, name, labels, secretName)
+	}
+	certificate := func(name, secretName, issuerRef string) string {
+		return fmt.Sprintf(`{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": %q, "namespace": "team-a"}, "spec": {"secretName": %q, "issuerRef": %s}}`, name, secretName, issuerRef)
+	}
+	secret := func(namespace, name string) []byte {
+		return []byte(fmt.Sprintf(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": %q, "namespace": %q}, "data": {"tls.key": "a2V5"}}`, name, namespace))
+	}
+
+	testCases := map[string]struct {
+		opts             ClusterBackupOptions
+		expectedRequests map[string]string
+		expectedNames    []string
+	}{
+		"namespaces and selector": {
+			opts: ClusterBackupOptions{
+				Namespaces:    []string{"team-a", "team-a"},
+				LabelSelector: "team=a",
+			},
+			expectedRequests: map[string]string{
+				"/apis/cert-manager.io/v1/namespaces/team-a/issuers":      "",
+				"/apis/cert-manager.io/v1/clusterissuers":                 "",
+				"/apis/cert-manager.io/v1/namespaces/team-a/certificates": "labelSelector=team%3Da",
+				"/api/v1/namespaces/team-a/secrets/app-tls":               "",
+				"/api/v1/namespaces/team-a/secrets/web-tls":               "",
+				"/api/v1/namespaces/team-a/secrets/team-a-ca":             "",
+				"/api/v1/namespaces/team-a/secrets/app-ca-key":            "",
+			},
+			// the issuers are included after the certificates, app-ca as a certificate references it, and only the
+			// referenced cluster issuer is included, without its secret as it is outside of the namespaces in scope
+			expectedNames: []string{"app", "web", "team-a-ca", "app-ca", "shared-ca", "app-ca-key", "app-tls", "team-a-ca", "web-tls"},
+		},
+		"excluded namespaces": {
+			opts: ClusterBackupOptions{
+				ExcludeNamespaces: []string{"team-b", "team-c"},
+			},
+			expectedRequests: map[string]string{
+				"/apis/cert-manager.io/v1/issuers":                      "fieldSelector=metadata.namespace%21%3Dteam-b%2Cmetadata.namespace%21%3Dteam-c",
+				"/apis/cert-manager.io/v1/clusterissuers":               "",
+				"/apis/cert-manager.io/v1/certificates":                 "fieldSelector=metadata.namespace%21%3Dteam-b%2Cmetadata.namespace%21%3Dteam-c",
+				"/api/v1/namespaces/team-a/secrets/app-tls":             "",
+				"/api/v1/namespaces/team-a/secrets/web-tls":             "",
+				"/api/v1/namespaces/team-a/secrets/team-a-ca":           "",
+				"/api/v1/namespaces/team-a/secrets/app-ca-key":          "",
+				"/api/v1/namespaces/cert-manager/secrets/shared-ca-key": "",
+			},
+			expectedNames: []string{"team-a-ca", "app-ca", "app", "web", "shared-ca", "shared-ca-key", "app-ca-key", "app-tls", "team-a-ca", "web-tls"},
+		},
+		"selector": {
+			opts: ClusterBackupOptions{
+				LabelSelector: "team=b",
+			},
+			expectedRequests: map[string]string{
+				"/apis/cert-manager.io/v1/issuers":                                 "",
+				"/apis/cert-manager.io/v1/clusterissuers":                          "",
+				"/apis/cert-manager.io/v1/certificates":                            "labelSelector=team%3Db",
+				"/apis/policy.cert-manager.io/v1alpha1/certificaterequestpolicies": "labelSelector=team%3Db",
+				"/api/v1/namespaces/team-a/secrets/app-tls":                        "",
+				"/api/v1/namespaces/team-a/secrets/web-tls":                        "",
+				"/api/v1/namespaces/team-a/secrets/app-ca-key":                     "",
+				"/api/v1/namespaces/cert-manager/secrets/other-ca-key":             "",
+				"/api/v1/namespaces/cert-manager/secrets/shared-ca-key":            "",
+			},
+			// both cluster issuers match the selector and app-ca is referenced, but team-a-ca neither matches nor is
+			// referenced; the fake server does not filter the certificates
+			expectedNames: []string{"app", "web", "app-ca", "shared-ca", "other-ca", "other-ca-key", "shared-ca-key", "app-ca-key", "app-tls", "web-tls"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			requests := map[string]string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path != "/apis/apiextensions.k8s.io/v1/customresourcedefinitions" {
+					requests[r.URL.Path] = r.URL.RawQuery
+				}
+
+				switch {
+				case r.URL.Path == "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+					w.Write([]byte(crdList))
+				case strings.HasSuffix(r.URL.Path, "/issuers"):
+					// app-ca is only included if the certificate referencing it is
+					issuers := []string{issuer("team-a-ca", "team-a-ca", `{"team": "a"}`)}
+					if r.URL.Query().Get("labelSelector") == "" {
+						issuers = append(issuers, issuer("app-ca", "app-ca-key", `{}`))
+					}
+					w.Write([]byte(`{"items": [` + strings.Join(issuers, ",") + `]}`))
+				case r.URL.Path == "/apis/cert-manager.io/v1/clusterissuers":
+					w.Write([]byte(`{"items": [` + clusterIssuer("shared-ca", "shared-ca-key") + `,` + clusterIssuer("other-ca", "other-ca-key") + `]}`))
+				case strings.HasSuffix(r.URL.Path, "/certificates"):
+					w.Write([]byte(`{"items": [` + certificate("app", "app-tls", `{"kind": "ClusterIssuer", "name": "shared-ca"}`) + `,` + certificate("web", "web-tls", `{"name": "app-ca"}`) + `]}`))
+				case r.URL.Path == "/apis/policy.cert-manager.io/v1alpha1/certificaterequestpolicies":
+					w.Write([]byte(`{"items": []}`))
+				case strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/"):
+					parts := strings.Split(r.URL.Path, "/")
+					w.Write(secret(parts[4], parts[6]))
+				default:
+					t.Fatalf("unexpected request: %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			opts := tc.opts
+			opts.RestConfig = &rest.Config{Host: server.URL}
+			opts.FormatResources = true
+			opts.IncludeCertificates = true
+			opts.IncludeIssuers = true
+			opts.IncludeCertificateRequestPolicies = true
+			opts.IncludeSecrets = true
+			opts.UnencryptedSecrets = true
+			opts.ClusterResourceNamespace = "cert-manager"
+
+			backup, err := FetchClusterBackup(context.Background(), opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRequests, requests)
+
+			var names []string
+			for _, resource := range *backup {
+				data, err := json.Marshal(resource)
+				require.NoError(t, err)
+				var meta struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				}
+				require.NoError(t, json.Unmarshal(data, &meta))
+				names = append(names, meta.Metadata.Name)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestBackup_ShimIntent(t *testing.T) {
+	ingresses := `{"items": [
+		{"metadata": {"name": "app", "namespace": "team-a", "annotations": {"cert-manager.io/cluster-issuer": "letsencrypt", "cert-manager.io/duration": "2160h", "nginx.ingress.kubernetes.io/ssl-redirect": "true"}}, "spec": {"tls": [{"hosts": ["app.example.com"], "secretName": "app-tls"}]}},
+		{"metadata": {"name": "acme", "namespace": "team-a", "annotations": {"kubernetes.io/tls-acme": "true"}}, "spec": {"tls": [{"hosts": ["acme.example.com"], "secretName": "acme-tls"}]}},
+		{"metadata": {"name": "plain", "namespace": "team-a"}, "spec": {"tls": [{"hosts": ["plain.example.com"], "secretName": "plain-tls"}]}}
+	]}`
+	gateways := `{"items": [
+		{"metadata": {"name": "gateway", "namespace": "team-b", "annotations": {"cert-manager.io/issuer": "ca", "kubernetes.io/tls-acme": "true"}}, "spec": {"gatewayClassName": "example", "listeners": [
+			{"name": "http", "port": 80, "protocol": "HTTP"},
+			{"name": "https", "hostname": "gateway.example.com", "port": 443, "protocol": "HTTPS", "tls": {"mode": "Terminate", "certificateRefs": [{"group": "", "kind": "Secret", "name": "gateway-tls"}]}}
+		]}},
+		{"metadata": {"name": "acme", "namespace": "team-b", "annotations": {"kubernetes.io/tls-acme": "true"}}, "spec": {"gatewayClassName": "example", "listeners": []}}
+	]}`
+
+	testCases := map[string]struct {
+		crdList       string
+		expectedNames []string
+	}{
+		"ingresses and gateways": {
+			crdList:       `{"items": [{"metadata": {"name": "gateways.gateway.networking.k8s.io"}, "spec": {"group": "gateway.networking.k8s.io", "names": {"kind": "Gateway"}, "versions": [{"name": "v1beta1", "served": true}]}}]}`,
+			expectedNames: []string{"app", "acme", "gateway"},
+		},
+		"gateway API not installed": {
+			crdList:       `{"items": []}`,
+			expectedNames: []string{"app", "acme"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+					w.Write([]byte(tc.crdList))
+				case "/apis/networking.k8s.io/v1/ingresses":
+					w.Write([]byte(ingresses))
+				case "/apis/gateway.networking.k8s.io/v1beta1/gateways":
+					w.Write([]byte(gateways))
+				default:
+					t.Fatalf("unexpected request: %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			backup, err := FetchClusterBackup(context.Background(), ClusterBackupOptions{
+				RestConfig:        &rest.Config{Host: server.URL},
+				FormatResources:   true,
+				IncludeShimIntent: true,
+			})
+			require.NoError(t, err)
+
+			var names []string
+			for _, resource := range *backup {
+				intent, ok := resource.(*ShimIntent)
+				require.True(t, ok, "unexpected resource %T", resource)
+				names = append(names, intent.Name)
+			}
+			require.Equal(t, tc.expectedNames, names)
+
+			ingressIntent := (*backup)[0].(*ShimIntent)
+			assert.Equal(t, ShimIntentTarget{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"}, ingressIntent.Target)
+			assert.Equal(t, "team-a", ingressIntent.Namespace)
+			// annotations of the ingress controller are not part of the intent
+			assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", "cert-manager.io/duration": "2160h"}, ingressIntent.Annotations)
+			require.Len(t, ingressIntent.IngressTLS, 1)
+			assert.Equal(t, "app-tls", ingressIntent.IngressTLS[0].SecretName)
+
+			if len(names) < 3 {
+				return
+			}
+			gatewayIntent := (*backup)[2].(*ShimIntent)
+			assert.Equal(t, ShimIntentTarget{APIVersion: "gateway.networking.k8s.io/v1beta1", Kind: "Gateway"}, gatewayIntent.Target)
+			require.Len(t, gatewayIntent.GatewayListeners, 1)
+			assert.Equal(t, "https", string(gatewayIntent.GatewayListeners[0].Name))
+			assert.Equal(t, "gateway-tls", string(gatewayIntent.GatewayListeners[0].TLS.CertificateRefs[0].Name))
+
+			backupYAML, err := backup.ToYAML()
+			require.NoError(t, err)
+			assert.Contains(t, string(backupYAML), "apiVersion: backup.jsctl.jetstack.io/v1alpha1\n")
+			assert.Contains(t, string(backupYAML), "kind: ShimIntent\n")
+		})
+	}
+}
+
+func TestShimManaged(t *testing.T) {
+	testCases := map[string]struct {
+		owners   []metav1.OwnerReference
+		expected bool
+	}{
+		"no owners": {},
+		"owned by an Ingress": {
+			owners:   []metav1.OwnerReference{{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "app"}},
+			expected: true,
+		},
+		"owned by a Gateway": {
+			owners:   []metav1.OwnerReference{{APIVersion: "gateway.networking.k8s.io/v1beta1", Kind: "Gateway", Name: "app"}},
+			expected: true,
+		},
+		"owned by a Gateway of another API group": {
+			owners: []metav1.OwnerReference{{APIVersion: "networking.istio.io/v1beta1", Kind: "Gateway", Name: "app"}},
+		},
+		"owned by another kind": {
+			owners: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "App", Name: "app"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			certificate := v1certmanager.Certificate{ObjectMeta: metav1.ObjectMeta{OwnerReferences: tc.owners}}
+			assert.Equal(t, tc.expected, shimManaged(certificate))
+		})
+	}
+}
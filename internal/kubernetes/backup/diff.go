@@ -0,0 +1,305 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The kinds of change to an object between two backups
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// redactedValue replaces the values of Secret data in diffs
+const redactedValue = "(redacted)"
+
+// ignoredFields are set by the API server and differ between clusters, or change without the object being changed.
+// They are the fields dropped from backups when resources are formatted.
+var ignoredFields = [][]string{
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "managedFields"},
+	{"metadata", "selfLink"},
+	{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+	{"status"},
+}
+
+// BackupDiff lists the objects which differ between two backups, sorted by kind, namespace and name.
+type BackupDiff struct {
+	Objects []ObjectDiff `json:"objects"`
+}
+
+// Count returns the number of objects with the given change.
+func (d *BackupDiff) Count(change string) int {
+	count := 0
+	for _, object := range d.Objects {
+		if object.Change == change {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ObjectDiff describes how an object differs between two backups. Fields is only set for changed objects.
+type ObjectDiff struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Change     string      `json:"change"`
+	Fields     []FieldDiff `json:"fields,omitempty"`
+}
+
+// FieldDiff describes a field whose value differs between two backups. Old is not set if the field was added and New
+// is not set if it was removed.
+type FieldDiff struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ToUnstructured returns the resources in the backup as unstructured objects, as they would be loaded from a file.
+func (c *ClusterBackup) ToUnstructured() ([]*unstructured.Unstructured, error) {
+	resources := make([]*unstructured.Unstructured, 0, len(*c))
+	for _, r := range *c {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling resource: %w", err)
+		}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("error unmarshalling resource: %w", err)
+		}
+		resources = append(resources, &unstructured.Unstructured{Object: object})
+	}
+
+	return resources, nil
+}
+
+// objectKey identifies an object in a backup.
+type objectKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// Diff compares the objects in two backups, matching them by group, version, kind, namespace and name. Objects only
+// in b are added and objects only in a are removed. Fields set by the API server, such as the resource version and
+// status, are ignored, and the values of Secret data are redacted.
+func Diff(a, b []*unstructured.Unstructured) (*BackupDiff, error) {
+	aObjects, err := indexObjects(a)
+	if err != nil {
+		return nil, fmt.Errorf("invalid first backup: %w", err)
+	}
+	bObjects, err := indexObjects(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid second backup: %w", err)
+	}
+
+	diff := &BackupDiff{}
+	for key, aObject := range aObjects {
+		bObject, ok := bObjects[key]
+		if !ok {
+			diff.Objects = append(diff.Objects, newObjectDiff(key, ChangeRemoved, nil))
+			continue
+		}
+
+		aFields, err := normalizeObject(aObject.Object)
+		if err != nil {
+			return nil, err
+		}
+		bFields, err := normalizeObject(bObject.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := diffFields(nil, aFields, bFields)
+		if isSecret(key.gvk) {
+			redactSecretData(fields)
+		}
+		if len(fields) > 0 {
+			diff.Objects = append(diff.Objects, newObjectDiff(key, ChangeChanged, fields))
+		}
+	}
+	for key := range bObjects {
+		if _, ok := aObjects[key]; !ok {
+			diff.Objects = append(diff.Objects, newObjectDiff(key, ChangeAdded, nil))
+		}
+	}
+
+	sort.Slice(diff.Objects, func(i, j int) bool {
+		a, b := diff.Objects[i], diff.Objects[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.APIVersion != b.APIVersion {
+			return a.APIVersion < b.APIVersion
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return diff, nil
+}
+
+func indexObjects(resources []*unstructured.Unstructured) (map[objectKey]*unstructured.Unstructured, error) {
+	objects := make(map[objectKey]*unstructured.Unstructured, len(resources))
+	for _, resource := range resources {
+		key := objectKey{gvk: resource.GroupVersionKind(), namespace: resource.GetNamespace(), name: resource.GetName()}
+		if _, ok := objects[key]; ok {
+			return nil, fmt.Errorf("duplicate %s %s", key.gvk.Kind, objectName(key))
+		}
+		objects[key] = resource
+	}
+
+	return objects, nil
+}
+
+func newObjectDiff(key objectKey, change string, fields []FieldDiff) ObjectDiff {
+	apiVersion, kind := key.gvk.ToAPIVersionAndKind()
+	return ObjectDiff{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  key.namespace,
+		Name:       key.name,
+		Change:     change,
+		Fields:     fields,
+	}
+}
+
+func objectName(key objectKey) string {
+	if key.namespace == "" {
+		return key.name
+	}
+
+	return key.namespace + "/" + key.name
+}
+
+// normalizeObject returns a copy of the object without the ignored fields. The copy is made by encoding the object
+// as JSON, so that numbers are of the same type whether the object was loaded from YAML or JSON.
+func normalizeObject(object map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object: %w", err)
+	}
+	object = nil
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("error unmarshalling object: %w", err)
+	}
+
+	for _, field := range ignoredFields {
+		unstructured.RemoveNestedField(object, field...)
+	}
+
+	// removing the last-applied-configuration annotation can leave no annotations
+	if annotations, ok, _ := unstructured.NestedMap(object, "metadata", "annotations"); ok && len(annotations) == 0 {
+		unstructured.RemoveNestedField(object, "metadata", "annotations")
+	}
+
+	return object, nil
+}
+
+// diffFields returns the differences between two values, descending into maps and into lists of the same length.
+func diffFields(path []string, a, b interface{}) []FieldDiff {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(a)+len(b))
+			for key := range a {
+				keys = append(keys, key)
+			}
+			for key := range b {
+				if _, ok := a[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			var fields []FieldDiff
+			for _, key := range keys {
+				fieldPath := append(append([]string{}, path...), fieldSegment(key))
+				aValue, aOK := a[key]
+				bValue, bOK := b[key]
+				switch {
+				case !aOK:
+					fields = append(fields, FieldDiff{Path: joinFieldPath(fieldPath), New: bValue})
+				case !bOK:
+					fields = append(fields, FieldDiff{Path: joinFieldPath(fieldPath), Old: aValue})
+				default:
+					fields = append(fields, diffFields(fieldPath, aValue, bValue)...)
+				}
+			}
+			return fields
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && len(a) == len(b) {
+			var fields []FieldDiff
+			for i := range a {
+				fieldPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
+				fields = append(fields, diffFields(fieldPath, a[i], b[i])...)
+			}
+			return fields
+		}
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+
+	return []FieldDiff{{Path: joinFieldPath(path), Old: a, New: b}}
+}
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// fieldSegment returns the key as a segment of a field path, quoting keys such as annotation names which contain
+// dots or slashes.
+func fieldSegment(key string) string {
+	if identifierRegexp.MatchString(key) {
+		return "." + key
+	}
+
+	return fmt.Sprintf("[%q]", key)
+}
+
+func joinFieldPath(path []string) string {
+	return strings.TrimPrefix(strings.Join(path, ""), ".")
+}
+
+func isSecret(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// redactSecretData replaces the values of changed Secret data, so that diffs can be shared.
+func redactSecretData(fields []FieldDiff) {
+	for i, field := range fields {
+		if !isFieldOf(field.Path, "data") && !isFieldOf(field.Path, "stringData") {
+			continue
+		}
+		if field.Old != nil {
+			fields[i].Old = redactedValue
+		}
+		if field.New != nil {
+			fields[i].New = redactedValue
+		}
+	}
+}
+
+// isFieldOf returns true if the path is the field or one of its children.
+func isFieldOf(path, field string) bool {
+	return path == field || strings.HasPrefix(path, field+".") || strings.HasPrefix(path, field+"[")
+}
@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiff(t *testing.T) {
+	certificate := func(dnsNames []interface{}, duration string, annotations map[string]interface{}) *unstructured.Unstructured {
+		metadata := map[string]interface{}{
+			"name":      "example-com",
+			"namespace": "jetstack-secure",
+		}
+		if annotations != nil {
+			metadata["annotations"] = annotations
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata":   metadata,
+			"spec": map[string]interface{}{
+				"dnsNames":             dnsNames,
+				"duration":             duration,
+				"revisionHistoryLimit": int64(1),
+			},
+		}}
+	}
+	issuer := func(apiVersion, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "ClusterIssuer",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"selfSigned": map[string]interface{}{}},
+		}}
+	}
+	secret := func(key string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "example-com-tls", "namespace": "jetstack-secure"},
+			"data":       map[string]interface{}{"tls.key": key},
+		}}
+	}
+
+	a := []*unstructured.Unstructured{
+		certificate([]interface{}{"example.com", "www.example.com"}, "2160h", nil),
+		issuer("cert-manager.io/v1", "removed"),
+		issuer("cert-manager.io/v1", "unchanged"),
+		secret("b2xk"),
+	}
+
+	b := []*unstructured.Unstructured{
+		certificate([]interface{}{"example.com", "api.example.com"}, "720h", map[string]interface{}{"example.com/team": "a"}),
+		issuer("cert-manager.io/v1", "added"),
+		issuer("cert-manager.io/v1", "unchanged"),
+		secret("bmV3"),
+	}
+	// fields set by the API server are ignored, as are number types which differ between YAML and JSON
+	b[0].SetResourceVersion("123")
+	b[0].Object["status"] = map[string]interface{}{"revision": 1}
+	b[0].Object["spec"].(map[string]interface{})["revisionHistoryLimit"] = float64(1)
+	b[2].SetUID("uid")
+	b[2].SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"})
+
+	diff, err := Diff(a, b)
+	require.NoError(t, err)
+
+	assert.Equal(t, &BackupDiff{
+		Objects: []ObjectDiff{
+			{
+				APIVersion: "cert-manager.io/v1",
+				Kind:       "Certificate",
+				Namespace:  "jetstack-secure",
+				Name:       "example-com",
+				Change:     ChangeChanged,
+				Fields: []FieldDiff{
+					{Path: "metadata.annotations", New: map[string]interface{}{"example.com/team": "a"}},
+					{Path: "spec.dnsNames[1]", Old: "www.example.com", New: "api.example.com"},
+					{Path: "spec.duration", Old: "2160h", New: "720h"},
+				},
+			},
+			{APIVersion: "cert-manager.io/v1", Kind: "ClusterIssuer", Name: "added", Change: ChangeAdded},
+			{APIVersion: "cert-manager.io/v1", Kind: "ClusterIssuer", Name: "removed", Change: ChangeRemoved},
+			{
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Namespace:  "jetstack-secure",
+				Name:       "example-com-tls",
+				Change:     ChangeChanged,
+				Fields: []FieldDiff{
+					{Path: `data["tls.key"]`, Old: redactedValue, New: redactedValue},
+				},
+			},
+		},
+	}, diff)
+	assert.Equal(t, 1, diff.Count(ChangeAdded))
+	assert.Equal(t, 2, diff.Count(ChangeChanged))
+
+	// objects are matched by version as well as kind
+	diff, err = Diff([]*unstructured.Unstructured{issuer("cert-manager.io/v1alpha2", "unchanged")}, []*unstructured.Unstructured{issuer("cert-manager.io/v1", "unchanged")})
+	require.NoError(t, err)
+	assert.Equal(t, []ObjectDiff{
+		{APIVersion: "cert-manager.io/v1", Kind: "ClusterIssuer", Name: "unchanged", Change: ChangeAdded},
+		{APIVersion: "cert-manager.io/v1alpha2", Kind: "ClusterIssuer", Name: "unchanged", Change: ChangeRemoved},
+	}, diff.Objects)
+
+	_, err = Diff(a, append(b, issuer("cert-manager.io/v1", "added")))
+	assert.EqualError(t, err, "invalid second backup: duplicate ClusterIssuer added")
+}
@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EncryptedSecretAPIVersion and EncryptedSecretKind identify Secrets encrypted in a backup. They are not served by
+	// any API server, so applying a backup with kubectl fails rather than creating Secrets containing ciphertext.
+	EncryptedSecretAPIVersion = "backup.jsctl.jetstack.io/v1alpha1"
+	EncryptedSecretKind       = "EncryptedSecret"
+
+	// EncryptionAlgorithm is the algorithm used to encrypt the data of Secrets: AES-256-GCM with a key derived from a
+	// passphrase using PBKDF2 with HMAC-SHA256
+	EncryptionAlgorithm = "PBKDF2-SHA256/AES-256-GCM"
+
+	keyIterations = 600000
+	// maxKeyIterations limits the work a backup file can demand of the Decrypter when deriving a key
+	maxKeyIterations = 4 * keyIterations
+	keyLength        = 32
+	saltLength       = 16
+)
+
+// ErrDecryptionFailed is returned when an encrypted Secret cannot be decrypted, which is usually because the
+// passphrase is wrong.
+var ErrDecryptionFailed = errors.New("failed to decrypt secret, check the passphrase")
+
+// EncryptedSecret is a Secret whose data has been encrypted with a passphrase for inclusion in a backup. The
+// namespace and name of the Secret are authenticated along with its data, so the encrypted data cannot be moved to
+// another Secret.
+type EncryptedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Type          corev1.SecretType    `json:"type,omitempty"`
+	Encryption    EncryptionParameters `json:"encryption"`
+	EncryptedData []byte               `json:"encryptedData"`
+}
+
+// EncryptionParameters describes how the data of an EncryptedSecret was encrypted.
+type EncryptionParameters struct {
+	Algorithm  string `json:"algorithm"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// Encrypter encrypts Secrets with a key derived from a passphrase. The key is derived once, so all Secrets encrypted
+// by an Encrypter share a salt and each has its own nonce.
+type Encrypter struct {
+	aead cipher.AEAD
+	salt []byte
+}
+
+// NewEncrypter returns an Encrypter that uses a key derived from the passphrase with a new random salt.
+func NewEncrypter(passphrase []byte) (*Encrypter, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := newAEAD(passphrase, salt, keyIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encrypter{aead: aead, salt: salt}, nil
+}
+
+// Encrypt returns the Secret with its data encrypted. The Secret's metadata is kept, except for the last applied
+// configuration annotation, managed fields and owner references, as the first two can contain the data in plaintext.
+func (e *Encrypter) Encrypt(secret corev1.Secret) (*EncryptedSecret, error) {
+	meta := *secret.ObjectMeta.DeepCopy()
+	delete(meta.Annotations, corev1.LastAppliedConfigAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	meta.ManagedFields = nil
+	meta.OwnerReferences = nil
+
+	plaintext, err := json.Marshal(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secret data: %w", err)
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &EncryptedSecret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: EncryptedSecretAPIVersion, Kind: EncryptedSecretKind},
+		ObjectMeta: meta,
+		Type:       secret.Type,
+		Encryption: EncryptionParameters{
+			Algorithm:  EncryptionAlgorithm,
+			Iterations: keyIterations,
+			Salt:       e.salt,
+			Nonce:      nonce,
+		},
+		EncryptedData: e.aead.Seal(nil, nonce, plaintext, additionalData(meta)),
+	}, nil
+}
+
+// Decrypter decrypts EncryptedSecrets with a passphrase, deriving a key for each salt it encounters once.
+type Decrypter struct {
+	passphrase []byte
+	aeads      map[string]cipher.AEAD
+}
+
+// NewDecrypter returns a Decrypter for Secrets encrypted with the passphrase.
+func NewDecrypter(passphrase []byte) *Decrypter {
+	return &Decrypter{passphrase: passphrase, aeads: map[string]cipher.AEAD{}}
+}
+
+// Decrypt returns the Secret that was encrypted.
+func (d *Decrypter) Decrypt(encrypted EncryptedSecret) (*corev1.Secret, error) {
+	parameters := encrypted.Encryption
+	if parameters.Algorithm != EncryptionAlgorithm {
+		return nil, fmt.Errorf("unsupported encryption algorithm %q", parameters.Algorithm)
+	}
+	if parameters.Iterations <= 0 || parameters.Iterations > maxKeyIterations {
+		return nil, fmt.Errorf("invalid number of key derivation iterations %d", parameters.Iterations)
+	}
+
+	cacheKey := fmt.Sprintf("%d/%x", parameters.Iterations, parameters.Salt)
+	aead, ok := d.aeads[cacheKey]
+	if !ok {
+		var err error
+		aead, err = newAEAD(d.passphrase, parameters.Salt, parameters.Iterations)
+		if err != nil {
+			return nil, err
+		}
+		d.aeads[cacheKey] = aead
+	}
+
+	if len(parameters.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(parameters.Nonce))
+	}
+
+	plaintext, err := aead.Open(nil, parameters.Nonce, encrypted.EncryptedData, additionalData(encrypted.ObjectMeta))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: encrypted.ObjectMeta,
+		Type:       encrypted.Type,
+	}
+	if err := json.Unmarshal(plaintext, &secret.Data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal secret data: %w", err)
+	}
+
+	return secret, nil
+}
+
+func newAEAD(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, iterations, keyLength, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds encrypted data to the namespace and name of its Secret.
+func additionalData(meta metav1.ObjectMeta) []byte {
+	return []byte(meta.Namespace + "/" + meta.Name)
+}
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	secret := corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com-tls", Namespace: "jetstack-secure"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": []byte("certificate"),
+			"tls.key": []byte("key"),
+		},
+	}
+
+	encrypter, err := NewEncrypter([]byte("passphrase"))
+	require.NoError(t, err)
+
+	encrypted, err := encrypter.Encrypt(secret)
+	require.NoError(t, err)
+	assert.Equal(t, EncryptedSecretKind, encrypted.Kind)
+	assert.Equal(t, secret.ObjectMeta, encrypted.ObjectMeta)
+	assert.NotContains(t, string(encrypted.EncryptedData), "key")
+
+	t.Run("decrypts with the same passphrase", func(t *testing.T) {
+		decrypted, err := NewDecrypter([]byte("passphrase")).Decrypt(*encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, &secret, decrypted)
+	})
+
+	t.Run("fails with the wrong passphrase", func(t *testing.T) {
+		_, err := NewDecrypter([]byte("wrong")).Decrypt(*encrypted)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("fails if the data is moved to another secret", func(t *testing.T) {
+		moved := *encrypted
+		moved.Name = "other"
+		_, err := NewDecrypter([]byte("passphrase")).Decrypt(moved)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("fails with too many key derivation iterations", func(t *testing.T) {
+		expensive := *encrypted
+		expensive.Encryption.Iterations = maxKeyIterations + 1
+		_, err := NewDecrypter([]byte("passphrase")).Decrypt(expensive)
+		assert.EqualError(t, err, fmt.Sprintf("invalid number of key derivation iterations %d", maxKeyIterations+1))
+	})
+
+	t.Run("drops metadata that can contain the data", func(t *testing.T) {
+		applied := secret
+		applied.ObjectMeta = metav1.ObjectMeta{
+			Name:      "example-com-tls",
+			Namespace: "jetstack-secure",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"data":{"tls.key":"a2V5"}}`,
+				"example.com/team":                 "a",
+			},
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Certificate", Name: "example-com"}},
+		}
+
+		encrypted, err := encrypter.Encrypt(applied)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"example.com/team": "a"}, encrypted.Annotations)
+		assert.Empty(t, encrypted.ManagedFields)
+		assert.Empty(t, encrypted.OwnerReferences)
+		// the secret being encrypted is not modified
+		assert.Len(t, applied.Annotations, 2)
+	})
+
+	_, err = NewEncrypter(nil)
+	assert.EqualError(t, err, "passphrase must not be empty")
+}
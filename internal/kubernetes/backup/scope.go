@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// namespaceScoped returns true if the backup is limited to some namespaces.
+func (opts ClusterBackupOptions) namespaceScoped() bool {
+	return len(opts.Namespaces) > 0 || len(opts.ExcludeNamespaces) > 0
+}
+
+// inNamespaceScope returns true if resources in the namespace are to be backed up.
+func (opts ClusterBackupOptions) inNamespaceScope(namespace string) bool {
+	for _, excluded := range opts.ExcludeNamespaces {
+		if namespace == excluded {
+			return false
+		}
+	}
+	if len(opts.Namespaces) == 0 {
+		return true
+	}
+	for _, included := range opts.Namespaces {
+		if namespace == included {
+			return true
+		}
+	}
+
+	return false
+}
+
+// namespacedRequestOptions returns the options to list namespaced resources with: one per included namespace, or
+// one across all namespaces with a field selector for the excluded namespaces.
+func (opts ClusterBackupOptions) namespacedRequestOptions(dropFields []string) []*clients.GenericRequestOptions {
+	if len(opts.Namespaces) == 0 {
+		var requirements []string
+		for _, excluded := range opts.ExcludeNamespaces {
+			requirements = append(requirements, "metadata.namespace!="+excluded)
+		}
+
+		return []*clients.GenericRequestOptions{
+			{
+				FieldSelector: strings.Join(requirements, ","),
+				LabelSelector: opts.LabelSelector,
+				DropFields:    dropFields,
+			},
+		}
+	}
+
+	namespaces := append([]string{}, opts.Namespaces...)
+	sort.Strings(namespaces)
+
+	var requestOptions []*clients.GenericRequestOptions
+	for i, namespace := range namespaces {
+		if (i > 0 && namespace == namespaces[i-1]) || !opts.inNamespaceScope(namespace) {
+			continue
+		}
+		requestOptions = append(requestOptions, &clients.GenericRequestOptions{
+			Namespace:     namespace,
+			LabelSelector: opts.LabelSelector,
+			DropFields:    dropFields,
+		})
+	}
+
+	return requestOptions
+}
+
+// issuerReference identifies an issuer a Certificate references. The namespace is that of the Certificate, which is
+// the namespace of the issuer if it is namespaced.
+type issuerReference struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// certificateIssuerReferences returns the issuers referenced by the Certificates, defaulting the group and kind as
+// cert-manager does.
+func certificateIssuerReferences(certificates []v1certmanager.Certificate) map[issuerReference]bool {
+	refs := make(map[issuerReference]bool, len(certificates))
+	for _, certificate := range certificates {
+		ref := issuerReference{
+			group:     certificate.Spec.IssuerRef.Group,
+			kind:      certificate.Spec.IssuerRef.Kind,
+			namespace: certificate.Namespace,
+			name:      certificate.Spec.IssuerRef.Name,
+		}
+		if ref.group == "" {
+			ref.group = "cert-manager.io"
+		}
+		if ref.kind == "" {
+			ref.kind = "Issuer"
+		}
+		refs[ref] = true
+	}
+
+	return refs
+}
+
+// splitClusterIssuers separates the cluster scoped issuers from the namespaced ones.
+func splitClusterIssuers(issuers []interface{}) ([]interface{}, []interface{}, error) {
+	var namespaced, clusterScoped []interface{}
+	for _, issuer := range issuers {
+		data, err := json.Marshal(issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal issuer: %w", err)
+		}
+		var meta struct {
+			metav1.ObjectMeta `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal issuer: %w", err)
+		}
+
+		if meta.Namespace != "" {
+			namespaced = append(namespaced, issuer)
+		} else {
+			clusterScoped = append(clusterScoped, issuer)
+		}
+	}
+
+	return namespaced, clusterScoped, nil
+}
+
+// filterIssuers returns the issuers which are referenced or match the label selector. Cluster scoped issuers are only
+// matched against the label selector if the backup is not limited to some namespaces, and namespaced issuers are
+// only passed to it if the backup has a label selector.
+func filterIssuers(issuers []interface{}, referenced map[issuerReference]bool, opts ClusterBackupOptions) ([]interface{}, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	// cluster scoped issuers can be referenced from any namespace
+	referencedClusterScoped := make(map[issuerReference]bool, len(referenced))
+	for ref := range referenced {
+		ref.namespace = ""
+		referencedClusterScoped[ref] = true
+	}
+
+	var filtered []interface{}
+	for _, issuer := range issuers {
+		data, err := json.Marshal(issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal issuer: %w", err)
+		}
+		var meta struct {
+			metav1.TypeMeta   `json:",inline"`
+			metav1.ObjectMeta `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal issuer: %w", err)
+		}
+
+		gv, err := schema.ParseGroupVersion(meta.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API version of %s %s: %w", meta.Kind, meta.Name, err)
+		}
+		ref := issuerReference{group: gv.Group, kind: meta.Kind, namespace: meta.Namespace, name: meta.Name}
+
+		var keep bool
+		if meta.Namespace != "" {
+			keep = referenced[ref] || selector.Matches(labels.Set(meta.Labels))
+		} else {
+			keep = referencedClusterScoped[ref] || (!opts.namespaceScoped() && selector.Matches(labels.Set(meta.Labels)))
+		}
+		if keep {
+			filtered = append(filtered, issuer)
+		}
+	}
+
+	return filtered, nil
+}
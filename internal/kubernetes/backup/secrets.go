@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	v1certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// secretReference identifies a Secret to include in a backup.
+type secretReference struct {
+	namespace string
+	name      string
+}
+
+// certificateSecretReferences returns the Secrets that the Certificates store their key pairs in.
+func certificateSecretReferences(certificates []v1certmanager.Certificate) []secretReference {
+	var refs []secretReference
+	for _, certificate := range certificates {
+		if certificate.Spec.SecretName != "" {
+			refs = append(refs, secretReference{namespace: certificate.Namespace, name: certificate.Spec.SecretName})
+		}
+	}
+
+	return refs
+}
+
+// caSecretReferences returns the Secrets holding the key pairs of CA Issuers and ClusterIssuers. The Secrets of
+// ClusterIssuers are in the cluster resource namespace.
+func caSecretReferences(issuers []interface{}, clusterResourceNamespace string) []secretReference {
+	var refs []secretReference
+	for _, issuer := range issuers {
+		switch issuer := issuer.(type) {
+		case v1certmanager.Issuer:
+			if issuer.Spec.CA != nil && issuer.Spec.CA.SecretName != "" {
+				refs = append(refs, secretReference{namespace: issuer.Namespace, name: issuer.Spec.CA.SecretName})
+			}
+		case v1certmanager.ClusterIssuer:
+			if issuer.Spec.CA != nil && issuer.Spec.CA.SecretName != "" {
+				refs = append(refs, secretReference{namespace: clusterResourceNamespace, name: issuer.Spec.CA.SecretName})
+			}
+		}
+	}
+
+	return refs
+}
+
+// fetchSecrets writes the referenced Secrets to w one at a time, encrypted if an encrypter is given, and returns the
+// number written. Secrets that do not exist, such as those of Certificates that have not been issued yet, are skipped.
+func fetchSecrets(ctx context.Context, cfg *rest.Config, refs []secretReference, dropFields []string, encrypter *Encrypter, w ResourceWriter) (int, error) {
+	secretClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&clients.GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create client for secrets: %w", err)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].namespace != refs[j].namespace {
+			return refs[i].namespace < refs[j].namespace
+		}
+		return refs[i].name < refs[j].name
+	})
+
+	count := 0
+	seen := make(map[secretReference]bool, len(refs))
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		var secret corev1.Secret
+		err := secretClient.Get(ctx, &clients.GenericRequestOptions{Namespace: ref.namespace, Name: ref.name, DropFields: dropFields}, &secret)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(os.Stderr, "skipping missing secret %s/%s\n", ref.namespace, ref.name)
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to get secret %s/%s: %w", ref.namespace, ref.name, err)
+		}
+
+		secret.APIVersion = "v1"
+		secret.Kind = "Secret"
+
+		var resource interface{} = secret
+		if encrypter != nil {
+			resource, err = encrypter.Encrypt(secret)
+			if err != nil {
+				return count, fmt.Errorf("failed to encrypt secret %s/%s: %w", ref.namespace, ref.name, err)
+			}
+		}
+
+		if err := w.WriteResource(resource); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
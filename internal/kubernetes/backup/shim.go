@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+const (
+	// ShimIntentAPIVersion and ShimIntentKind identify the certificate intent of Ingresses and Gateways in a backup.
+	// Like EncryptedSecrets, they are not served by any API server and are applied to existing resources on restore.
+	ShimIntentAPIVersion = "backup.jsctl.jetstack.io/v1alpha1"
+	ShimIntentKind       = "ShimIntent"
+
+	// tlsACMEAnnotationKey triggers ingress-shim to use the default issuer
+	tlsACMEAnnotationKey = "kubernetes.io/tls-acme"
+)
+
+// ShimIntent records the cert-manager annotations and TLS configuration of an Ingress or Gateway, from which
+// ingress-shim and gateway-shim create Certificates. The Certificates themselves are not backed up, as they are
+// owned by the Ingress or Gateway, so the intent is needed to have them recreated on another cluster. The name and
+// namespace are those of the Ingress or Gateway.
+type ShimIntent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Target      ShimIntentTarget  `json:"target"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// IngressTLS is the TLS section of an Ingress
+	IngressTLS []networkingv1.IngressTLS `json:"ingressTLS,omitempty"`
+	// GatewayListeners are the TLS configurations of the listeners of a Gateway which have them
+	GatewayListeners []GatewayListenerTLS `json:"gatewayListeners,omitempty"`
+}
+
+// ShimIntentTarget is the type of the resource the intent was recorded from.
+type ShimIntentTarget struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// GatewayListenerTLS is the TLS configuration of a Gateway listener, identified by its name.
+type GatewayListenerTLS struct {
+	Name gatewayv1beta1.SectionName       `json:"name"`
+	TLS  *gatewayv1beta1.GatewayTLSConfig `json:"tls"`
+}
+
+// IsShimAnnotation returns true if the annotation is read by ingress-shim or gateway-shim.
+func IsShimAnnotation(key string) bool {
+	return strings.HasPrefix(key, "cert-manager.io/") ||
+		strings.HasPrefix(key, "acme.cert-manager.io/") ||
+		key == tlsACMEAnnotationKey
+}
+
+// IngressShimIntent returns the certificate intent of the Ingress, or nil if it does not have the annotations that
+// trigger ingress-shim to create Certificates.
+func IngressShimIntent(ingress networkingv1.Ingress) *ShimIntent {
+	if !hasShimTrigger(ingress.Annotations, true) {
+		return nil
+	}
+
+	intent := newShimIntent(ingress.ObjectMeta, networkingv1.SchemeGroupVersion.String(), "Ingress")
+	intent.IngressTLS = ingress.Spec.TLS
+
+	return intent
+}
+
+// GatewayShimIntent returns the certificate intent of the Gateway, or nil if it does not have the annotations that
+// trigger gateway-shim to create Certificates.
+func GatewayShimIntent(gateway gatewayv1beta1.Gateway) *ShimIntent {
+	if !hasShimTrigger(gateway.Annotations, false) {
+		return nil
+	}
+
+	intent := newShimIntent(gateway.ObjectMeta, gatewayv1beta1.GroupVersion.String(), "Gateway")
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS != nil {
+			intent.GatewayListeners = append(intent.GatewayListeners, GatewayListenerTLS{Name: listener.Name, TLS: listener.TLS})
+		}
+	}
+
+	return intent
+}
+
+func newShimIntent(target metav1.ObjectMeta, apiVersion, kind string) *ShimIntent {
+	intent := &ShimIntent{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ShimIntentAPIVersion, Kind: ShimIntentKind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: target.Namespace, Name: target.Name},
+		Target:     ShimIntentTarget{APIVersion: apiVersion, Kind: kind},
+	}
+	for key, value := range target.Annotations {
+		if IsShimAnnotation(key) {
+			if intent.Annotations == nil {
+				intent.Annotations = make(map[string]string)
+			}
+			intent.Annotations[key] = value
+		}
+	}
+
+	return intent
+}
+
+// hasShimTrigger returns true if the annotations name an issuer, or for Ingresses, request the default issuer with
+// kubernetes.io/tls-acme. This matches the annotations ingress-shim and gateway-shim act on.
+func hasShimTrigger(annotations map[string]string, ingress bool) bool {
+	if _, ok := annotations[v1certmanager.IngressIssuerNameAnnotationKey]; ok {
+		return true
+	}
+	if _, ok := annotations[v1certmanager.IngressClusterIssuerNameAnnotationKey]; ok {
+		return true
+	}
+	if ingress {
+		if tlsACME, err := strconv.ParseBool(annotations[tlsACMEAnnotationKey]); err == nil && tlsACME {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gatewayCRDServed returns true if the cluster serves the Gateway API version that gateway-shim watches.
+func gatewayCRDServed(crds []apiextensionsv1.CustomResourceDefinition) bool {
+	for _, crd := range crds {
+		if crd.Spec.Group != gatewayv1beta1.GroupName || crd.Spec.Names.Kind != "Gateway" {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Name == gatewayv1beta1.GroupVersion.Version && v.Served {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fetchShimIntents returns the certificate intent of the annotated Ingresses and, if includeGateways is set,
+// Gateways listed with each of the request options.
+func fetchShimIntents(ctx context.Context, cfg *rest.Config, requestOptions []*clients.GenericRequestOptions, includeGateways bool) ([]interface{}, error) {
+	ingressClient, err := clients.NewGenericClient[*networkingv1.Ingress, *networkingv1.IngressList](
+		&clients.GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/apis",
+			Group:      networkingv1.GroupName,
+			Version:    networkingv1.SchemeGroupVersion.Version,
+			Kind:       "ingresses",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for ingresses: %w", err)
+	}
+
+	var intents []interface{}
+	for _, options := range requestOptions {
+		var ingresses networkingv1.IngressList
+		if err := ingressClient.List(ctx, options, &ingresses); err != nil {
+			return nil, fmt.Errorf("failed to list ingresses: %w", err)
+		}
+		for _, ingress := range ingresses.Items {
+			if intent := IngressShimIntent(ingress); intent != nil {
+				intents = append(intents, intent)
+			}
+		}
+	}
+
+	if !includeGateways {
+		return intents, nil
+	}
+
+	gatewayClient, err := clients.NewGenericClient[*gatewayv1beta1.Gateway, *gatewayv1beta1.GatewayList](
+		&clients.GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/apis",
+			Group:      gatewayv1beta1.GroupName,
+			Version:    gatewayv1beta1.GroupVersion.Version,
+			Kind:       "gateways",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for gateways: %w", err)
+	}
+
+	for _, options := range requestOptions {
+		var gateways gatewayv1beta1.GatewayList
+		if err := gatewayClient.List(ctx, options, &gateways); err != nil {
+			return nil, fmt.Errorf("failed to list gateways: %w", err)
+		}
+		for _, gateway := range gateways.Items {
+			if intent := GatewayShimIntent(gateway); intent != nil {
+				intents = append(intents, intent)
+			}
+		}
+	}
+
+	return intents, nil
+}
@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSOptions configure a GCS sink.
+type GCSOptions struct {
+	Bucket string
+	Prefix string
+
+	// Endpoint is the base URL of the JSON API of a service compatible with Google Cloud Storage, such as
+	// http://fake-gcs-server:4443/storage/v1/. If not set, Google Cloud Storage is used.
+	Endpoint string
+
+	// ClientOptions are passed to the storage client after those set from the other options. Unless they set
+	// credentials, Application Default Credentials are used, which are read from GOOGLE_APPLICATION_CREDENTIALS, the
+	// gcloud configuration or the metadata server of the instance or pod.
+	ClientOptions []option.ClientOption
+}
+
+// GCS is a Sink that stores backups in a Google Cloud Storage bucket.
+type GCS struct {
+	opts   GCSOptions
+	bucket *storage.BucketHandle
+}
+
+// NewGCS returns a GCS sink for the bucket.
+func NewGCS(ctx context.Context, opts GCSOptions) (*GCS, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("bucket must not be empty")
+	}
+
+	var clientOptions []option.ClientOption
+	if opts.Endpoint != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(opts.Endpoint))
+	}
+	clientOptions = append(clientOptions, opts.ClientOptions...)
+
+	client, err := storage.NewClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &GCS{opts: opts, bucket: client.Bucket(opts.Bucket)}, nil
+}
+
+// Put uploads the data to the key. The upload is cancelled if reading the data fails, so no object is created.
+func (g *GCS) Put(ctx context.Context, key string, data io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := g.bucket.Object(joinKey(g.opts.Prefix, key)).NewWriter(ctx)
+	if _, err := io.Copy(writer, data); err != nil {
+		cancel()
+		writer.Close()
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// List returns the keys of the objects in the bucket which start with the prefix.
+func (g *GCS) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	query := &storage.Query{Prefix: joinKey(g.opts.Prefix, prefix)}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return nil, err
+	}
+
+	objects := g.bucket.Objects(ctx, query)
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		key := attrs.Name
+		if g.opts.Prefix != "" {
+			key = strings.TrimPrefix(key, g.opts.Prefix+"/")
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Delete removes the object at the key.
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	if err := g.bucket.Object(joinKey(g.opts.Prefix, key)).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+)
+
+func TestGCS(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"code": 401, "message": "Invalid Credentials"}}`))
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/backups/o":
+			// small objects are uploaded in a single request, with their metadata followed by their content
+			assert.Equal(t, "multipart", r.URL.Query().Get("uploadType"))
+			_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			reader := multipart.NewReader(r.Body, params["boundary"])
+
+			part, err := reader.NextPart()
+			require.NoError(t, err)
+			var metadata struct {
+				Name string `json:"name"`
+			}
+			require.NoError(t, json.NewDecoder(part).Decode(&metadata))
+
+			part, err = reader.NextPart()
+			require.NoError(t, err)
+			content, err := io.ReadAll(part)
+			require.NoError(t, err)
+
+			objects[metadata.Name] = content
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]string{"bucket": "backups", "name": metadata.Name}))
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/backups/o":
+			var names []string
+			for name := range objects {
+				if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			// return one object per page to exercise pagination
+			start := 0
+			if token := r.URL.Query().Get("pageToken"); token != "" {
+				start = sort.SearchStrings(names, token)
+			}
+			result := map[string]interface{}{}
+			if start < len(names) {
+				result["items"] = []map[string]string{{"name": names[start]}}
+			}
+			if start+1 < len(names) {
+				result["nextPageToken"] = names[start+1]
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(result))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/storage/v1/b/backups/o/"):
+			delete(objects, strings.TrimPrefix(r.URL.Path, "/storage/v1/b/backups/o/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newSink := func(prefix, token string) Sink {
+		sink, err := NewGCS(ctx, GCSOptions{
+			Bucket:   "backups",
+			Prefix:   prefix,
+			Endpoint: server.URL + "/storage/v1/",
+			ClientOptions: []option.ClientOption{
+				option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})),
+			},
+		})
+		require.NoError(t, err)
+		return sink
+	}
+
+	sink := newSink("nightly", "token")
+	require.NoError(t, sink.Put(ctx, "prod/prod-20230203T040506Z.yaml.gz", strings.NewReader("data")))
+	require.NoError(t, sink.Put(ctx, "prod/prod-20230203T040506Z.manifest.json", strings.NewReader("{}")))
+	require.NoError(t, sink.Put(ctx, "prod-eu/prod-eu-20230203T040506Z.manifest.json", strings.NewReader("{}")))
+	assert.Equal(t, []byte("data"), objects["nightly/prod/prod-20230203T040506Z.yaml.gz"])
+
+	keys, err := sink.List(ctx, "prod/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod/prod-20230203T040506Z.manifest.json", "prod/prod-20230203T040506Z.yaml.gz"}, keys)
+
+	require.NoError(t, sink.Delete(ctx, "prod/prod-20230203T040506Z.yaml.gz"))
+	keys, err = sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod-eu/prod-eu-20230203T040506Z.manifest.json", "prod/prod-20230203T040506Z.manifest.json"}, keys)
+
+	err = newSink("", "wrong").Put(ctx, "prod/prod-20230203T040506Z.yaml.gz", strings.NewReader("data"))
+	assert.ErrorContains(t, err, "failed to upload prod/prod-20230203T040506Z.yaml.gz: ")
+	assert.ErrorContains(t, err, "Invalid Credentials")
+}
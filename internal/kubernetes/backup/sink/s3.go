@@ -0,0 +1,130 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const defaultS3Region = "us-east-1"
+
+// S3Options configure an S3 sink.
+type S3Options struct {
+	Bucket string
+	Prefix string
+
+	// Endpoint is the URL of an S3 compatible service, such as MinIO, which is addressed with path style requests. If
+	// not set, the AWS endpoint for the region is used.
+	Endpoint string
+	// Region is the region of the bucket. If not set, it is read from the AWS configuration, such as AWS_REGION, then
+	// us-east-1 is used.
+	Region string
+
+	// Credentials are used to sign requests. If not set, the default AWS credential chain is used, which reads them
+	// from the environment, the shared configuration and credentials files, a web identity token or the role of the
+	// instance or task.
+	Credentials aws.CredentialsProvider
+}
+
+// S3 is a Sink that stores backups in an S3 compatible bucket.
+type S3 struct {
+	opts     S3Options
+	client   *s3.Client
+	uploader *manager.Uploader
+}
+
+// NewS3 returns an S3 sink for the bucket.
+func NewS3(ctx context.Context, opts S3Options) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("bucket must not be empty")
+	}
+
+	var loadOptions []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(opts.Region))
+	}
+	if opts.Credentials != nil {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(opts.Credentials))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	opts.Region = cfg.Region
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.Endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(opts.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3{opts: opts, client: client, uploader: manager.NewUploader(client)}, nil
+}
+
+// Put uploads the data to the key, in parts if it is large. A multipart upload is aborted if reading the data fails,
+// so no object is created.
+func (s *S3) Put(ctx context.Context, key string, data io.Reader) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(joinKey(s.opts.Prefix, key)),
+		Body:   data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// List returns the keys of the objects in the bucket which start with the prefix.
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.opts.Bucket),
+		Prefix: aws.String(joinKey(s.opts.Prefix, prefix)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			if s.opts.Prefix != "" {
+				key = strings.TrimPrefix(key, s.opts.Prefix+"/")
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Delete removes the object at the key.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(joinKey(s.opts.Prefix, key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package sink
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in memory stand-in for an S3 compatible service.
+type fakeS3 struct {
+	t           *testing.T
+	bucket      string
+	accessKeyID string
+	mu          sync.Mutex
+	objects     map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// requests are signed by the SDK, so only the credentials they were signed with are checked
+	body, err := io.ReadAll(r.Body)
+	require.NoError(f.t, err)
+	assert.Equal(f.t, sha256Hex(body), r.Header.Get("X-Amz-Content-Sha256"))
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+f.accessKeyID+"/") {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>InvalidAccessKeyId</Code><Message>The AWS Access Key Id you provided does not exist in our records.</Message></Error>"))
+		return
+	}
+
+	bucketPath := "/" + f.bucket
+	if r.URL.Path == bucketPath && r.Method == http.MethodGet {
+		var keys []string
+		for key := range f.objects {
+			if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		// return one key per page to exercise pagination
+		start := 0
+		if token := r.URL.Query().Get("continuation-token"); token != "" {
+			start = sort.SearchStrings(keys, token)
+		}
+		type content struct {
+			Key string `xml:"Key"`
+		}
+		result := struct {
+			XMLName               xml.Name  `xml:"ListBucketResult"`
+			Contents              []content `xml:"Contents"`
+			IsTruncated           bool      `xml:"IsTruncated"`
+			NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+		}{}
+		if start < len(keys) {
+			result.Contents = []content{{Key: keys[start]}}
+		}
+		if start+1 < len(keys) {
+			result.IsTruncated = true
+			result.NextContinuationToken = keys[start+1]
+		}
+		data, err := xml.Marshal(result)
+		require.NoError(f.t, err)
+		w.Write(data)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, bucketPath+"/")
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeS3{t: t, bucket: "backups", accessKeyID: "access-key", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	sink, err := NewS3(ctx, S3Options{
+		Bucket:      "backups",
+		Prefix:      "nightly",
+		Endpoint:    server.URL,
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("access-key", "secret-key", ""),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Put(ctx, "prod/prod-20230203T040506Z.yaml.gz", strings.NewReader("data")))
+	require.NoError(t, sink.Put(ctx, "prod/prod-20230203T040506Z.manifest.json", strings.NewReader("{}")))
+	require.NoError(t, sink.Put(ctx, "prod-eu/name with spaces+plus.txt", strings.NewReader("other")))
+	assert.Equal(t, []byte("data"), fake.objects["nightly/prod/prod-20230203T040506Z.yaml.gz"])
+	assert.Equal(t, []byte("other"), fake.objects["nightly/prod-eu/name with spaces+plus.txt"])
+
+	keys, err := sink.List(ctx, "prod/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod/prod-20230203T040506Z.manifest.json", "prod/prod-20230203T040506Z.yaml.gz"}, keys)
+
+	require.NoError(t, sink.Delete(ctx, "prod/prod-20230203T040506Z.yaml.gz"))
+	require.NoError(t, sink.Delete(ctx, "prod-eu/name with spaces+plus.txt"))
+	keys, err = sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod/prod-20230203T040506Z.manifest.json"}, keys)
+
+	sink, err = NewS3(ctx, S3Options{
+		Bucket:      "backups",
+		Endpoint:    server.URL,
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("wrong", "secret-key", ""),
+	})
+	require.NoError(t, err)
+	err = sink.Put(ctx, "prod/prod-20230203T040506Z.yaml.gz", strings.NewReader("data"))
+	assert.ErrorContains(t, err, "failed to upload prod/prod-20230203T040506Z.yaml.gz: ")
+	assert.ErrorContains(t, err, "InvalidAccessKeyId")
+}
@@ -0,0 +1,70 @@
+// Package sink contains types and functions for storing cluster backups in a local directory or object storage
+// bucket, and for deleting old backups according to a retention policy.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Sink is a place backups can be stored. Keys are slash separated paths relative to the location the Sink was
+// created for.
+type Sink interface {
+	// Put stores the data read from the reader at the key, replacing any existing object. If reading fails, nothing
+	// is stored at the key.
+	Put(ctx context.Context, key string, data io.Reader) error
+	// List returns the keys of all objects whose key starts with the prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the object at the key
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the Sink for the destination, which is either a local directory path or a URL: file:///path for a
+// local directory, s3://bucket/prefix for an S3 compatible bucket or gs://bucket/prefix for a Google Cloud Storage
+// bucket. S3 and GCS URLs accept an endpoint query parameter to use a compatible service, such as MinIO, and S3 URLs
+// also accept a region. Credentials for buckets are found in the default locations of each provider's SDK.
+func New(ctx context.Context, destination string) (Sink, error) {
+	if !strings.Contains(destination, "://") {
+		return NewDirectory(destination)
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination %q: %w", destination, err)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	query := u.Query()
+
+	switch u.Scheme {
+	case "file":
+		return NewDirectory(u.Path)
+	case "s3":
+		return NewS3(ctx, S3Options{
+			Bucket:   u.Host,
+			Prefix:   prefix,
+			Endpoint: query.Get("endpoint"),
+			Region:   query.Get("region"),
+		})
+	case "gs":
+		return NewGCS(ctx, GCSOptions{
+			Bucket:   u.Host,
+			Prefix:   prefix,
+			Endpoint: query.Get("endpoint"),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported destination scheme %q, must be one of: file, s3, gs", u.Scheme)
+	}
+}
+
+// joinKey joins the prefix of a bucket and a key relative to it.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "/" + key
+}
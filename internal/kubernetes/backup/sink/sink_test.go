@@ -0,0 +1,58 @@
+package sink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	// credentials are only used once requests are made, but must be found for the clients to be created
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`), 0600))
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credentialsFile)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+
+	dir := t.TempDir()
+
+	sink, err := New(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, &Directory{root: dir}, sink)
+
+	sink, err = New(ctx, "file://"+dir)
+	require.NoError(t, err)
+	assert.Equal(t, &Directory{root: dir}, sink)
+
+	sink, err = New(ctx, "s3://backups/clusters/nightly?endpoint=http://minio:9000&region=eu-west-1")
+	require.NoError(t, err)
+	s3, ok := sink.(*S3)
+	require.True(t, ok)
+	assert.Equal(t, "backups", s3.opts.Bucket)
+	assert.Equal(t, "clusters/nightly", s3.opts.Prefix)
+	assert.Equal(t, "http://minio:9000", s3.opts.Endpoint)
+	assert.Equal(t, "eu-west-1", s3.opts.Region)
+
+	sink, err = New(ctx, "s3://backups")
+	require.NoError(t, err)
+	assert.Equal(t, defaultS3Region, sink.(*S3).opts.Region)
+
+	sink, err = New(ctx, "gs://backups/clusters")
+	require.NoError(t, err)
+	gcs, ok := sink.(*GCS)
+	require.True(t, ok)
+	assert.Equal(t, "backups", gcs.opts.Bucket)
+	assert.Equal(t, "clusters", gcs.opts.Prefix)
+
+	_, err = New(ctx, "azure://backups")
+	assert.EqualError(t, err, `unsupported destination scheme "azure", must be one of: file, s3, gs`)
+}
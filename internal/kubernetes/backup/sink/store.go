@@ -0,0 +1,248 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// timestampFormat is used in backup names, it sorts in the same order as the times it represents
+	timestampFormat = "20060102T150405Z"
+
+	manifestSuffix = ".manifest.json"
+)
+
+// Manifest describes a stored backup. It is written after the backup's data, so a backup without a manifest is
+// incomplete.
+type Manifest struct {
+	Cluster   string           `json:"cluster"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Objects   []ManifestObject `json:"objects"`
+}
+
+// ManifestObject describes an object that is part of a stored backup. Checksums are hex encoded SHA-256 digests of
+// the object as stored and of its uncompressed content.
+type ManifestObject struct {
+	Key                string `json:"key"`
+	Format             string `json:"format"`
+	Compression        string `json:"compression"`
+	Size               int    `json:"size"`
+	SHA256             string `json:"sha256"`
+	UncompressedSize   int    `json:"uncompressedSize"`
+	UncompressedSHA256 string `json:"uncompressedSHA256"`
+}
+
+// StoreOptions describe a backup to store.
+type StoreOptions struct {
+	// ClusterName is used to name the backup, so backups of several clusters can share a destination
+	ClusterName string
+	// Format is the format of the backup, used as its file extension
+	Format string
+	// Write writes the backup to w, which compresses it and streams it to the sink as it is written
+	Write func(w io.Writer) error
+	// Now is the time the backup was made
+	Now time.Time
+}
+
+// Store compresses the backup with gzip and stores it, followed by its manifest, at keys named after the cluster and
+// time: <cluster>/<cluster>-<timestamp>.<format>.gz and <cluster>/<cluster>-<timestamp>.manifest.json. The backup is
+// streamed to the sink rather than held in memory, and if writing it fails no manifest is stored.
+func Store(ctx context.Context, sink Sink, opts StoreOptions) (*Manifest, error) {
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return nil, err
+	}
+
+	createdAt := opts.Now.UTC().Truncate(time.Second)
+	name := backupName(opts.ClusterName, createdAt)
+	dataKey := name + "." + opts.Format + ".gz"
+
+	compressed, uncompressed := newDigestWriter(), newDigestWriter()
+	reader, writer := io.Pipe()
+	putDone := make(chan struct{})
+	writeErr := make(chan error, 1)
+	go func() {
+		gzipWriter := gzip.NewWriter(io.MultiWriter(writer, compressed))
+		err := opts.Write(io.MultiWriter(gzipWriter, uncompressed))
+		if err == nil {
+			err = gzipWriter.Close()
+		}
+		select {
+		case <-putDone:
+			// the sink stopped reading early, so the error is only a consequence of the sink's
+			writeErr <- nil
+		default:
+			writeErr <- err
+		}
+		writer.CloseWithError(err)
+	}()
+
+	putErr := sink.Put(ctx, dataKey, reader)
+	close(putDone)
+	// unblock the writer if the sink stopped reading early
+	reader.CloseWithError(fmt.Errorf("failed to upload %s", dataKey))
+	if err := <-writeErr; err != nil {
+		return nil, err
+	}
+	if putErr != nil {
+		return nil, putErr
+	}
+
+	manifest := &Manifest{
+		Cluster:   opts.ClusterName,
+		CreatedAt: createdAt,
+		Objects: []ManifestObject{
+			{
+				Key:                dataKey,
+				Format:             opts.Format,
+				Compression:        "gzip",
+				Size:               compressed.size,
+				SHA256:             compressed.sha256Hex(),
+				UncompressedSize:   uncompressed.size,
+				UncompressedSHA256: uncompressed.sha256Hex(),
+			},
+		},
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := sink.Put(ctx, name+manifestSuffix, bytes.NewReader(manifestData)); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Retention determines which backups of a cluster are kept. A backup is deleted if it is not one of the newest
+// KeepLast complete backups, or if it is older than KeepFor. Either may be left unset, and if both are unset no
+// backups are deleted.
+type Retention struct {
+	KeepLast int
+	KeepFor  time.Duration
+}
+
+// ApplyRetention deletes the backups of the cluster which are not kept by the retention policy and returns the keys of
+// the deleted objects. Only complete backups, which have a manifest, count towards KeepLast. Incomplete backups are
+// deleted once a newer backup is complete, as they are no longer being written, or once they are older than KeepFor.
+// Objects whose names do not match those of stored backups are left alone.
+func ApplyRetention(ctx context.Context, sink Sink, clusterName string, retention Retention, now time.Time) ([]string, error) {
+	if err := validateClusterName(clusterName); err != nil {
+		return nil, err
+	}
+	if retention.KeepLast <= 0 && retention.KeepFor <= 0 {
+		return nil, nil
+	}
+
+	prefix := clusterName + "/" + clusterName + "-"
+	keys, err := sink.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// group the objects of each backup by the time it was made
+	objects := make(map[time.Time][]string)
+	complete := make(map[time.Time]bool)
+	for _, key := range keys {
+		rest := strings.TrimPrefix(key, prefix)
+		if len(rest) < len(timestampFormat) || strings.Contains(rest, "/") {
+			continue
+		}
+		createdAt, err := time.Parse(timestampFormat, rest[:len(timestampFormat)])
+		if err != nil {
+			continue
+		}
+		objects[createdAt] = append(objects[createdAt], key)
+		if strings.HasSuffix(key, manifestSuffix) {
+			complete[createdAt] = true
+		}
+	}
+
+	times := make([]time.Time, 0, len(objects))
+	for createdAt := range objects {
+		times = append(times, createdAt)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].After(times[j]) })
+
+	var (
+		deleted       []string
+		completeCount int
+	)
+	for _, createdAt := range times {
+		keep := true
+		if complete[createdAt] {
+			completeCount++
+			if retention.KeepLast > 0 && completeCount > retention.KeepLast {
+				keep = false
+			}
+		} else if completeCount > 0 {
+			keep = false
+		}
+		if retention.KeepFor > 0 && now.Sub(createdAt) > retention.KeepFor {
+			keep = false
+		}
+		if keep {
+			continue
+		}
+
+		// the manifest is deleted last, so a backup which is partially deleted is treated as incomplete
+		backupKeys := objects[createdAt]
+		sort.Slice(backupKeys, func(i, j int) bool {
+			return !strings.HasSuffix(backupKeys[i], manifestSuffix) && strings.HasSuffix(backupKeys[j], manifestSuffix)
+		})
+		for _, key := range backupKeys {
+			if err := sink.Delete(ctx, key); err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, key)
+		}
+	}
+
+	return deleted, nil
+}
+
+func backupName(clusterName string, createdAt time.Time) string {
+	return fmt.Sprintf("%s/%s-%s", clusterName, clusterName, createdAt.Format(timestampFormat))
+}
+
+// validateClusterName checks that the cluster name can be used in keys without being confused with another's.
+func validateClusterName(clusterName string) error {
+	if clusterName == "" {
+		return fmt.Errorf("cluster name must not be empty")
+	}
+	if strings.ContainsAny(clusterName, "/\\") || clusterName == "." || clusterName == ".." {
+		return fmt.Errorf("invalid cluster name %q", clusterName)
+	}
+
+	return nil
+}
+
+// digestWriter computes the size and SHA-256 digest of the data written to it.
+type digestWriter struct {
+	hash hash.Hash
+	size int
+}
+
+func newDigestWriter() *digestWriter {
+	return &digestWriter{hash: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	d.size += len(p)
+	return d.hash.Write(p)
+}
+
+// sha256Hex returns the hex encoded digest of the data written so far.
+func (d *digestWriter) sha256Hex() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
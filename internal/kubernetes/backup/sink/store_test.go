@@ -0,0 +1,209 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	sink, err := NewDirectory(root)
+	require.NoError(t, err)
+
+	data := []byte("apiVersion: cert-manager.io/v1\nkind: Certificate\n")
+	now := time.Date(2023, 2, 3, 4, 5, 6, 7, time.UTC)
+
+	manifest, err := Store(ctx, sink, StoreOptions{ClusterName: "prod", Format: "yaml", Write: writeData(data), Now: now})
+	require.NoError(t, err)
+
+	keys, err := sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod/prod-20230203T040506Z.manifest.json", "prod/prod-20230203T040506Z.yaml.gz"}, keys)
+
+	compressed, err := os.ReadFile(filepath.Join(root, "prod", "prod-20230203T040506Z.yaml.gz"))
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, data, uncompressed)
+
+	var storedManifest Manifest
+	manifestData, err := os.ReadFile(filepath.Join(root, "prod", "prod-20230203T040506Z.manifest.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(manifestData, &storedManifest))
+	assert.Equal(t, *manifest, storedManifest)
+	assert.Equal(t, "prod", storedManifest.Cluster)
+	assert.Equal(t, now.Truncate(time.Second), storedManifest.CreatedAt)
+	require.Len(t, storedManifest.Objects, 1)
+	assert.Equal(t, ManifestObject{
+		Key:                "prod/prod-20230203T040506Z.yaml.gz",
+		Format:             "yaml",
+		Compression:        "gzip",
+		Size:               len(compressed),
+		SHA256:             sha256Hex(compressed),
+		UncompressedSize:   len(data),
+		UncompressedSHA256: sha256Hex(data),
+	}, storedManifest.Objects[0])
+
+	_, err = Store(ctx, sink, StoreOptions{ClusterName: "../prod", Format: "yaml", Write: writeData(data), Now: now})
+	assert.EqualError(t, err, `invalid cluster name "../prod"`)
+}
+
+func TestStore_WriteError(t *testing.T) {
+	ctx := context.Background()
+	sink, err := NewDirectory(t.TempDir())
+	require.NoError(t, err)
+
+	write := func(w io.Writer) error {
+		if _, err := w.Write([]byte("apiVersion: cert-manager.io/v1\n")); err != nil {
+			return err
+		}
+		return errors.New("error backing up cluster: connection refused")
+	}
+	_, err = Store(ctx, sink, StoreOptions{ClusterName: "prod", Format: "yaml", Write: write, Now: time.Now()})
+	assert.EqualError(t, err, "error backing up cluster: connection refused")
+
+	// neither the partially written backup nor a manifest is stored
+	keys, err := sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+// failingSink fails to store data without reading it, like a sink that cannot reach its bucket.
+type failingSink struct {
+	Sink
+}
+
+func (f failingSink) Put(_ context.Context, key string, _ io.Reader) error {
+	return fmt.Errorf("failed to upload %s: access denied", key)
+}
+
+func TestStore_PutError(t *testing.T) {
+	// the backup is larger than the pipe and gzip buffers, so writing it blocks until the sink gives up
+	data := bytes.Repeat([]byte("apiVersion: cert-manager.io/v1\n"), 100000)
+
+	_, err := Store(context.Background(), failingSink{}, StoreOptions{ClusterName: "prod", Format: "yaml", Write: writeData(data), Now: time.Date(2023, 2, 3, 4, 5, 6, 0, time.UTC)})
+	assert.EqualError(t, err, "failed to upload prod/prod-20230203T040506Z.yaml.gz: access denied")
+}
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	newSink := func(t *testing.T) Sink {
+		sink, err := NewDirectory(t.TempDir())
+		require.NoError(t, err)
+
+		// a backup a day for the last five days, newest first
+		for i := 0; i < 5; i++ {
+			_, err := Store(ctx, sink, StoreOptions{ClusterName: "prod", Format: "json", Write: writeData([]byte("{}")), Now: now.Add(-time.Duration(i) * 24 * time.Hour)})
+			require.NoError(t, err)
+		}
+		// incomplete backups that are still being written, that failed between complete backups and that are older
+		// than all complete backups, another cluster's backup and an unrelated file
+		require.NoError(t, sink.Put(ctx, "prod/prod-20230210T010000Z.json.gz", strings.NewReader("{}")))
+		require.NoError(t, sink.Put(ctx, "prod/prod-20230208T120000Z.json.gz", strings.NewReader("{}")))
+		require.NoError(t, sink.Put(ctx, "prod/prod-20230101T000000Z.json.gz", strings.NewReader("{}")))
+		_, err = Store(ctx, sink, StoreOptions{ClusterName: "prod-eu", Format: "json", Write: writeData([]byte("{}")), Now: now.Add(-30 * 24 * time.Hour)})
+		require.NoError(t, err)
+		require.NoError(t, sink.Put(ctx, "prod/notes.txt", strings.NewReader("notes")))
+
+		return sink
+	}
+
+	testCases := map[string]struct {
+		retention       Retention
+		expectedDeleted []string
+	}{
+		"no retention policy": {},
+		"keep last": {
+			retention: Retention{KeepLast: 3},
+			expectedDeleted: []string{
+				"prod/prod-20230208T120000Z.json.gz",
+				"prod/prod-20230207T000000Z.json.gz",
+				"prod/prod-20230207T000000Z.manifest.json",
+				"prod/prod-20230206T000000Z.json.gz",
+				"prod/prod-20230206T000000Z.manifest.json",
+				"prod/prod-20230101T000000Z.json.gz",
+			},
+		},
+		"keep for": {
+			retention: Retention{KeepFor: 36 * time.Hour},
+			expectedDeleted: []string{
+				"prod/prod-20230208T120000Z.json.gz",
+				"prod/prod-20230208T000000Z.json.gz",
+				"prod/prod-20230208T000000Z.manifest.json",
+				"prod/prod-20230207T000000Z.json.gz",
+				"prod/prod-20230207T000000Z.manifest.json",
+				"prod/prod-20230206T000000Z.json.gz",
+				"prod/prod-20230206T000000Z.manifest.json",
+				"prod/prod-20230101T000000Z.json.gz",
+			},
+		},
+		"keep last and keep for": {
+			retention: Retention{KeepLast: 1, KeepFor: 36 * time.Hour},
+			expectedDeleted: []string{
+				"prod/prod-20230209T000000Z.json.gz",
+				"prod/prod-20230209T000000Z.manifest.json",
+				"prod/prod-20230208T120000Z.json.gz",
+				"prod/prod-20230208T000000Z.json.gz",
+				"prod/prod-20230208T000000Z.manifest.json",
+				"prod/prod-20230207T000000Z.json.gz",
+				"prod/prod-20230207T000000Z.manifest.json",
+				"prod/prod-20230206T000000Z.json.gz",
+				"prod/prod-20230206T000000Z.manifest.json",
+				"prod/prod-20230101T000000Z.json.gz",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sink := newSink(t)
+			before, err := sink.List(ctx, "")
+			require.NoError(t, err)
+
+			deleted, err := ApplyRetention(ctx, sink, "prod", tc.retention, now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedDeleted, deleted)
+
+			after, err := sink.List(ctx, "")
+			require.NoError(t, err)
+			assert.Len(t, after, len(before)-len(deleted))
+			assert.Contains(t, after, "prod/prod-20230210T000000Z.manifest.json")
+			assert.Contains(t, after, "prod/prod-20230210T010000Z.json.gz")
+			assert.Contains(t, after, "prod/notes.txt")
+			assert.Contains(t, after, "prod-eu/prod-eu-20230111T000000Z.manifest.json")
+		})
+	}
+}
+
+// writeData returns a StoreOptions.Write function which writes the data.
+func writeData(data []byte) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
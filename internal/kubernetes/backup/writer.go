@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ResourceWriter receives the resources of a backup as they are fetched.
+type ResourceWriter interface {
+	WriteResource(resource interface{}) error
+}
+
+// WriteResource appends the resource to the backup.
+func (c *ClusterBackup) WriteResource(resource interface{}) error {
+	*c = append(*c, resource)
+	return nil
+}
+
+// StreamWriter writes the resources of a backup to an io.Writer as they are fetched, in the same formats as ToYAML
+// and ToJSON, so that large backups do not need to be held in memory. YAML backups are written as a stream of
+// documents and JSON backups as a v1 List, whose items are written one at a time. Close must be called once all
+// resources have been written to complete the output.
+type StreamWriter struct {
+	w      io.Writer
+	format string
+	count  int
+	closed bool
+}
+
+// NewStreamWriter returns a StreamWriter writing resources to w in the format, which is either yaml or json.
+func NewStreamWriter(w io.Writer, format string) (*StreamWriter, error) {
+	switch format {
+	case "yaml", "json":
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+
+	return &StreamWriter{w: w, format: format}, nil
+}
+
+// WriteResource writes the resource to the output.
+func (s *StreamWriter) WriteResource(resource interface{}) error {
+	if s.closed {
+		return fmt.Errorf("cannot write resource, the backup has been closed")
+	}
+
+	var data []byte
+	var err error
+	switch s.format {
+	case "yaml":
+		data, err = yaml.Marshal(resource)
+		if err != nil {
+			return fmt.Errorf("error marshalling resource to YAML: %w", err)
+		}
+		data = append([]byte("---\n"), data...)
+	case "json":
+		// the items are indented as they would be by marshalling the whole list
+		data, err = json.MarshalIndent(resource, "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling resource to JSON: %w", err)
+		}
+		separator := ",\n    "
+		if s.count == 0 {
+			separator = "{\n  \"apiVersion\": \"v1\",\n  \"items\": [\n    "
+		}
+		data = append([]byte(separator), data...)
+	}
+
+	if _, err := s.w.Write(data); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+	s.count++
+
+	return nil
+}
+
+// Count returns the number of resources written so far.
+func (s *StreamWriter) Count() int {
+	return s.count
+}
+
+// Close completes the output. It does not close the underlying io.Writer.
+func (s *StreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.format != "json" {
+		return nil
+	}
+
+	end := "\n  ],\n  \"kind\": \"List\"\n}"
+	if s.count == 0 {
+		end = "{\n  \"apiVersion\": \"v1\",\n  \"items\": [],\n  \"kind\": \"List\"\n}"
+	}
+	if _, err := io.WriteString(s.w, end); err != nil {
+		return fmt.Errorf("error writing backup: %w", err)
+	}
+
+	return nil
+}
+
+// writeBackup writes the resources in the backup to a buffer with a StreamWriter.
+func writeBackup(c *ClusterBackup, format string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer, err := NewStreamWriter(buf, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range *c {
+		if err := writer.WriteResource(r); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriter(t *testing.T) {
+	resources := []interface{}{
+		map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "a"}},
+		map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "b"}},
+	}
+
+	testCases := map[string]struct {
+		format    string
+		resources []interface{}
+		expected  string
+	}{
+		"yaml": {
+			format:    "yaml",
+			resources: resources,
+			expected: `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: b
+`,
+		},
+		"json": {
+			format:    "json",
+			resources: resources,
+			expected: `{
+  "apiVersion": "v1",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {
+        "name": "a"
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {
+        "name": "b"
+      }
+    }
+  ],
+  "kind": "List"
+}`,
+		},
+		"empty json": {
+			format: "json",
+			expected: `{
+  "apiVersion": "v1",
+  "items": [],
+  "kind": "List"
+}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewStreamWriter(&buf, tc.format)
+			require.NoError(t, err)
+
+			for _, resource := range tc.resources {
+				require.NoError(t, writer.WriteResource(resource))
+			}
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, tc.expected, buf.String())
+			assert.Equal(t, len(tc.resources), writer.Count())
+			if tc.format == "json" {
+				assert.True(t, json.Valid(buf.Bytes()))
+			}
+
+			require.Error(t, writer.WriteResource(resources[0]))
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := NewStreamWriter(&bytes.Buffer{}, "toml")
+		require.EqualError(t, err, "unknown output format: toml")
+	})
+}
@@ -0,0 +1,359 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PolicyRequest holds the attributes of a certificate request that CertificateRequestPolicies are evaluated against.
+// It can be built from a CertificateRequest, or from a Certificate to see how the requests cert-manager creates for it
+// would be evaluated.
+type PolicyRequest struct {
+	Namespace string
+	Name      string
+	IssuerRef cmmeta.ObjectReference
+
+	CommonName     string
+	DNSNames       []string
+	IPAddresses    []string
+	URIs           []string
+	EmailAddresses []string
+
+	Organizations       []string
+	Countries           []string
+	OrganizationalUnits []string
+	Localities          []string
+	Provinces           []string
+	StreetAddresses     []string
+	PostalCodes         []string
+	SerialNumber        string
+
+	IsCA     bool
+	Usages   []cmapi.KeyUsage
+	Duration *metav1.Duration
+
+	KeyAlgorithm cmapi.PrivateKeyAlgorithm
+	// KeySize is the size of the key in bits, or -1 for Ed25519 keys which have no size
+	KeySize int
+}
+
+// PolicySimulation is the result of evaluating a CertificateRequestPolicy against a PolicyRequest locally.
+type PolicySimulation struct {
+	Policy string `json:"policy"`
+	// Selected is true if the issuerRef selector of the policy matches the request
+	Selected bool `json:"selected"`
+	// Approved is true if the policy is selected and the request satisfies all its allowed attributes and constraints
+	Approved bool `json:"approved"`
+	// Violations explains each allowed attribute or constraint of the policy that the request does not satisfy
+	Violations []string `json:"violations,omitempty"`
+	// UnevaluatedPlugins lists the plugins configured on the policy, which cannot be evaluated locally
+	UnevaluatedPlugins []string `json:"unevaluatedPlugins,omitempty"`
+}
+
+// PolicyRequestFromCertificateRequest returns the attributes of the CertificateRequest, read from its CSR.
+func PolicyRequestFromCertificateRequest(request cmapi.CertificateRequest) (PolicyRequest, error) {
+	block, _ := pem.Decode(request.Spec.Request)
+	if block == nil {
+		return PolicyRequest{}, errors.New("failed to decode PEM encoded certificate signing request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return PolicyRequest{}, fmt.Errorf("failed to parse certificate signing request: %w", err)
+	}
+
+	algorithm, size, err := publicKeyAlgorithm(csr.PublicKey)
+	if err != nil {
+		return PolicyRequest{}, err
+	}
+
+	policyRequest := PolicyRequest{
+		Namespace:           request.Namespace,
+		Name:                request.Name,
+		IssuerRef:           request.Spec.IssuerRef,
+		CommonName:          csr.Subject.CommonName,
+		DNSNames:            csr.DNSNames,
+		EmailAddresses:      csr.EmailAddresses,
+		Organizations:       csr.Subject.Organization,
+		Countries:           csr.Subject.Country,
+		OrganizationalUnits: csr.Subject.OrganizationalUnit,
+		Localities:          csr.Subject.Locality,
+		Provinces:           csr.Subject.Province,
+		StreetAddresses:     csr.Subject.StreetAddress,
+		PostalCodes:         csr.Subject.PostalCode,
+		SerialNumber:        csr.Subject.SerialNumber,
+		IsCA:                request.Spec.IsCA,
+		Usages:              request.Spec.Usages,
+		Duration:            request.Spec.Duration,
+		KeyAlgorithm:        algorithm,
+		KeySize:             size,
+	}
+	for _, ip := range csr.IPAddresses {
+		policyRequest.IPAddresses = append(policyRequest.IPAddresses, ip.String())
+	}
+	for _, uri := range csr.URIs {
+		policyRequest.URIs = append(policyRequest.URIs, uri.String())
+	}
+
+	return policyRequest, nil
+}
+
+// PolicyRequestFromCertificate returns the attributes of the CertificateRequests cert-manager would create for the
+// Certificate, applying cert-manager's defaults for the private key.
+func PolicyRequestFromCertificate(certificate cmapi.Certificate) PolicyRequest {
+	spec := certificate.Spec
+	policyRequest := PolicyRequest{
+		Namespace:      certificate.Namespace,
+		Name:           certificate.Name,
+		IssuerRef:      spec.IssuerRef,
+		CommonName:     spec.CommonName,
+		DNSNames:       spec.DNSNames,
+		IPAddresses:    spec.IPAddresses,
+		URIs:           spec.URIs,
+		EmailAddresses: spec.EmailAddresses,
+		IsCA:           spec.IsCA,
+		Usages:         spec.Usages,
+		Duration:       spec.Duration,
+		KeyAlgorithm:   cmapi.RSAKeyAlgorithm,
+	}
+
+	if subject := spec.Subject; subject != nil {
+		policyRequest.Organizations = subject.Organizations
+		policyRequest.Countries = subject.Countries
+		policyRequest.OrganizationalUnits = subject.OrganizationalUnits
+		policyRequest.Localities = subject.Localities
+		policyRequest.Provinces = subject.Provinces
+		policyRequest.StreetAddresses = subject.StreetAddresses
+		policyRequest.PostalCodes = subject.PostalCodes
+		policyRequest.SerialNumber = subject.SerialNumber
+	}
+
+	if spec.PrivateKey != nil && spec.PrivateKey.Algorithm != "" {
+		policyRequest.KeyAlgorithm = spec.PrivateKey.Algorithm
+	}
+	switch policyRequest.KeyAlgorithm {
+	case cmapi.RSAKeyAlgorithm:
+		policyRequest.KeySize = 2048
+	case cmapi.ECDSAKeyAlgorithm:
+		policyRequest.KeySize = 256
+	case cmapi.Ed25519KeyAlgorithm:
+		policyRequest.KeySize = -1
+	}
+	if spec.PrivateKey != nil && spec.PrivateKey.Size != 0 && policyRequest.KeyAlgorithm != cmapi.Ed25519KeyAlgorithm {
+		policyRequest.KeySize = spec.PrivateKey.Size
+	}
+
+	return policyRequest
+}
+
+// SimulatePolicies evaluates each CertificateRequestPolicy against the request in the same way as approver-policy,
+// returning the results sorted by policy name. The request would be approved if any selected policy approves it and
+// that policy is ready and bound to the requester by RBAC, neither of which is checked here.
+func SimulatePolicies(request PolicyRequest, policies []policyapi.CertificateRequestPolicy) []PolicySimulation {
+	simulations := make([]PolicySimulation, 0, len(policies))
+	for _, policy := range policies {
+		simulation := PolicySimulation{
+			Policy:   policy.Name,
+			Selected: selectsIssuer(policy, request.IssuerRef),
+		}
+
+		for plugin := range policy.Spec.Plugins {
+			simulation.UnevaluatedPlugins = append(simulation.UnevaluatedPlugins, plugin)
+		}
+		sort.Strings(simulation.UnevaluatedPlugins)
+
+		if simulation.Selected {
+			errs := append(evaluateAllowed(policy.Spec.Allowed, request), evaluateConstraints(policy.Spec.Constraints, request)...)
+			for _, err := range errs {
+				simulation.Violations = append(simulation.Violations, err.Error())
+			}
+			simulation.Approved = len(errs) == 0
+		}
+
+		simulations = append(simulations, simulation)
+	}
+
+	sort.Slice(simulations, func(i, j int) bool {
+		return simulations[i].Policy < simulations[j].Policy
+	})
+
+	return simulations
+}
+
+// evaluateAllowed returns the attributes of the request that are not allowed by the policy, described with the same
+// messages approver-policy uses.
+func evaluateAllowed(allowed *policyapi.CertificateRequestPolicyAllowed, request PolicyRequest) field.ErrorList {
+	if allowed == nil {
+		allowed = &policyapi.CertificateRequestPolicyAllowed{}
+	}
+
+	var (
+		errs    field.ErrorList
+		fldPath = field.NewPath("spec", "allowed")
+	)
+
+	errs = append(errs, evaluateAllowedString(fldPath.Child("commonName"), allowed.CommonName, request.CommonName)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("dnsNames"), allowed.DNSNames, request.DNSNames)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("ipAddresses"), allowed.IPAddresses, request.IPAddresses)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("uris"), allowed.URIs, request.URIs)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("emailAddresses"), allowed.EmailAddresses, request.EmailAddresses)...)
+
+	if request.IsCA {
+		if allowed.IsCA == nil {
+			errs = append(errs, field.Invalid(fldPath.Child("isCA"), request.IsCA, "nil"))
+		} else if !*allowed.IsCA {
+			errs = append(errs, field.Invalid(fldPath.Child("isCA"), request.IsCA, strconv.FormatBool(*allowed.IsCA)))
+		}
+	}
+
+	if len(request.Usages) > 0 {
+		var requestUsages, policyUsages []string
+		for _, usage := range request.Usages {
+			requestUsages = append(requestUsages, string(usage))
+		}
+
+		if allowed.Usages == nil {
+			errs = append(errs, field.Invalid(fldPath.Child("usages"), requestUsages, "nil"))
+		} else {
+			for _, usage := range *allowed.Usages {
+				policyUsages = append(policyUsages, string(usage))
+			}
+			if !wildcardSubset(policyUsages, requestUsages) {
+				errs = append(errs, field.Invalid(fldPath.Child("usages"), requestUsages, strings.Join(policyUsages, ", ")))
+			}
+		}
+	}
+
+	subject := allowed.Subject
+	if subject == nil {
+		subject = &policyapi.CertificateRequestPolicyAllowedX509Subject{}
+	}
+	fldPath = fldPath.Child("subject")
+
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("organizations"), subject.Organizations, request.Organizations)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("countries"), subject.Countries, request.Countries)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("organizationalUnits"), subject.OrganizationalUnits, request.OrganizationalUnits)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("localities"), subject.Localities, request.Localities)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("provinces"), subject.Provinces, request.Provinces)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("streetAddresses"), subject.StreetAddresses, request.StreetAddresses)...)
+	errs = append(errs, evaluateAllowedStrings(fldPath.Child("postalCodes"), subject.PostalCodes, request.PostalCodes)...)
+	errs = append(errs, evaluateAllowedString(fldPath.Child("serialNumber"), subject.SerialNumber, request.SerialNumber)...)
+
+	return errs
+}
+
+// evaluateAllowedString checks a single valued attribute of the request, which must match the allowed value if set
+// and be set if required.
+func evaluateAllowedString(fldPath *field.Path, allowed *policyapi.CertificateRequestPolicyAllowedString, value string) field.ErrorList {
+	switch {
+	case value != "" && (allowed == nil || allowed.Value == nil):
+		return field.ErrorList{field.Invalid(fldPath.Child("value"), value, "nil")}
+	case value != "" && !wildcardMatch(allowed.Value, value):
+		return field.ErrorList{field.Invalid(fldPath.Child("value"), value, *allowed.Value)}
+	case value == "" && allowed != nil && allowed.Required != nil && *allowed.Required:
+		return field.ErrorList{field.Required(fldPath.Child("required"), strconv.FormatBool(*allowed.Required))}
+	}
+
+	return nil
+}
+
+// evaluateAllowedStrings checks a multi valued attribute of the request, each value of which must match one of the
+// allowed values, and which must have a value if required.
+func evaluateAllowedStrings(fldPath *field.Path, allowed *policyapi.CertificateRequestPolicyAllowedStringSlice, values []string) field.ErrorList {
+	switch {
+	case len(values) > 0 && (allowed == nil || allowed.Values == nil):
+		return field.ErrorList{field.Invalid(fldPath.Child("values"), values, "nil")}
+	case len(values) > 0 && !wildcardSubset(*allowed.Values, values):
+		return field.ErrorList{field.Invalid(fldPath.Child("values"), values, strings.Join(*allowed.Values, ", "))}
+	case len(values) == 0 && allowed != nil && allowed.Required != nil && *allowed.Required:
+		return field.ErrorList{field.Required(fldPath.Child("required"), strconv.FormatBool(*allowed.Required))}
+	}
+
+	return nil
+}
+
+// evaluateConstraints returns the constraints of the policy that the request does not satisfy, described with the
+// same messages approver-policy uses.
+func evaluateConstraints(constraints *policyapi.CertificateRequestPolicyConstraints, request PolicyRequest) field.ErrorList {
+	if constraints == nil {
+		return nil
+	}
+
+	var (
+		errs     field.ErrorList
+		fldPath  = field.NewPath("spec", "constraints")
+		duration = "<nil>"
+	)
+	if request.Duration != nil {
+		duration = request.Duration.Duration.String()
+	}
+
+	// a request without a duration satisfies neither duration constraint
+	if maxDuration := constraints.MaxDuration; maxDuration != nil && (request.Duration == nil || maxDuration.Duration < request.Duration.Duration) {
+		errs = append(errs, field.Invalid(fldPath.Child("maxDuration"), duration, maxDuration.Duration.String()))
+	}
+	if minDuration := constraints.MinDuration; minDuration != nil && (request.Duration == nil || minDuration.Duration > request.Duration.Duration) {
+		errs = append(errs, field.Invalid(fldPath.Child("minDuration"), duration, minDuration.Duration.String()))
+	}
+
+	if key := constraints.PrivateKey; key != nil {
+		fldPath := fldPath.Child("privateKey")
+		if key.Algorithm != nil && *key.Algorithm != request.KeyAlgorithm {
+			errs = append(errs, field.Invalid(fldPath.Child("algorithm"), string(request.KeyAlgorithm), string(*key.Algorithm)))
+		}
+		if key.MaxSize != nil && *key.MaxSize < request.KeySize {
+			errs = append(errs, field.Invalid(fldPath.Child("maxSize"), strconv.Itoa(request.KeySize), strconv.Itoa(*key.MaxSize)))
+		}
+		if key.MinSize != nil && *key.MinSize > request.KeySize {
+			errs = append(errs, field.Invalid(fldPath.Child("minSize"), strconv.Itoa(request.KeySize), strconv.Itoa(*key.MinSize)))
+		}
+	}
+
+	return errs
+}
+
+// publicKeyAlgorithm returns the algorithm and size in bits of the public key, with a size of -1 for Ed25519 keys.
+func publicKeyAlgorithm(publicKey any) (cmapi.PrivateKeyAlgorithm, int, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return cmapi.RSAKeyAlgorithm, key.N.BitLen(), nil
+	case *ecdsa.PublicKey:
+		return cmapi.ECDSAKeyAlgorithm, key.Curve.Params().BitSize, nil
+	case ed25519.PublicKey:
+		return cmapi.Ed25519KeyAlgorithm, -1, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// wildcardSubset returns true if every value matches at least one of the patterns.
+func wildcardSubset(patterns, values []string) bool {
+	for _, value := range values {
+		matched := false
+		for i := range patterns {
+			if wildcardMatch(&patterns[i], value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
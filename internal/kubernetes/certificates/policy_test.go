@@ -0,0 +1,141 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPolicyRequestFromCertificateRequest(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}, key)
+	require.NoError(t, err)
+
+	request := cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"},
+		Spec: cmapi.CertificateRequestSpec{
+			Request:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}),
+			IssuerRef: cmmeta.ObjectReference{Name: "ca"},
+			Usages:    []cmapi.KeyUsage{cmapi.UsageServerAuth},
+			Duration:  &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	policyRequest, err := PolicyRequestFromCertificateRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, PolicyRequest{
+		Namespace:     "foo",
+		Name:          "bar",
+		IssuerRef:     cmmeta.ObjectReference{Name: "ca"},
+		CommonName:    "example.com",
+		DNSNames:      []string{"example.com", "www.example.com"},
+		IPAddresses:   []string{"10.0.0.1"},
+		Organizations: []string{"Example"},
+		Usages:        []cmapi.KeyUsage{cmapi.UsageServerAuth},
+		Duration:      &metav1.Duration{Duration: time.Hour},
+		KeyAlgorithm:  cmapi.ECDSAKeyAlgorithm,
+		KeySize:       384,
+	}, policyRequest)
+
+	request.Spec.Request = []byte("not a csr")
+	_, err = PolicyRequestFromCertificateRequest(request)
+	assert.EqualError(t, err, "failed to decode PEM encoded certificate signing request")
+}
+
+func TestSimulatePolicies(t *testing.T) {
+	value := func(s string) *string { return &s }
+	required := true
+	minSize := 4096
+
+	policies := []policyapi.CertificateRequestPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{
+					CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: value("*.example.com")},
+					DNSNames:   &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*.example.com"}},
+				},
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					MaxDuration: &metav1.Duration{Duration: 24 * time.Hour},
+					PrivateKey:  &policyapi.CertificateRequestPolicyConstraintsPrivateKey{MinSize: &minSize},
+				},
+				Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "all"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{
+					CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: value("*")},
+					DNSNames:   &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+					Subject: &policyapi.CertificateRequestPolicyAllowedX509Subject{
+						Organizations: &policyapi.CertificateRequestPolicyAllowedStringSlice{Required: &required},
+					},
+				},
+				Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"rego": {}},
+				Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "venafi"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: value("venafi-*")}},
+			},
+		},
+	}
+
+	request := PolicyRequestFromCertificate(cmapi.Certificate{
+		Spec: cmapi.CertificateSpec{
+			CommonName: "foo.example.com",
+			DNSNames:   []string{"foo.example.com", "foo.example.org"},
+			IssuerRef:  cmmeta.ObjectReference{Name: "ca", Kind: "ClusterIssuer"},
+		},
+	})
+
+	assert.Equal(t, []PolicySimulation{
+		{
+			Policy:             "all",
+			Selected:           true,
+			Violations:         []string{"spec.allowed.subject.organizations.required: Required value: true"},
+			UnevaluatedPlugins: []string{"rego"},
+		},
+		{
+			Policy:   "example-com",
+			Selected: true,
+			Violations: []string{
+				`spec.allowed.dnsNames.values: Invalid value: []string{"foo.example.com", "foo.example.org"}: *.example.com`,
+				`spec.constraints.maxDuration: Invalid value: "<nil>": 24h0m0s`,
+				`spec.constraints.privateKey.minSize: Invalid value: "2048": 4096`,
+			},
+		},
+		{
+			Policy: "venafi",
+		},
+	}, SimulatePolicies(request, policies))
+
+	request.DNSNames = []string{"foo.example.com"}
+	request.Duration = &metav1.Duration{Duration: time.Hour}
+	request.KeySize = 4096
+	simulations := SimulatePolicies(request, policies)
+	assert.True(t, simulations[1].Approved)
+	assert.Empty(t, simulations[1].Violations)
+}
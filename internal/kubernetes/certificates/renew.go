@@ -0,0 +1,105 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+const (
+	// renewalReason and renewalMessage are those cmctl sets on the Issuing condition when renewing a Certificate, so
+	// that renewals look the same to cert-manager whichever tool triggered them
+	renewalReason  = "ManuallyTriggered"
+	renewalMessage = "Certificate re-issuance manually triggered"
+)
+
+// ErrAlreadyIssuing is returned when renewing a Certificate that cert-manager is already issuing.
+var ErrAlreadyIssuing = errors.New("certificate is already being issued")
+
+// Renew triggers the renewal of a Certificate by setting its Issuing condition, as cmctl does. ErrAlreadyIssuing
+// is returned if the Certificate is already being issued.
+func Renew(ctx context.Context, client clients.Generic[*cmapi.Certificate, *cmapi.CertificateList], namespace, name string, now time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var certificate cmapi.Certificate
+		options := &clients.GenericRequestOptions{Namespace: namespace, Name: name}
+		if err := client.Get(ctx, options, &certificate); err != nil {
+			return err
+		}
+
+		if IsCurrentlyBeingIssued(certificate) {
+			return ErrAlreadyIssuing
+		}
+
+		setCondition(&certificate, cmapi.CertificateCondition{
+			Type:               cmapi.CertificateConditionIssuing,
+			Status:             cmmeta.ConditionTrue,
+			Reason:             renewalReason,
+			Message:            renewalMessage,
+			LastTransitionTime: &metav1.Time{Time: now},
+			ObservedGeneration: certificate.Generation,
+		})
+
+		return client.UpdateStatus(ctx, options, &certificate)
+	})
+}
+
+// WaitForReady polls the Certificate until it has been issued and is ready, returning an error if issuance fails or
+// the context is done first.
+func WaitForReady(ctx context.Context, client clients.Generic[*cmapi.Certificate, *cmapi.CertificateList], namespace, name string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var certificate cmapi.Certificate
+		options := &clients.GenericRequestOptions{Namespace: namespace, Name: name}
+		if err := client.Get(ctx, options, &certificate); err != nil {
+			return err
+		}
+
+		var issuing, ready *cmapi.CertificateCondition
+		for i, condition := range certificate.Status.Conditions {
+			switch condition.Type {
+			case cmapi.CertificateConditionIssuing:
+				issuing = &certificate.Status.Conditions[i]
+			case cmapi.CertificateConditionReady:
+				ready = &certificate.Status.Conditions[i]
+			}
+		}
+
+		switch {
+		case issuing != nil && issuing.Status == cmmeta.ConditionFalse:
+			return fmt.Errorf("issuance failed: %s", issuing.Message)
+		case issuing == nil && ready != nil && ready.Status == cmmeta.ConditionTrue:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ready != nil && ready.Message != "" {
+				return fmt.Errorf("%w: %s", ctx.Err(), ready.Message)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// setCondition replaces the condition of the same type, or adds the condition if there is none.
+func setCondition(certificate *cmapi.Certificate, condition cmapi.CertificateCondition) {
+	for i, existing := range certificate.Status.Conditions {
+		if existing.Type == condition.Type {
+			certificate.Status.Conditions[i] = condition
+			return
+		}
+	}
+
+	certificate.Status.Conditions = append(certificate.Status.Conditions, condition)
+}
@@ -0,0 +1,117 @@
+package certificates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestRenew(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	readyCondition := cmapi.CertificateCondition{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue}
+	issuingCondition := cmapi.CertificateCondition{Type: cmapi.CertificateConditionIssuing, Status: cmmeta.ConditionTrue}
+
+	t.Run("sets the Issuing condition, retrying on conflicts", func(t *testing.T) {
+		var updates []*cmapi.Certificate
+		client := &clients.FakeGeneric[*cmapi.Certificate, *cmapi.CertificateList]{
+			FakeGet: func(_ context.Context, options *clients.GenericRequestOptions, certificate *cmapi.Certificate) error {
+				assert.Equal(t, "foo", options.Namespace)
+				assert.Equal(t, "bar", options.Name)
+				certificate.Generation = 2
+				certificate.Status.Conditions = []cmapi.CertificateCondition{readyCondition}
+				return nil
+			},
+			FakeUpdateStatus: func(_ context.Context, _ *clients.GenericRequestOptions, certificate *cmapi.Certificate) error {
+				updates = append(updates, certificate)
+				if len(updates) == 1 {
+					return apierrors.NewConflict(schema.GroupResource{Resource: "certificates"}, "bar", nil)
+				}
+				return nil
+			},
+		}
+
+		require.NoError(t, Renew(context.Background(), client, "foo", "bar", now))
+		require.Len(t, updates, 2)
+		assert.Equal(t, []cmapi.CertificateCondition{
+			readyCondition,
+			{
+				Type:               cmapi.CertificateConditionIssuing,
+				Status:             cmmeta.ConditionTrue,
+				Reason:             "ManuallyTriggered",
+				Message:            "Certificate re-issuance manually triggered",
+				LastTransitionTime: &metav1.Time{Time: now},
+				ObservedGeneration: 2,
+			},
+		}, updates[1].Status.Conditions)
+	})
+
+	t.Run("skips certificates that are already being issued", func(t *testing.T) {
+		client := &clients.FakeGeneric[*cmapi.Certificate, *cmapi.CertificateList]{
+			FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, certificate *cmapi.Certificate) error {
+				certificate.Status.Conditions = []cmapi.CertificateCondition{readyCondition, issuingCondition}
+				return nil
+			},
+		}
+
+		assert.ErrorIs(t, Renew(context.Background(), client, "foo", "bar", now), ErrAlreadyIssuing)
+	})
+}
+
+func TestWaitForReady(t *testing.T) {
+	tests := map[string]struct {
+		states      [][]cmapi.CertificateCondition
+		expectedErr string
+	}{
+		"returns once issuance completes": {
+			states: [][]cmapi.CertificateCondition{
+				{
+					{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue},
+					{Type: cmapi.CertificateConditionIssuing, Status: cmmeta.ConditionTrue},
+				},
+				{
+					{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue},
+				},
+			},
+		},
+		"returns an error if issuance fails": {
+			states: [][]cmapi.CertificateCondition{
+				{
+					{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue},
+					{Type: cmapi.CertificateConditionIssuing, Status: cmmeta.ConditionFalse, Message: "The certificate request has failed to complete"},
+				},
+			},
+			expectedErr: "issuance failed: The certificate request has failed to complete",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			client := &clients.FakeGeneric[*cmapi.Certificate, *cmapi.CertificateList]{
+				FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, certificate *cmapi.Certificate) error {
+					certificate.Status.Conditions = test.states[calls]
+					calls++
+					return nil
+				},
+			}
+
+			err := WaitForReady(context.Background(), client, "foo", "bar", time.Millisecond)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, len(test.states), calls)
+		})
+	}
+}
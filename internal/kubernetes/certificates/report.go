@@ -0,0 +1,349 @@
+// Package certificates provides functions for inspecting the cert-manager Certificates in a cluster, along with the
+// X.509 certificates they have been issued.
+package certificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// Problems reported for Certificates.
+const (
+	ProblemUnready      = "unready"
+	ProblemRenewingSoon = "renewing-soon"
+	ProblemExpiringSoon = "expiring-soon"
+	ProblemIssuing      = "issuing"
+	ProblemFailing      = "failing"
+	ProblemInvalidChain = "invalid-chain"
+	ProblemMissingCert  = "missing-certificate"
+)
+
+type (
+	// ReportOptions configure which Certificates are reported as renewing or expiring soon.
+	ReportOptions struct {
+		// RenewalWindow is how soon before its renewal time a Certificate is reported as renewing soon
+		RenewalWindow time.Duration
+		// ExpiryWindow is how soon before it expires a Certificate is reported as expiring soon
+		ExpiryWindow time.Duration
+	}
+
+	// CertificateReport describes a cert-manager Certificate and the X.509 certificate stored in its Secret.
+	CertificateReport struct {
+		Namespace   string     `json:"namespace"`
+		Name        string     `json:"name"`
+		IssuerKind  string     `json:"issuerKind"`
+		IssuerName  string     `json:"issuerName"`
+		SecretName  string     `json:"secretName"`
+		Ready       string     `json:"ready"`
+		NotBefore   *time.Time `json:"notBefore,omitempty"`
+		NotAfter    *time.Time `json:"notAfter,omitempty"`
+		RenewalTime *time.Time `json:"renewalTime,omitempty"`
+		Problems    []string   `json:"problems,omitempty"`
+
+		// The following are read from the X.509 certificate in the Secret, if there is one
+		Subject      string   `json:"subject,omitempty"`
+		DNSNames     []string `json:"dnsNames,omitempty"`
+		IPAddresses  []string `json:"ipAddresses,omitempty"`
+		URIs         []string `json:"uris,omitempty"`
+		EmailAddress []string `json:"emailAddresses,omitempty"`
+		KeyAlgorithm string   `json:"keyAlgorithm,omitempty"`
+		KeySize      int      `json:"keySize,omitempty"`
+		ChainValid   bool     `json:"chainValid"`
+		ChainError   string   `json:"chainError,omitempty"`
+	}
+)
+
+// FetchReport lists all Certificates in the cluster and reads the X.509 certificate from each of their Secrets.
+// Reports are sorted by namespace and name.
+func FetchReport(ctx context.Context, cfg *rest.Config, opts ReportOptions, now time.Time) ([]CertificateReport, error) {
+	certificateClient, err := clients.NewCertificateClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate client: %w", err)
+	}
+
+	secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&clients.GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets client: %w", err)
+	}
+
+	var certificates cmapi.CertificateList
+	if err := certificateClient.List(ctx, &clients.GenericRequestOptions{}, &certificates); err != nil {
+		return nil, fmt.Errorf("failed to list certificates: %w", err)
+	}
+
+	reports, err := buildReports(ctx, secretsClient, certificates.Items, opts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		if reports[i].Namespace != reports[j].Namespace {
+			return reports[i].Namespace < reports[j].Namespace
+		}
+		return reports[i].Name < reports[j].Name
+	})
+
+	return reports, nil
+}
+
+// secretListPageSize is the number of Secrets fetched per request when listing the Secrets of a namespace.
+const secretListPageSize = 500
+
+// buildReports reports on each Certificate, listing the TLS Secrets of each namespace once rather than fetching the
+// Secret of every Certificate. Secrets not of the kubernetes.io/tls type, which cert-manager reuses if they already
+// exist, are fetched individually.
+func buildReports(ctx context.Context, secretsClient clients.Generic[*corev1.Secret, *corev1.SecretList], certificates []cmapi.Certificate, opts ReportOptions, now time.Time) ([]CertificateReport, error) {
+	secretsByNamespace := make(map[string]map[string]*corev1.Secret)
+	reports := make([]CertificateReport, 0, len(certificates))
+	for _, certificate := range certificates {
+		secrets, ok := secretsByNamespace[certificate.Namespace]
+		if !ok {
+			var list corev1.SecretList
+			err := secretsClient.List(ctx, &clients.GenericRequestOptions{
+				Namespace:     certificate.Namespace,
+				FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+				Limit:         secretListPageSize,
+			}, &list)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list secrets in namespace %s: %w", certificate.Namespace, err)
+			}
+
+			secrets = make(map[string]*corev1.Secret, len(list.Items))
+			for i := range list.Items {
+				secrets[list.Items[i].Name] = &list.Items[i]
+			}
+			secretsByNamespace[certificate.Namespace] = secrets
+		}
+
+		if secret, ok := secrets[certificate.Spec.SecretName]; ok {
+			reports = append(reports, BuildReport(certificate, secret, opts, now))
+			continue
+		}
+
+		var secret corev1.Secret
+		err := secretsClient.Get(ctx, &clients.GenericRequestOptions{
+			Namespace: certificate.Namespace,
+			Name:      certificate.Spec.SecretName,
+		}, &secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			reports = append(reports, BuildReport(certificate, nil, opts, now))
+		case err != nil:
+			return nil, fmt.Errorf("failed to get secret %s/%s: %w", certificate.Namespace, certificate.Spec.SecretName, err)
+		default:
+			reports = append(reports, BuildReport(certificate, &secret, opts, now))
+		}
+	}
+
+	return reports, nil
+}
+
+// BuildReport describes the Certificate and the X.509 certificate stored in its Secret, which may be nil if the
+// Secret does not exist.
+func BuildReport(certificate cmapi.Certificate, secret *corev1.Secret, opts ReportOptions, now time.Time) CertificateReport {
+	report := CertificateReport{
+		Namespace:  certificate.Namespace,
+		Name:       certificate.Name,
+		IssuerKind: certificate.Spec.IssuerRef.Kind,
+		IssuerName: certificate.Spec.IssuerRef.Name,
+		SecretName: certificate.Spec.SecretName,
+		Ready:      string(cmmeta.ConditionUnknown),
+	}
+	if report.IssuerKind == "" {
+		report.IssuerKind = cmapi.IssuerKind
+	}
+
+	for _, condition := range certificate.Status.Conditions {
+		if condition.Type == cmapi.CertificateConditionReady {
+			report.Ready = string(condition.Status)
+		}
+	}
+	if certificate.Status.NotBefore != nil {
+		report.NotBefore = &certificate.Status.NotBefore.Time
+	}
+	if certificate.Status.NotAfter != nil {
+		report.NotAfter = &certificate.Status.NotAfter.Time
+	}
+	if certificate.Status.RenewalTime != nil {
+		report.RenewalTime = &certificate.Status.RenewalTime.Time
+	}
+
+	if IsUnready(certificate) {
+		report.Problems = append(report.Problems, ProblemUnready)
+	}
+	if WillBeRenewedSoon(certificate, opts.RenewalWindow, now) {
+		report.Problems = append(report.Problems, ProblemRenewingSoon)
+	}
+	if WillExpireSoon(certificate, opts.ExpiryWindow, now) {
+		report.Problems = append(report.Problems, ProblemExpiringSoon)
+	}
+	if IsCurrentlyBeingIssued(certificate) {
+		report.Problems = append(report.Problems, ProblemIssuing)
+	}
+	if IsCurrentlyFailingIssuance(certificate) {
+		report.Problems = append(report.Problems, ProblemFailing)
+	}
+
+	var data []byte
+	if secret != nil {
+		data = secret.Data[corev1.TLSCertKey]
+	}
+	chain, err := parseChain(data)
+	if err != nil {
+		report.ChainError = err.Error()
+		report.Problems = append(report.Problems, ProblemMissingCert)
+		return report
+	}
+
+	leaf := chain[0]
+	report.Subject = leaf.Subject.String()
+	report.DNSNames = leaf.DNSNames
+	report.EmailAddress = leaf.EmailAddresses
+	for _, ip := range leaf.IPAddresses {
+		report.IPAddresses = append(report.IPAddresses, ip.String())
+	}
+	for _, uri := range leaf.URIs {
+		report.URIs = append(report.URIs, uri.String())
+	}
+	report.KeyAlgorithm, report.KeySize = publicKeyInfo(leaf)
+
+	if err := verifyChain(chain, secret.Data[cmmeta.TLSCAKey], now); err != nil {
+		report.ChainError = err.Error()
+		report.Problems = append(report.Problems, ProblemInvalidChain)
+	} else {
+		report.ChainValid = true
+	}
+
+	return report
+}
+
+// IsUnready returns true if the Certificate does not have a Ready condition, or if that condition is false.
+func IsUnready(cert cmapi.Certificate) bool {
+	hasReady := false
+	for _, cond := range cert.Status.Conditions {
+		if cond.Type == cmapi.CertificateConditionReady {
+			hasReady = true
+			if cond.Status == cmmeta.ConditionFalse {
+				return true
+			}
+		}
+	}
+	return !hasReady
+}
+
+// WillBeRenewedSoon returns true if the Certificate is due to be renewed within the buffer.
+func WillBeRenewedSoon(cert cmapi.Certificate, buffer time.Duration, nowTime time.Time) bool {
+	return cert.Status.RenewalTime != nil && nowTime.Add(buffer).After(cert.Status.RenewalTime.Time)
+}
+
+// WillExpireSoon returns true if the Certificate expires within the buffer.
+func WillExpireSoon(cert cmapi.Certificate, buffer time.Duration, nowTime time.Time) bool {
+	return cert.Status.NotAfter != nil && nowTime.Add(buffer).After(cert.Status.NotAfter.Time)
+}
+
+// IsCurrentlyBeingIssued returns true if cert-manager is issuing the Certificate.
+func IsCurrentlyBeingIssued(cert cmapi.Certificate) bool {
+	for _, cond := range cert.Status.Conditions {
+		if cond.Type == cmapi.CertificateConditionIssuing && cond.Status == cmmeta.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCurrentlyFailingIssuance returns true if the last attempts to issue the Certificate failed.
+func IsCurrentlyFailingIssuance(cert cmapi.Certificate) bool {
+	failedAttempts := cert.Status.FailedIssuanceAttempts
+	return failedAttempts != nil && *failedAttempts > 0
+}
+
+// parseChain parses the PEM encoded certificates of a Secret's tls.crt, the first of which is the leaf certificate.
+func parseChain(data []byte) ([]*x509.Certificate, error) {
+	if len(data) == 0 {
+		return nil, errors.New("no certificate found in secret")
+	}
+
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("no PEM encoded certificate found in secret")
+	}
+
+	return chain, nil
+}
+
+// verifyChain checks that the leaf certificate chains up to the CA in the Secret's ca.crt through the certificates
+// that follow it. The system roots are used if the Secret has no ca.crt, as is usual for public CAs.
+func verifyChain(chain []*x509.Certificate, caData []byte, now time.Time) error {
+	opts := x509.VerifyOptions{
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if len(caData) > 0 {
+		opts.Roots = x509.NewCertPool()
+		if !opts.Roots.AppendCertsFromPEM(caData) {
+			return errors.New("failed to parse ca.crt")
+		}
+	}
+
+	_, err := chain[0].Verify(opts)
+	return err
+}
+
+func publicKeyInfo(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", len(key) * 8
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
+	}
+}
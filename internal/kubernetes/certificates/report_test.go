@@ -0,0 +1,215 @@
+package certificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "foo.example.com"},
+		DNSNames:     []string{"foo.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(48 * time.Hour),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+	leafPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &otherKey.PublicKey, otherKey)
+	require.NoError(t, err)
+	otherPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherDER})
+
+	notAfter := metav1.NewTime(now.Add(48 * time.Hour))
+	renewalTime := metav1.NewTime(now.Add(16 * time.Hour))
+	certificate := cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Spec: cmapi.CertificateSpec{
+			SecretName: "foo-tls",
+			IssuerRef:  cmmeta.ObjectReference{Name: "ca"},
+		},
+		Status: cmapi.CertificateStatus{
+			Conditions: []cmapi.CertificateCondition{
+				{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue},
+			},
+			NotAfter:    &notAfter,
+			RenewalTime: &renewalTime,
+		},
+	}
+
+	opts := ReportOptions{RenewalWindow: 24 * time.Hour, ExpiryWindow: 24 * time.Hour}
+
+	tests := map[string]struct {
+		secret             *corev1.Secret
+		expected           CertificateReport
+		expectedChainError string
+	}{
+		"a certificate that chains to its CA": {
+			secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": leafPEM, "ca.crt": caPEM}},
+			expected: CertificateReport{
+				Namespace:    "bar",
+				Name:         "foo",
+				IssuerKind:   "Issuer",
+				IssuerName:   "ca",
+				SecretName:   "foo-tls",
+				Ready:        "True",
+				NotAfter:     &notAfter.Time,
+				RenewalTime:  &renewalTime.Time,
+				Problems:     []string{ProblemRenewingSoon},
+				Subject:      "CN=foo.example.com",
+				DNSNames:     []string{"foo.example.com"},
+				IPAddresses:  []string{"10.0.0.1"},
+				KeyAlgorithm: "ECDSA",
+				KeySize:      256,
+				ChainValid:   true,
+			},
+		},
+		"a certificate that does not chain to its CA": {
+			secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": leafPEM, "ca.crt": otherPEM}},
+			expected: CertificateReport{
+				Namespace:    "bar",
+				Name:         "foo",
+				IssuerKind:   "Issuer",
+				IssuerName:   "ca",
+				SecretName:   "foo-tls",
+				Ready:        "True",
+				NotAfter:     &notAfter.Time,
+				RenewalTime:  &renewalTime.Time,
+				Problems:     []string{ProblemRenewingSoon, ProblemInvalidChain},
+				Subject:      "CN=foo.example.com",
+				DNSNames:     []string{"foo.example.com"},
+				IPAddresses:  []string{"10.0.0.1"},
+				KeyAlgorithm: "ECDSA",
+				KeySize:      256,
+			},
+			expectedChainError: "certificate signed by unknown authority",
+		},
+		"a certificate without a secret": {
+			expected: CertificateReport{
+				Namespace:   "bar",
+				Name:        "foo",
+				IssuerKind:  "Issuer",
+				IssuerName:  "ca",
+				SecretName:  "foo-tls",
+				Ready:       "True",
+				NotAfter:    &notAfter.Time,
+				RenewalTime: &renewalTime.Time,
+				Problems:    []string{ProblemRenewingSoon, ProblemMissingCert},
+				ChainError:  "no certificate found in secret",
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := BuildReport(certificate, test.secret, opts, now)
+			if test.expectedChainError != "" {
+				// the wording of verification errors depends on the Go version
+				assert.Contains(t, report.ChainError, test.expectedChainError)
+				report.ChainError = ""
+			}
+			assert.Equal(t, test.expected, report)
+		})
+	}
+}
+
+func TestBuildReports(t *testing.T) {
+	certificate := func(namespace, name string) cmapi.Certificate {
+		return cmapi.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       cmapi.CertificateSpec{SecretName: name + "-tls"},
+		}
+	}
+	secret := func(namespace, name string) corev1.Secret {
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"tls.crt": []byte("not a certificate")},
+		}
+	}
+
+	var listed, fetched []string
+	secretsClient := &clients.FakeGeneric[*corev1.Secret, *corev1.SecretList]{
+		FakeList: func(_ context.Context, options *clients.GenericRequestOptions, list *corev1.SecretList) error {
+			assert.Equal(t, "type=kubernetes.io/tls", options.FieldSelector)
+			listed = append(listed, options.Namespace)
+			if options.Namespace == "foo" {
+				list.Items = []corev1.Secret{secret("foo", "a-tls"), secret("foo", "unrelated")}
+			}
+			return nil
+		},
+		FakeGet: func(_ context.Context, options *clients.GenericRequestOptions, result *corev1.Secret) error {
+			fetched = append(fetched, options.Namespace+"/"+options.Name)
+			if options.Name == "opaque-tls" {
+				*result = secret(options.Namespace, options.Name)
+				return nil
+			}
+			return apierrors.NewNotFound(corev1.Resource("secrets"), options.Name)
+		},
+	}
+
+	reports, err := buildReports(context.Background(), secretsClient, []cmapi.Certificate{
+		certificate("foo", "a"),
+		certificate("foo", "missing"),
+		certificate("bar", "opaque"),
+		certificate("foo", "b"),
+	}, ReportOptions{}, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"foo", "bar"}, listed)
+	assert.Equal(t, []string{"foo/missing-tls", "bar/opaque-tls", "foo/b-tls"}, fetched)
+
+	chainErrors := make(map[string]string)
+	for _, report := range reports {
+		chainErrors[report.Name] = report.ChainError
+	}
+	assert.Equal(t, map[string]string{
+		"a":       "no PEM encoded certificate found in secret",
+		"missing": "no certificate found in secret",
+		"opaque":  "no PEM encoded certificate found in secret",
+		"b":       "no certificate found in secret",
+	}, chainErrors)
+}
@@ -0,0 +1,166 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// States of a CertificateRequest's approval.
+const (
+	RequestPending  = "Pending"
+	RequestApproved = "Approved"
+	RequestDenied   = "Denied"
+)
+
+const (
+	// approvedReason and deniedReason are set on the conditions of CertificateRequests approved or denied with jsctl
+	approvedReason = "ManuallyApproved"
+	deniedReason   = "ManuallyDenied"
+
+	// approverPolicyReason is the reason approver-policy sets on the conditions of CertificateRequests it reviews,
+	// and noPolicyApprovedPrefix begins the message it sets when denying them
+	approverPolicyReason   = "policy.cert-manager.io"
+	noPolicyApprovedPrefix = "No policy approved this request: "
+)
+
+// PolicyEvaluation is the result of evaluating a CertificateRequestPolicy against a CertificateRequest.
+type PolicyEvaluation struct {
+	Policy  string `json:"policy"`
+	Message string `json:"message"`
+}
+
+// RequestState returns whether the CertificateRequest is approved, denied or pending either.
+func RequestState(request cmapi.CertificateRequest) string {
+	for _, condition := range request.Status.Conditions {
+		if condition.Status != cmmeta.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case cmapi.CertificateRequestConditionApproved:
+			return RequestApproved
+		case cmapi.CertificateRequestConditionDenied:
+			return RequestDenied
+		}
+	}
+
+	return RequestPending
+}
+
+// ApproveRequest sets the Approved condition of a pending CertificateRequest, with the message giving the reason.
+func ApproveRequest(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], namespace, name, message string, now time.Time) error {
+	return decideRequest(ctx, client, namespace, name, cmapi.CertificateRequestConditionApproved, approvedReason, message, now)
+}
+
+// DenyRequest sets the Denied condition of a pending CertificateRequest, with the message giving the reason.
+func DenyRequest(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], namespace, name, message string, now time.Time) error {
+	return decideRequest(ctx, client, namespace, name, cmapi.CertificateRequestConditionDenied, deniedReason, message, now)
+}
+
+func decideRequest(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], namespace, name string, conditionType cmapi.CertificateRequestConditionType, reason, message string, now time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var request cmapi.CertificateRequest
+		options := &clients.GenericRequestOptions{Namespace: namespace, Name: name}
+		if err := client.Get(ctx, options, &request); err != nil {
+			return err
+		}
+
+		// cert-manager does not allow a decision to be changed once it has been made
+		if state := RequestState(request); state != RequestPending {
+			return fmt.Errorf("certificate request %s/%s has already been %s", namespace, name, strings.ToLower(state))
+		}
+
+		request.Status.Conditions = append(request.Status.Conditions, cmapi.CertificateRequestCondition{
+			Type:               conditionType,
+			Status:             cmmeta.ConditionTrue,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: &metav1.Time{Time: now},
+		})
+
+		return client.UpdateStatus(ctx, options, &request)
+	})
+}
+
+// PolicyEvaluations returns the CertificateRequestPolicies that approver-policy evaluated when denying the
+// CertificateRequest, along with why each of them did not approve it. Nothing is returned if the request was not
+// denied by approver-policy.
+func PolicyEvaluations(request cmapi.CertificateRequest) []PolicyEvaluation {
+	var message string
+	for _, condition := range request.Status.Conditions {
+		if condition.Type == cmapi.CertificateRequestConditionDenied && condition.Reason == approverPolicyReason {
+			message = condition.Message
+		}
+	}
+	if !strings.HasPrefix(message, noPolicyApprovedPrefix) {
+		return nil
+	}
+
+	// the message lists each policy as "[name: message]", separated by spaces
+	message = strings.TrimPrefix(message, noPolicyApprovedPrefix)
+	message = strings.TrimSuffix(strings.TrimPrefix(message, "["), "]")
+
+	var evaluations []PolicyEvaluation
+	for _, part := range strings.Split(message, "] [") {
+		policy, policyMessage, _ := strings.Cut(part, ": ")
+		evaluations = append(evaluations, PolicyEvaluation{Policy: policy, Message: policyMessage})
+	}
+
+	return evaluations
+}
+
+// ApplicablePolicies returns the names of the CertificateRequestPolicies whose issuerRef selector matches the
+// CertificateRequest. approver-policy only evaluates those that are also ready and bound to the requester by RBAC.
+func ApplicablePolicies(request cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) []string {
+	var names []string
+	for _, policy := range policies {
+		if selectsIssuer(policy, request.Spec.IssuerRef) {
+			names = append(names, policy.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// selectsIssuer returns true if the issuerRef selector of the CertificateRequestPolicy matches the issuer reference.
+// An empty group or kind refers to a cert-manager Issuer.
+func selectsIssuer(policy policyapi.CertificateRequestPolicy, ref cmmeta.ObjectReference) bool {
+	selector := policy.Spec.Selector.IssuerRef
+	if selector == nil {
+		return false
+	}
+
+	group, kind := ref.Group, ref.Kind
+	if group == "" {
+		group = cmapi.SchemeGroupVersion.Group
+	}
+	if kind == "" {
+		kind = cmapi.IssuerKind
+	}
+
+	return wildcardMatch(selector.Name, ref.Name) && wildcardMatch(selector.Kind, kind) && wildcardMatch(selector.Group, group)
+}
+
+// wildcardMatch returns true if the value matches the pattern, in which "*" matches any characters. A nil pattern
+// matches everything, as it does for approver-policy.
+func wildcardMatch(pattern *string, value string) bool {
+	if pattern == nil {
+		return true
+	}
+
+	expression := strings.ReplaceAll(regexp.QuoteMeta(*pattern), `\*`, ".*")
+	return regexp.MustCompile("^" + expression + "$").MatchString(value)
+}
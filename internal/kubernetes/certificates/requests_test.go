@@ -0,0 +1,149 @@
+package certificates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestDecideRequest(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		conditions     []cmapi.CertificateRequestCondition
+		decide         func(context.Context, clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], string, string, string, time.Time) error
+		expectedUpdate []cmapi.CertificateRequestCondition
+		expectedErr    string
+	}{
+		"approve a pending request": {
+			decide: ApproveRequest,
+			expectedUpdate: []cmapi.CertificateRequestCondition{
+				{
+					Type:               cmapi.CertificateRequestConditionApproved,
+					Status:             cmmeta.ConditionTrue,
+					Reason:             "ManuallyApproved",
+					Message:            "looks fine",
+					LastTransitionTime: &metav1.Time{Time: now},
+				},
+			},
+		},
+		"deny a pending request": {
+			decide: DenyRequest,
+			expectedUpdate: []cmapi.CertificateRequestCondition{
+				{
+					Type:               cmapi.CertificateRequestConditionDenied,
+					Status:             cmmeta.ConditionTrue,
+					Reason:             "ManuallyDenied",
+					Message:            "looks fine",
+					LastTransitionTime: &metav1.Time{Time: now},
+				},
+			},
+		},
+		"refuse to approve a denied request": {
+			conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionDenied, Status: cmmeta.ConditionTrue},
+			},
+			decide:      ApproveRequest,
+			expectedErr: "certificate request foo/bar has already been denied",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var updated *cmapi.CertificateRequest
+			client := &clients.FakeGeneric[*cmapi.CertificateRequest, *cmapi.CertificateRequestList]{
+				FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, request *cmapi.CertificateRequest) error {
+					request.Status.Conditions = test.conditions
+					return nil
+				},
+				FakeUpdateStatus: func(_ context.Context, options *clients.GenericRequestOptions, request *cmapi.CertificateRequest) error {
+					assert.Equal(t, "foo", options.Namespace)
+					assert.Equal(t, "bar", options.Name)
+					updated = request
+					return nil
+				},
+			}
+
+			err := test.decide(context.Background(), client, "foo", "bar", "looks fine", now)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.Nil(t, updated)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, updated)
+			assert.Equal(t, test.expectedUpdate, updated.Status.Conditions)
+		})
+	}
+}
+
+func TestPolicyEvaluations(t *testing.T) {
+	request := cmapi.CertificateRequest{
+		Status: cmapi.CertificateRequestStatus{
+			Conditions: []cmapi.CertificateRequestCondition{
+				{
+					Type:    cmapi.CertificateRequestConditionDenied,
+					Status:  cmmeta.ConditionTrue,
+					Reason:  "policy.cert-manager.io",
+					Message: `No policy approved this request: [a: spec.allowed.dnsNames.values: Invalid value: []string{"foo.example.com"}: example.com] [b: spec.allowed.commonName.value: Required value: example.com]`,
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []PolicyEvaluation{
+		{Policy: "a", Message: `spec.allowed.dnsNames.values: Invalid value: []string{"foo.example.com"}: example.com`},
+		{Policy: "b", Message: "spec.allowed.commonName.value: Required value: example.com"},
+	}, PolicyEvaluations(request))
+
+	request.Status.Conditions[0].Reason = "ManuallyDenied"
+	assert.Empty(t, PolicyEvaluations(request))
+}
+
+func TestApplicablePolicies(t *testing.T) {
+	value := func(s string) *string { return &s }
+
+	policies := []policyapi.CertificateRequestPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "all"},
+			Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "venafi"},
+			Spec: policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{
+				Name: value("venafi-*"),
+				Kind: value("ClusterIssuer"),
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "issuers"},
+			Spec: policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{
+				Kind:  value("Issuer"),
+				Group: value("cert-manager.io"),
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-selector"},
+		},
+	}
+
+	request := cmapi.CertificateRequest{
+		Spec: cmapi.CertificateRequestSpec{
+			IssuerRef: cmmeta.ObjectReference{Name: "venafi-tpp", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		},
+	}
+	assert.Equal(t, []string{"all", "venafi"}, ApplicablePolicies(request, policies))
+
+	// an empty issuerRef refers to an Issuer
+	request.Spec.IssuerRef = cmmeta.ObjectReference{Name: "ca"}
+	assert.Equal(t, []string{"all", "issuers"}, ApplicablePolicies(request, policies))
+}
@@ -5,6 +5,7 @@ import (
 
 	v1alpha1approverpolicy "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	v1extensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/client-go/rest"
 )
@@ -81,3 +82,21 @@ func NewCertificateRequestPolicyClient(config *rest.Config) (Generic[*v1alpha1ap
 
 	return genericClient, nil
 }
+
+// NewDeploymentClient returns an instance of a generic client for querying Deployments
+func NewDeploymentClient(config *rest.Config) (Generic[*appsv1.Deployment, *appsv1.DeploymentList], error) {
+	genericClient, err := NewGenericClient[*appsv1.Deployment, *appsv1.DeploymentList](
+		&GenericClientOptions{
+			RestConfig: config,
+			APIPath:    "/apis",
+			Group:      appsv1.SchemeGroupVersion.Group,
+			Version:    appsv1.SchemeGroupVersion.Version,
+			Kind:       "deployments",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating generic client: %w", err)
+	}
+
+	return genericClient, nil
+}
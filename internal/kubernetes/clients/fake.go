@@ -7,10 +7,15 @@ import (
 )
 
 type FakeGeneric[T, ListT runtime.Object] struct {
-	FakeGet     func(context.Context, *GenericRequestOptions, T) error
-	FakeList    func(context.Context, *GenericRequestOptions, ListT) error
-	FakePresent func(context.Context, *GenericRequestOptions) (bool, error)
-	FakePatch   func(context.Context, *GenericRequestOptions, []byte) error
+	FakeGet       func(context.Context, *GenericRequestOptions, T) error
+	FakeList      func(context.Context, *GenericRequestOptions, ListT) error
+	FakeListPages func(context.Context, *GenericRequestOptions, ListT, func() error) error
+	FakePresent   func(context.Context, *GenericRequestOptions) (bool, error)
+	FakePatch     func(context.Context, *GenericRequestOptions, []byte) error
+	FakeDelete    func(context.Context, *GenericRequestOptions) error
+
+	FakeUpdateStatus func(context.Context, *GenericRequestOptions, T) error
+	FakeCreate       func(context.Context, *GenericRequestOptions, T) error
 }
 
 var _ Generic[*runtime.Unknown, *runtime.Unknown] = &FakeGeneric[*runtime.Unknown, *runtime.Unknown]{}
@@ -23,9 +28,25 @@ func (f *FakeGeneric[T, ListT]) List(ctx context.Context, options *GenericReques
 	return f.FakeList(ctx, options, result)
 }
 
+func (f *FakeGeneric[T, ListT]) ListPages(ctx context.Context, options *GenericRequestOptions, page ListT, fn func() error) error {
+	return f.FakeListPages(ctx, options, page, fn)
+}
+
 func (f *FakeGeneric[T, ListT]) Present(ctx context.Context, options *GenericRequestOptions) (bool, error) {
 	return f.FakePresent(ctx, options)
 }
 func (f *FakeGeneric[T, ListT]) Patch(ctx context.Context, options *GenericRequestOptions, patch []byte) error {
 	return f.FakePatch(ctx, options, patch)
 }
+
+func (f *FakeGeneric[T, ListT]) Delete(ctx context.Context, options *GenericRequestOptions) error {
+	return f.FakeDelete(ctx, options)
+}
+
+func (f *FakeGeneric[T, ListT]) UpdateStatus(ctx context.Context, options *GenericRequestOptions, object T) error {
+	return f.FakeUpdateStatus(ctx, options, object)
+}
+
+func (f *FakeGeneric[T, ListT]) Create(ctx context.Context, options *GenericRequestOptions, object T) error {
+	return f.FakeCreate(ctx, options, object)
+}
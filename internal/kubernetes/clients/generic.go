@@ -3,9 +3,12 @@ package clients
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strconv"
 
 	"github.com/Jeffail/gabs/v2"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -17,8 +20,12 @@ import (
 type Generic[T, ListT runtime.Object] interface {
 	Get(context.Context, *GenericRequestOptions, T) error
 	List(context.Context, *GenericRequestOptions, ListT) error
+	ListPages(ctx context.Context, options *GenericRequestOptions, page ListT, fn func() error) error
 	Present(ctx context.Context, options *GenericRequestOptions) (bool, error)
 	Patch(ctx context.Context, options *GenericRequestOptions, patch []byte) error
+	Delete(ctx context.Context, options *GenericRequestOptions) error
+	UpdateStatus(ctx context.Context, options *GenericRequestOptions, object T) error
+	Create(ctx context.Context, options *GenericRequestOptions, object T) error
 }
 
 type generic[T, ListT runtime.Object] struct {
@@ -45,6 +52,15 @@ type GenericRequestOptions struct {
 	// Namespace is the name of the namespace to fetch resources from. Set only
 	// when fetching resources in a single namespace and namespaced resources.
 	Namespace string
+	// FieldSelector and LabelSelector restrict the resources returned when
+	// listing resources.
+	FieldSelector string
+	LabelSelector string
+
+	// Limit, if set, makes List fetch resources in pages of at most this many,
+	// which keeps the responses for large lists small enough to be served
+	// before they time out.
+	Limit int64
 
 	// DropFields is a list of fields to drop from the response
 	DropFields []string
@@ -131,17 +147,94 @@ func (c *generic[T, ListT]) Get(ctx context.Context, options *GenericRequestOpti
 }
 
 // List is must the same as get, however it returns results in a list type
-// instead
+// instead. If options.Limit is set, the resources are fetched in pages of at
+// most that many and combined in the result.
 func (c *generic[T, ListT]) List(ctx context.Context, options *GenericRequestOptions, result ListT) error {
+	if options.Limit == 0 {
+		_, err := c.listPage(ctx, options, "", result)
+		return err
+	}
+
+	// pages are unmarshalled into a copy of the result, as it is reset for
+	// each one
+	page := result.DeepCopyObject().(ListT)
+	var items []runtime.Object
+	err := c.ListPages(ctx, options, page, func() error {
+		pageItems, err := meta.ExtractList(page)
+		if err != nil {
+			return fmt.Errorf("failed to read items of %T: %w", page, err)
+		}
+		items = append(items, pageItems...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the result has the type and list metadata of the last page, which has
+	// no continue token
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(page).Elem())
+	err = meta.SetList(result, items)
+	if err != nil {
+		return fmt.Errorf("failed to set items of %T: %w", result, err)
+	}
+
+	return nil
+}
+
+// ListPages lists resources in pages of at most options.Limit items, following
+// the continue token of each page. Each page is unmarshalled into page, and fn
+// is called before the next is fetched, so that only one page is held in
+// memory. If options.Limit is not set, all resources are listed in one page.
+func (c *generic[T, ListT]) ListPages(ctx context.Context, options *GenericRequestOptions, page ListT, fn func() error) error {
+	continueToken := ""
+	for {
+		// a page without a continue token must not keep that of the previous
+		// page, and its items must not reuse their memory
+		resetObject(page)
+
+		next, err := c.listPage(ctx, options, continueToken, page)
+		if err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+
+		if next == "" {
+			return nil
+		}
+		continueToken = next
+	}
+}
+
+// listPage lists the page of resources starting at the continue token and
+// returns the token for the next page, which is empty for the last one.
+func (c *generic[T, ListT]) listPage(ctx context.Context, options *GenericRequestOptions, continueToken string, result ListT) (string, error) {
 	r := c.restClient.Get().Resource(c.resource)
 
 	if options.Namespace != "" {
 		r = r.Namespace(options.Namespace)
 	}
+	if options.FieldSelector != "" {
+		r = r.Param("fieldSelector", options.FieldSelector)
+	}
+	if options.LabelSelector != "" {
+		r = r.Param("labelSelector", options.LabelSelector)
+	}
+	if options.Limit > 0 {
+		r = r.Param("limit", strconv.FormatInt(options.Limit, 10))
+	}
+	if continueToken != "" {
+		r = r.Param("continue", continueToken)
+	}
 
 	jsonBody, err := r.DoRaw(ctx)
 	if err != nil {
-		return fmt.Errorf("error listing %T: %w", result, err)
+		if apiErrors.IsResourceExpired(err) {
+			return "", fmt.Errorf("error listing %T, the list changed too much while it was paged through: %w", result, err)
+		}
+		return "", fmt.Errorf("error listing %T: %w", result, err)
 	}
 
 	if len(options.DropFields) > 0 {
@@ -149,7 +242,7 @@ func (c *generic[T, ListT]) List(ctx context.Context, options *GenericRequestOpt
 		// parse the JSON for processing in gabs
 		container, err := gabs.ParseJSON(jsonBody)
 		if err != nil {
-			return fmt.Errorf("failed to parse generated json for resource: %s", err)
+			return "", fmt.Errorf("failed to parse generated json for resource: %s", err)
 		}
 
 		var items []interface{}
@@ -158,12 +251,12 @@ func (c *generic[T, ListT]) List(ctx context.Context, options *GenericRequestOpt
 				// also support JSONPointers for keys containing '.' chars
 				pathComponents, err := gabs.JSONPointerToSlice(v)
 				if err != nil {
-					return fmt.Errorf("invalid JSONPointer: %s", v)
+					return "", fmt.Errorf("invalid JSONPointer: %s", v)
 				}
 				if i.Exists(pathComponents...) {
 					err := i.Delete(pathComponents...)
 					if err != nil {
-						return fmt.Errorf("failed to delete field: %s", err)
+						return "", fmt.Errorf("failed to delete field: %s", err)
 					}
 				}
 			}
@@ -172,7 +265,7 @@ func (c *generic[T, ListT]) List(ctx context.Context, options *GenericRequestOpt
 
 		_, err = container.Set(items, "items")
 		if err != nil {
-			return fmt.Errorf("failed to update filtered items: %s", err)
+			return "", fmt.Errorf("failed to update filtered items: %s", err)
 		}
 
 		jsonBody = container.Bytes()
@@ -180,10 +273,25 @@ func (c *generic[T, ListT]) List(ctx context.Context, options *GenericRequestOpt
 
 	err = json.Unmarshal(jsonBody, result)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal resource: %s", err)
+		return "", fmt.Errorf("failed to unmarshal resource: %s", err)
 	}
 
-	return nil
+	if options.Limit == 0 {
+		return "", nil
+	}
+
+	list, err := meta.ListAccessor(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to read continue token of %T: %w", result, err)
+	}
+
+	return list.GetContinue(), nil
+}
+
+// resetObject sets the object, which must be a pointer, to its zero value.
+func resetObject(object runtime.Object) {
+	value := reflect.ValueOf(object).Elem()
+	value.Set(reflect.Zero(value.Type()))
 }
 
 func (c *generic[T, ListT]) Present(ctx context.Context, options *GenericRequestOptions) (bool, error) {
@@ -224,3 +332,75 @@ func (c *generic[T, ListT]) Patch(ctx context.Context, options *GenericRequestOp
 
 	return nil
 }
+
+func (c *generic[T, ListT]) Delete(ctx context.Context, options *GenericRequestOptions) error {
+	r := c.restClient.Delete().Resource(c.resource)
+
+	if options.Namespace != "" {
+		r = r.Namespace(options.Namespace)
+	}
+	if options.Name != "" {
+		r = r.Name(options.Name)
+	}
+
+	err := r.Do(ctx).Error()
+	if err != nil {
+		return fmt.Errorf("error deleting resource: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatus replaces the status of the resource with that of the object,
+// failing with a conflict if the resource has changed since the object was
+// read.
+func (c *generic[T, ListT]) UpdateStatus(ctx context.Context, options *GenericRequestOptions, object T) error {
+	body, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource: %w", err)
+	}
+
+	r := c.restClient.Put().Resource(c.resource).SubResource("status").Body(body)
+
+	if options.Namespace != "" {
+		r = r.Namespace(options.Namespace)
+	}
+	if options.Name != "" {
+		r = r.Name(options.Name)
+	}
+
+	err = r.Do(ctx).Error()
+	if err != nil {
+		return fmt.Errorf("error updating resource status: %w", err)
+	}
+
+	return nil
+}
+
+// Create creates the object in the namespace given in the options, updating
+// the object with the resource returned by the API server so that fields it
+// sets, such as a generated name, can be read.
+func (c *generic[T, ListT]) Create(ctx context.Context, options *GenericRequestOptions, object T) error {
+	body, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource: %w", err)
+	}
+
+	r := c.restClient.Post().Resource(c.resource).Body(body)
+
+	if options.Namespace != "" {
+		r = r.Namespace(options.Namespace)
+	}
+
+	jsonBody, err := r.DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating resource: %w", err)
+	}
+
+	err = json.Unmarshal(jsonBody, object)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal resource: %w", err)
+	}
+
+	return nil
+}
@@ -233,6 +233,73 @@ func TestGeneric_List(t *testing.T) {
 	assert.Equal(t, "jetstack-secure", result.Items[1].Namespace)
 }
 
+func TestGeneric_List_Paginated(t *testing.T) {
+	ctx := context.Background()
+
+	// the pages of pods served for each continue token
+	pages := map[string]string{
+		"":       `{"kind": "PodList", "apiVersion": "v1", "metadata": {"continue": "page-2"}, "items": [{"metadata": {"name": "pod-1"}, "status": {"phase": "Running"}}, {"metadata": {"name": "pod-2"}}]}`,
+		"page-2": `{"kind": "PodList", "apiVersion": "v1", "metadata": {"continue": "page-3"}, "items": [{"metadata": {"name": "pod-3"}}, {"metadata": {"name": "pod-4"}}]}`,
+		"page-3": `{"kind": "PodList", "apiVersion": "v1", "metadata": {"resourceVersion": "10"}, "items": [{"metadata": {"name": "pod-5"}}]}`,
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		page, ok := pages[r.URL.Query().Get("continue")]
+		require.True(t, ok)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(page))
+	}))
+
+	client, err := NewGenericClient[*corev1.Pod, *corev1.PodList](
+		&GenericClientOptions{
+			RestConfig: &rest.Config{Host: server.URL},
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "pods",
+		},
+	)
+	require.NoError(t, err)
+
+	options := &GenericRequestOptions{Limit: 2, DropFields: []string{"/status"}}
+
+	t.Run("list", func(t *testing.T) {
+		requests = nil
+
+		var result corev1.PodList
+		err = client.List(ctx, options, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"limit=2", "continue=page-2&limit=2", "continue=page-3&limit=2"}, requests)
+		var names []string
+		for _, pod := range result.Items {
+			names = append(names, pod.Name)
+		}
+		assert.Equal(t, []string{"pod-1", "pod-2", "pod-3", "pod-4", "pod-5"}, names)
+		assert.Equal(t, "", string(result.Items[0].Status.Phase))
+		assert.Equal(t, "", result.Continue)
+		assert.Equal(t, "10", result.ResourceVersion)
+	})
+
+	t.Run("list pages", func(t *testing.T) {
+		var page corev1.PodList
+		var pageNames [][]string
+		err = client.ListPages(ctx, options, &page, func() error {
+			var names []string
+			for _, pod := range page.Items {
+				names = append(names, pod.Name)
+			}
+			pageNames = append(pageNames, names)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, [][]string{{"pod-1", "pod-2"}, {"pod-3", "pod-4"}, {"pod-5"}}, pageNames)
+	})
+}
+
 func TestGeneric_List_ClusterScope(t *testing.T) {
 	ctx := context.Background()
 
@@ -377,3 +444,158 @@ func TestGeneric_Update(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, called)
 }
+
+func TestGeneric_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		require.Equal(t, "DELETE", r.Method)
+		require.Equal(t, "/api/v1/namespaces/jetstack-secure/secrets/test", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`))
+	}))
+
+	cfg := &rest.Config{
+		Host: server.URL,
+	}
+
+	client, err := NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	require.NoError(t, err)
+
+	err = client.Delete(ctx, &GenericRequestOptions{Name: "test", Namespace: "jetstack-secure"})
+	require.NoError(t, err)
+	require.True(t, called)
+}
+
+func TestGeneric_List_WithSelectors(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/namespaces/jetstack-secure/events", r.URL.Path)
+		require.Equal(t, "involvedObject.name=test", r.URL.Query().Get("fieldSelector"))
+		require.Equal(t, "app=jsctl", r.URL.Query().Get("labelSelector"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"EventList","apiVersion":"v1","items":[{"metadata":{"name":"test.1"},"reason":"Issued"}]}`))
+	}))
+
+	cfg := &rest.Config{
+		Host: server.URL,
+	}
+
+	client, err := NewGenericClient[*corev1.Event, *corev1.EventList](
+		&GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "events",
+		},
+	)
+	require.NoError(t, err)
+
+	var events corev1.EventList
+	err = client.List(ctx, &GenericRequestOptions{
+		Namespace:     "jetstack-secure",
+		FieldSelector: "involvedObject.name=test",
+		LabelSelector: "app=jsctl",
+	}, &events)
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	assert.Equal(t, "Issued", events.Items[0].Reason)
+}
+
+func TestGeneric_UpdateStatus(t *testing.T) {
+	ctx := context.Background()
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		require.Equal(t, "PUT", r.Method)
+		require.Equal(t, "/api/v1/namespaces/jetstack-secure/secrets/test/status", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `"resourceVersion":"1"`)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+
+	cfg := &rest.Config{
+		Host: server.URL,
+	}
+
+	client, err := NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{}
+	secret.Name = "test"
+	secret.Namespace = "jetstack-secure"
+	secret.ResourceVersion = "1"
+
+	err = client.UpdateStatus(ctx, &GenericRequestOptions{Name: "test", Namespace: "jetstack-secure"}, secret)
+	require.NoError(t, err)
+	require.True(t, called)
+}
+
+func TestGeneric_Create(t *testing.T) {
+	ctx := context.Background()
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/api/v1/namespaces/jetstack-secure/secrets", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `"generateName":"test-"`)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"metadata":{"name":"test-abcde","namespace":"jetstack-secure"}}`))
+	}))
+
+	cfg := &rest.Config{
+		Host: server.URL,
+	}
+
+	client, err := NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{}
+	secret.GenerateName = "test-"
+
+	err = client.Create(ctx, &GenericRequestOptions{Namespace: "jetstack-secure"}, secret)
+	require.NoError(t, err)
+	require.True(t, called)
+	require.Equal(t, "test-abcde", secret.Name)
+}
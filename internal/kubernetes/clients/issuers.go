@@ -79,6 +79,56 @@ func (s AnyIssuer) String() string {
 	return "unknown"
 }
 
+// Kind returns the Kind of the issuer's resources, as used in the issuerRef of a Certificate.
+func (s AnyIssuer) Kind() string {
+	switch s {
+	case CertManagerIssuer:
+		return "Issuer"
+	case CertManagerClusterIssuer:
+		return "ClusterIssuer"
+	case VenafiEnhancedIssuer:
+		return "VenafiIssuer"
+	case VenafiEnhancedClusterIssuer:
+		return "VenafiClusterIssuer"
+	case AWSPCAIssuer:
+		return "AWSPCAIssuer"
+	case AWSPCAClusterIssuer:
+		return "AWSPCAClusterIssuer"
+	case KMSIssuer:
+		return "KMSIssuer"
+	case GoogleCASIssuer:
+		return "GoogleCASIssuer"
+	case GoogleCASClusterIssuer:
+		return "GoogleCASClusterIssuer"
+	case OriginCAIssuer:
+		return "OriginIssuer"
+	case SmallStepIssuer:
+		return "StepIssuer"
+	case SmallStepClusterIssuer:
+		return "StepClusterIssuer"
+	}
+	return "unknown"
+}
+
+// Group returns the API group of the issuer's resources.
+func (s AnyIssuer) Group() string {
+	_, group, _ := strings.Cut(s.String(), ".")
+	return group
+}
+
+// ClusterScoped returns true if the issuer's resources are not namespaced.
+func (s AnyIssuer) ClusterScoped() bool {
+	switch s {
+	case CertManagerClusterIssuer,
+		VenafiEnhancedClusterIssuer,
+		AWSPCAClusterIssuer,
+		GoogleCASClusterIssuer,
+		SmallStepClusterIssuer:
+		return true
+	}
+	return false
+}
+
 type SupportedIssuer struct {
 	CRDName  string
 	Versions []string
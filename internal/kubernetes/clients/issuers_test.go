@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -70,6 +71,15 @@ func TestListSupportedIssuers(t *testing.T) {
 	assert.Equal(t, expectedSupportedIssuers, result)
 }
 
+func TestAnyIssuer_Kind(t *testing.T) {
+	for _, issuer := range AllIssuersList {
+		assert.NotEqual(t, "unknown", issuer.Kind(), "issuer %s has no kind", issuer)
+		// the resource name is the plural of the lower case kind
+		assert.Equal(t, strings.ToLower(issuer.Kind())+"s."+issuer.Group(), issuer.String())
+		assert.Equal(t, strings.Contains(issuer.Kind(), "Cluster"), issuer.ClusterScoped())
+	}
+}
+
 func TestAllIssuers_ListKinds(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
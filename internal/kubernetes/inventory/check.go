@@ -0,0 +1,426 @@
+package inventory
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// cert-manager issuer types, named after the field of the issuer's spec that configures them
+const (
+	TypeACME       = "acme"
+	TypeCA         = "ca"
+	TypeVault      = "vault"
+	TypeVenafi     = "venafi"
+	TypeSelfSigned = "selfSigned"
+)
+
+// IssuerCheck is the result of checking whether an issuer is able to issue certificates.
+type IssuerCheck struct {
+	Issuer
+	// Type is the type of cert-manager Issuers and ClusterIssuers, such as acme or ca, and empty for other kinds
+	Type string `json:"type,omitempty"`
+	// Problems describes each reason the issuer may be unable to issue certificates
+	Problems []string `json:"problems,omitempty"`
+	// Issuance is the result of issuing a test certificate, if one was requested
+	Issuance *Issuance `json:"issuance,omitempty"`
+}
+
+// Issuance is the result of issuing a test certificate with an issuer.
+type Issuance struct {
+	Request   string `json:"request,omitempty"`
+	Succeeded bool   `json:"succeeded"`
+	Skipped   bool   `json:"skipped,omitempty"`
+	Latency   string `json:"latency,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// CheckOptions configures how issuers are checked.
+type CheckOptions struct {
+	// ClusterResourceNamespace is the namespace cert-manager and the Google CAS issuer read the Secrets of cluster
+	// scoped issuers from
+	ClusterResourceNamespace string
+	// Now is the time CA certificates are checked against
+	Now time.Time
+}
+
+// secretRef is a reference from an issuer to a Secret it needs, along with the keys it must contain.
+type secretRef struct {
+	field     string
+	namespace string
+	name      string
+	keys      []string
+	// caKey is the key of a certificate in the Secret that must be a CA certificate, if any
+	caKey string
+}
+
+type secretGetter func(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+
+// CheckIssuers checks all issuers of the supported kinds in the cluster, reporting those that are not ready, are
+// missing the Secrets they reference, or have CA certificates that are missing or have expired. Issuers are sorted by
+// kind, namespace and name.
+func CheckIssuers(ctx context.Context, cfg *rest.Config, opts CheckOptions) ([]IssuerCheck, error) {
+	objects, err := listIssuerObjects(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	secretClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&clients.GenericClientOptions{
+			RestConfig: cfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret client: %w", err)
+	}
+
+	getSecret := func(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+		var secret corev1.Secret
+		err := secretClient.Get(ctx, &clients.GenericRequestOptions{Namespace: namespace, Name: name}, &secret)
+		return &secret, err
+	}
+
+	checks := make([]IssuerCheck, 0, len(objects))
+	for _, object := range objects {
+		check, err := checkIssuer(ctx, object, getSecret, opts)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		return lessIssuer(checks[i].Issuer, checks[j].Issuer)
+	})
+
+	return checks, nil
+}
+
+func checkIssuer(ctx context.Context, issuer issuerObject, getSecret secretGetter, opts CheckOptions) (IssuerCheck, error) {
+	check := IssuerCheck{
+		Issuer: summarizeIssuer(issuer.kind, issuer.object),
+		Type:   issuerType(issuer.kind, issuer.object),
+	}
+
+	switch {
+	case check.IsReady():
+	case check.Ready == readyUnknown && check.Reason == "":
+		check.Problems = append(check.Problems, "has not reported whether it is ready")
+	default:
+		check.Problems = append(check.Problems, fmt.Sprintf("not ready: %s: %s", check.Reason, check.Message))
+	}
+
+	for _, ref := range secretRefs(issuer.kind, issuer.object, check.Namespace, opts.ClusterResourceNamespace) {
+		secret, err := getSecret(ctx, ref.namespace, ref.name)
+		switch {
+		case apierrors.IsNotFound(err):
+			check.Problems = append(check.Problems, fmt.Sprintf("secret %s/%s referenced by %s not found", ref.namespace, ref.name, ref.field))
+			continue
+		case err != nil:
+			return IssuerCheck{}, fmt.Errorf("failed to get secret %s/%s: %w", ref.namespace, ref.name, err)
+		}
+
+		for _, key := range ref.keys {
+			if _, ok := secret.Data[key]; !ok {
+				check.Problems = append(check.Problems, fmt.Sprintf("secret %s/%s referenced by %s has no %s key", ref.namespace, ref.name, ref.field, key))
+			}
+		}
+
+		if data, ok := secret.Data[ref.caKey]; ok && ref.caKey != "" {
+			check.Problems = append(check.Problems, checkCertificates(fmt.Sprintf("secret %s/%s", ref.namespace, ref.name), data, true, opts.Now)...)
+		}
+	}
+
+	if field, bundle, ok := caBundle(issuer.kind, issuer.object); ok {
+		check.Problems = append(check.Problems, checkCertificates(field, bundle, false, opts.Now)...)
+	}
+
+	return check, nil
+}
+
+// issuerType returns the type of a cert-manager Issuer or ClusterIssuer.
+func issuerType(kind clients.AnyIssuer, object map[string]interface{}) string {
+	if kind != clients.CertManagerIssuer && kind != clients.CertManagerClusterIssuer {
+		return ""
+	}
+
+	for _, issuerType := range []string{TypeACME, TypeCA, TypeVault, TypeVenafi, TypeSelfSigned} {
+		if _, ok, _ := unstructured.NestedMap(object, "spec", issuerType); ok {
+			return issuerType
+		}
+	}
+
+	return ""
+}
+
+// secretRefs returns the Secrets referenced by the issuer. The Secrets of namespaced issuers are in their own
+// namespace, while those of cluster scoped issuers are in the cluster resource namespace, or in the namespace given
+// in the reference for issuers that support it.
+func secretRefs(kind clients.AnyIssuer, object map[string]interface{}, namespace, clusterResourceNamespace string) []secretRef {
+	if kind.ClusterScoped() {
+		namespace = clusterResourceNamespace
+	}
+
+	var refs []secretRef
+	// ref adds the Secret referenced by the name field of the object at the path, if it is set. If keyField is set,
+	// the Secret must contain the key it gives, or defaultKey if it is not set.
+	ref := func(path []string, keyField, defaultKey string) {
+		name, _, _ := unstructured.NestedString(object, append(path, "name")...)
+		if name == "" {
+			return
+		}
+
+		r := secretRef{field: strings.Join(append(path, "name"), "."), namespace: namespace, name: name}
+		if keyField != "" {
+			key, _, _ := unstructured.NestedString(object, append(path, keyField)...)
+			if key == "" {
+				key = defaultKey
+			}
+			if key != "" {
+				r.keys = []string{key}
+			}
+		}
+		refs = append(refs, r)
+	}
+
+	switch kind {
+	case clients.CertManagerIssuer, clients.CertManagerClusterIssuer:
+		ref([]string{"spec", "acme", "privateKeySecretRef"}, "key", corev1.TLSPrivateKeyKey)
+		if name, _, _ := unstructured.NestedString(object, "spec", "ca", "secretName"); name != "" {
+			refs = append(refs, secretRef{
+				field:     "spec.ca.secretName",
+				namespace: namespace,
+				name:      name,
+				keys:      []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+				caKey:     corev1.TLSCertKey,
+			})
+		}
+		ref([]string{"spec", "vault", "auth", "tokenSecretRef"}, "key", "")
+		ref([]string{"spec", "vault", "auth", "appRole", "secretRef"}, "key", "")
+		ref([]string{"spec", "vault", "auth", "kubernetes", "secretRef"}, "key", "token")
+		ref([]string{"spec", "venafi", "tpp", "credentialsRef"}, "", "")
+		ref([]string{"spec", "venafi", "cloud", "apiTokenSecretRef"}, "key", "")
+	case clients.GoogleCASIssuer, clients.GoogleCASClusterIssuer:
+		ref([]string{"spec", "credentials"}, "key", "")
+	case clients.AWSPCAIssuer, clients.AWSPCAClusterIssuer:
+		name, _, _ := unstructured.NestedString(object, "spec", "secretRef", "name")
+		if name == "" {
+			break
+		}
+
+		secretNamespace, _, _ := unstructured.NestedString(object, "spec", "secretRef", "namespace")
+		accessKeyID, _, _ := unstructured.NestedString(object, "spec", "secretRef", "accessKeyIDSelector", "key")
+		if accessKeyID == "" {
+			accessKeyID = "AWS_ACCESS_KEY_ID"
+		}
+		secretAccessKey, _, _ := unstructured.NestedString(object, "spec", "secretRef", "secretAccessKeySelector", "key")
+		if secretAccessKey == "" {
+			secretAccessKey = "AWS_SECRET_ACCESS_KEY"
+		}
+
+		refs = append(refs, secretRef{
+			field:     "spec.secretRef.name",
+			namespace: secretNamespace,
+			name:      name,
+			keys:      []string{accessKeyID, secretAccessKey},
+		})
+	case clients.OriginCAIssuer:
+		ref([]string{"spec", "auth", "serviceKeyRef"}, "key", "")
+	case clients.SmallStepIssuer:
+		ref([]string{"spec", "provisioner", "passwordRef"}, "key", "")
+	case clients.SmallStepClusterIssuer:
+		// StepClusterIssuers give the namespace of the Secret in the reference
+		namespace, _, _ = unstructured.NestedString(object, "spec", "provisioner", "passwordRef", "namespace")
+		ref([]string{"spec", "provisioner", "passwordRef"}, "key", "")
+	}
+
+	return refs
+}
+
+// caBundle returns the field of the issuer's spec that sets its CA certificates, and the PEM encoded certificates
+// set in it, if any.
+func caBundle(kind clients.AnyIssuer, object map[string]interface{}) (string, []byte, bool) {
+	var path []string
+	switch kind {
+	case clients.CertManagerIssuer, clients.CertManagerClusterIssuer:
+		path = []string{"spec", "vault", "caBundle"}
+	case clients.SmallStepIssuer, clients.SmallStepClusterIssuer:
+		path = []string{"spec", "caBundle"}
+	default:
+		return "", nil, false
+	}
+
+	// []byte fields are base64 encoded in the unstructured form of a resource
+	encoded, _, _ := unstructured.NestedString(object, path...)
+	if encoded == "" {
+		return "", nil, false
+	}
+
+	// a bundle that cannot be decoded is reported as containing no certificates
+	bundle, _ := base64.StdEncoding.DecodeString(encoded)
+	return strings.Join(path, "."), bundle, true
+}
+
+// checkCertificates returns the problems with the PEM encoded certificates found at the source: that there are none,
+// or that they have expired or are not yet valid. If requireCA is set, the first certificate must be a CA.
+func checkCertificates(source string, data []byte, requireCA bool, now time.Time) []string {
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return []string{fmt.Sprintf("%s contains an invalid certificate: %s", source, err)}
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return []string{fmt.Sprintf("%s contains no CA certificate", source)}
+	}
+
+	var problems []string
+	if requireCA && !certificates[0].IsCA {
+		problems = append(problems, fmt.Sprintf("certificate %q in %s is not a CA certificate", certificates[0].Subject.CommonName, source))
+	}
+	for _, certificate := range certificates {
+		switch {
+		case now.After(certificate.NotAfter):
+			problems = append(problems, fmt.Sprintf("certificate %q in %s expired on %s", certificate.Subject.CommonName, source, certificate.NotAfter.UTC().Format(time.RFC3339)))
+		case now.Before(certificate.NotBefore):
+			problems = append(problems, fmt.Sprintf("certificate %q in %s is not valid until %s", certificate.Subject.CommonName, source, certificate.NotBefore.UTC().Format(time.RFC3339)))
+		}
+	}
+
+	return problems
+}
+
+// CheckIssuance issues a test certificate for the DNS name with the issuer, by creating a CertificateRequest in the
+// namespace and waiting for it to become ready, checking it at the interval until the context is done. The
+// CertificateRequest is deleted afterwards. Requests to cluster scoped issuers are created in the namespace given,
+// and requests to namespaced issuers in the issuer's namespace.
+func CheckIssuance(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], issuer Issuer, namespace, dnsName string, interval time.Duration) Issuance {
+	if issuer.Scope == ScopeNamespaced {
+		namespace = issuer.Namespace
+	}
+
+	csr, err := testCSR(dnsName)
+	if err != nil {
+		return Issuance{Message: err.Error()}
+	}
+
+	request := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "jsctl-issuer-check-",
+			Namespace:    namespace,
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Request: csr,
+			IssuerRef: cmmeta.ObjectReference{
+				Name:  issuer.Name,
+				Kind:  issuer.Kind,
+				Group: issuer.Group,
+			},
+			Usages: []cmapi.KeyUsage{cmapi.UsageDigitalSignature, cmapi.UsageKeyEncipherment, cmapi.UsageServerAuth},
+		},
+	}
+
+	start := time.Now()
+	if err := client.Create(ctx, &clients.GenericRequestOptions{Namespace: namespace}, request); err != nil {
+		return Issuance{Message: fmt.Sprintf("failed to create certificate request: %s", err)}
+	}
+
+	issuance := waitForIssuance(ctx, client, namespace, request.Name, interval)
+	issuance.Request = namespace + "/" + request.Name
+	if issuance.Succeeded {
+		issuance.Latency = time.Since(start).Round(time.Millisecond).String()
+	}
+
+	// the request is deleted even if the context is done, as it was only created for the test
+	err = client.Delete(context.Background(), &clients.GenericRequestOptions{Namespace: namespace, Name: request.Name})
+	if err != nil {
+		issuance.Message = fmt.Sprintf("%s, and failed to delete the test certificate request: %s", issuance.Message, err)
+	}
+
+	return issuance
+}
+
+func waitForIssuance(ctx context.Context, client clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList], namespace, name string, interval time.Duration) Issuance {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := "waiting for the request to be created"
+	for {
+		var request cmapi.CertificateRequest
+		err := client.Get(ctx, &clients.GenericRequestOptions{Namespace: namespace, Name: name}, &request)
+		if err == nil {
+			state = "waiting for the request to be approved"
+			for _, condition := range request.Status.Conditions {
+				switch {
+				case condition.Type == cmapi.CertificateRequestConditionDenied && condition.Status == cmmeta.ConditionTrue:
+					return Issuance{Message: fmt.Sprintf("request denied: %s", condition.Message)}
+				case condition.Type == cmapi.CertificateRequestConditionApproved && condition.Status == cmmeta.ConditionTrue:
+					state = "waiting for the issuer to sign the request"
+				case condition.Type == cmapi.CertificateRequestConditionReady && condition.Status == cmmeta.ConditionTrue:
+					return Issuance{Succeeded: true, Message: "certificate issued"}
+				case condition.Type == cmapi.CertificateRequestConditionReady && condition.Reason == cmapi.CertificateRequestReasonFailed:
+					return Issuance{Message: fmt.Sprintf("issuance failed: %s", condition.Message)}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return Issuance{Message: fmt.Sprintf("timed out %s", state)}
+		case <-ticker.C:
+		}
+	}
+}
+
+// testCSR returns a PEM encoded certificate signing request for the DNS name, signed with a new key.
+func testCSR(dnsName string) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: dnsName},
+		DNSNames: []string{dnsName},
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate signing request: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}), nil
+}
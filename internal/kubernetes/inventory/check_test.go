@@ -0,0 +1,244 @@
+package inventory
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestCheckIssuer(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	readyStatus := map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		},
+	}
+
+	secrets := map[string]*corev1.Secret{
+		"cert-manager/ca": {Data: map[string][]byte{
+			"tls.crt": testCertificate(t, "expired-ca", true, now.Add(-2*time.Hour), now.Add(-time.Hour)),
+			"tls.key": []byte("key"),
+		}},
+		"cert-manager/leaf": {Data: map[string][]byte{
+			"tls.crt": testCertificate(t, "leaf", false, now.Add(-time.Hour), now.Add(time.Hour)),
+		}},
+		"foo/aws": {Data: map[string][]byte{
+			"AWS_ACCESS_KEY_ID": []byte("id"),
+		}},
+	}
+	getSecret := func(_ context.Context, namespace, name string) (*corev1.Secret, error) {
+		secret, ok := secrets[namespace+"/"+name]
+		if !ok {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+		}
+		return secret, nil
+	}
+
+	tests := map[string]struct {
+		kind             clients.AnyIssuer
+		object           map[string]interface{}
+		expectedType     string
+		expectedProblems []string
+	}{
+		"ready self signed issuer": {
+			kind: clients.CertManagerIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "self-signed", "namespace": "foo"},
+				"spec":     map[string]interface{}{"selfSigned": map[string]interface{}{}},
+				"status":   readyStatus,
+			},
+			expectedType: TypeSelfSigned,
+		},
+		"CA cluster issuer with an expired certificate": {
+			kind: clients.CertManagerClusterIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "ca"},
+				"spec":     map[string]interface{}{"ca": map[string]interface{}{"secretName": "ca"}},
+				"status":   readyStatus,
+			},
+			expectedType: TypeCA,
+			expectedProblems: []string{
+				`certificate "expired-ca" in secret cert-manager/ca expired on 2022-12-31T23:00:00Z`,
+			},
+		},
+		"CA issuer using a leaf certificate with no key": {
+			kind: clients.CertManagerClusterIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "ca"},
+				"spec":     map[string]interface{}{"ca": map[string]interface{}{"secretName": "leaf"}},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "False", "reason": "ErrInvalidKeyPair", "message": "Error getting keypair for CA issuer"},
+					},
+				},
+			},
+			expectedType: TypeCA,
+			expectedProblems: []string{
+				"not ready: ErrInvalidKeyPair: Error getting keypair for CA issuer",
+				"secret cert-manager/leaf referenced by spec.ca.secretName has no tls.key key",
+				`certificate "leaf" in secret cert-manager/leaf is not a CA certificate`,
+			},
+		},
+		"ACME issuer missing its account key": {
+			kind: clients.CertManagerIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "letsencrypt", "namespace": "foo"},
+				"spec": map[string]interface{}{"acme": map[string]interface{}{
+					"privateKeySecretRef": map[string]interface{}{"name": "letsencrypt"},
+				}},
+			},
+			expectedType: TypeACME,
+			expectedProblems: []string{
+				"has not reported whether it is ready",
+				"secret foo/letsencrypt referenced by spec.acme.privateKeySecretRef.name not found",
+			},
+		},
+		"AWS PCA cluster issuer missing a credential": {
+			kind: clients.AWSPCAClusterIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "pca"},
+				"spec": map[string]interface{}{"secretRef": map[string]interface{}{
+					"name":      "aws",
+					"namespace": "foo",
+				}},
+				"status": readyStatus,
+			},
+			expectedProblems: []string{
+				"secret foo/aws referenced by spec.secretRef.name has no AWS_SECRET_ACCESS_KEY key",
+			},
+		},
+		"step issuer with an invalid CA bundle": {
+			kind: clients.SmallStepIssuer,
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "step", "namespace": "foo"},
+				"spec": map[string]interface{}{
+					"caBundle":    base64.StdEncoding.EncodeToString([]byte("not a certificate")),
+					"provisioner": map[string]interface{}{"passwordRef": map[string]interface{}{"name": "step", "key": "password"}},
+				},
+				"status": readyStatus,
+			},
+			expectedProblems: []string{
+				"secret foo/step referenced by spec.provisioner.passwordRef.name not found",
+				"spec.caBundle contains no CA certificate",
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			check, err := checkIssuer(context.Background(), issuerObject{kind: test.kind, object: test.object}, getSecret, CheckOptions{
+				ClusterResourceNamespace: "cert-manager",
+				Now:                      now,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedType, check.Type)
+			assert.Equal(t, test.expectedProblems, check.Problems)
+		})
+	}
+}
+
+func TestCheckIssuance(t *testing.T) {
+	issuer := Issuer{Kind: "ClusterIssuer", Group: "cert-manager.io", Name: "ca", Scope: ScopeCluster}
+
+	tests := map[string]struct {
+		conditions []cmapi.CertificateRequestCondition
+		expected   Issuance
+	}{
+		"issued": {
+			conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionApproved, Status: cmmeta.ConditionTrue},
+				{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionTrue},
+			},
+			expected: Issuance{Request: "default/jsctl-issuer-check-abcde", Succeeded: true, Message: "certificate issued"},
+		},
+		"denied": {
+			conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionDenied, Status: cmmeta.ConditionTrue, Message: "No policy approved this request"},
+			},
+			expected: Issuance{Request: "default/jsctl-issuer-check-abcde", Message: "request denied: No policy approved this request"},
+		},
+		"failed": {
+			conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionApproved, Status: cmmeta.ConditionTrue},
+				{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: cmapi.CertificateRequestReasonFailed, Message: "signing failed"},
+			},
+			expected: Issuance{Request: "default/jsctl-issuer-check-abcde", Message: "issuance failed: signing failed"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created *cmapi.CertificateRequest
+			deleted := false
+			client := &clients.FakeGeneric[*cmapi.CertificateRequest, *cmapi.CertificateRequestList]{
+				FakeCreate: func(_ context.Context, options *clients.GenericRequestOptions, request *cmapi.CertificateRequest) error {
+					assert.Equal(t, "default", options.Namespace)
+					request.Name = "jsctl-issuer-check-abcde"
+					created = request
+					return nil
+				},
+				FakeGet: func(_ context.Context, options *clients.GenericRequestOptions, request *cmapi.CertificateRequest) error {
+					assert.Equal(t, "jsctl-issuer-check-abcde", options.Name)
+					request.Status.Conditions = test.conditions
+					return nil
+				},
+				FakeDelete: func(_ context.Context, options *clients.GenericRequestOptions) error {
+					assert.Equal(t, "jsctl-issuer-check-abcde", options.Name)
+					deleted = true
+					return nil
+				},
+			}
+
+			issuance := CheckIssuance(context.Background(), client, issuer, "default", "check.example.com", time.Millisecond)
+			if test.expected.Succeeded {
+				assert.NotEmpty(t, issuance.Latency)
+				issuance.Latency = ""
+			}
+			assert.Equal(t, test.expected, issuance)
+			assert.True(t, deleted)
+
+			require.NotNil(t, created)
+			assert.Equal(t, cmmeta.ObjectReference{Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io"}, created.Spec.IssuerRef)
+			block, _ := pem.Decode(created.Spec.Request)
+			require.NotNil(t, block)
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"check.example.com"}, csr.DNSNames)
+		})
+	}
+}
+
+func testCertificate(t *testing.T, commonName string, isCA bool, notBefore, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,256 @@
+// Package inventory provides functions for listing the issuers of all kinds found in a cluster, along with whether
+// they are ready and how they are used.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	kmsissuerv1alpha1 "github.com/Skyscanner/kms-issuer/apis/certmanager/v1alpha1"
+	awspcaissuerv1beta1 "github.com/cert-manager/aws-privateca-issuer/pkg/api/v1beta1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	origincaissuerv1 "github.com/cloudflare/origin-ca-issuer/pkgs/apis/v1"
+	googlecasissuerv1beta1 "github.com/jetstack/google-cas-issuer/api/v1beta1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+	stepissuerv1beta1 "github.com/smallstep/step-issuer/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+const (
+	// ScopeNamespaced and ScopeCluster describe whether an issuer can be used by Certificates in its own namespace
+	// or in any namespace
+	ScopeNamespaced = "Namespaced"
+	ScopeCluster    = "Cluster"
+
+	// readyUnknown is used for issuers that have not reported a Ready condition
+	readyUnknown = "Unknown"
+)
+
+// Issuer is a summary of an issuer of any kind found in a cluster.
+type Issuer struct {
+	Kind         string    `json:"kind"`
+	Group        string    `json:"group"`
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Scope        string    `json:"scope"`
+	Ready        string    `json:"ready"`
+	Reason       string    `json:"reason,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	Created      time.Time `json:"created"`
+	Certificates int       `json:"certificates"`
+}
+
+// IsReady returns true if the issuer reports that it is ready to issue certificates.
+func (i Issuer) IsReady() bool {
+	return i.Ready == "True"
+}
+
+// ListIssuers returns all issuers of the supported kinds in all namespaces of the cluster, along with the number of
+// Certificates that reference each of them. Issuers are sorted by kind, namespace and name.
+func ListIssuers(ctx context.Context, cfg *rest.Config) ([]Issuer, error) {
+	objects, err := listIssuerObjects(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuers []Issuer
+	for _, object := range objects {
+		issuers = append(issuers, summarizeIssuer(object.kind, object.object))
+	}
+
+	if len(issuers) == 0 {
+		return issuers, nil
+	}
+
+	if err := countCertificates(ctx, cfg, issuers); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(issuers, func(i, j int) bool {
+		return lessIssuer(issuers[i], issuers[j])
+	})
+
+	return issuers, nil
+}
+
+// lessIssuer orders issuers by kind, namespace and name.
+func lessIssuer(a, b Issuer) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// issuerObject is an issuer of any kind in its unstructured form.
+type issuerObject struct {
+	kind   clients.AnyIssuer
+	object map[string]interface{}
+}
+
+// listIssuerObjects returns all issuers of the supported kinds in all namespaces of the cluster.
+func listIssuerObjects(ctx context.Context, cfg *rest.Config) ([]issuerObject, error) {
+	issuerClient, err := clients.NewAllIssuers(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issuer client: %w", err)
+	}
+	issuerKinds, err := issuerClient.ListKinds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issuer kinds: %w", err)
+	}
+
+	var issuers []issuerObject
+	for _, kind := range issuerKinds {
+		var objects []map[string]interface{}
+		switch kind {
+		case clients.CertManagerIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewCertManagerIssuerClient, &cmapi.IssuerList{})
+		case clients.CertManagerClusterIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewCertManagerClusterIssuerClient, &cmapi.ClusterIssuerList{})
+		case clients.GoogleCASIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewGoogleCASIssuerClient, &googlecasissuerv1beta1.GoogleCASIssuerList{})
+		case clients.GoogleCASClusterIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewGoogleCASClusterIssuerClient, &googlecasissuerv1beta1.GoogleCASClusterIssuerList{})
+		case clients.AWSPCAIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewAWSPCAIssuerClient, &awspcaissuerv1beta1.AWSPCAIssuerList{})
+		case clients.AWSPCAClusterIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewAWSPCAClusterIssuerClient, &awspcaissuerv1beta1.AWSPCAClusterIssuerList{})
+		case clients.KMSIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewKMSIssuerClient, &kmsissuerv1alpha1.KMSIssuerList{})
+		case clients.VenafiEnhancedIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewVenafiEnhancedIssuerClient, &veiv1alpha1.VenafiIssuerList{})
+		case clients.VenafiEnhancedClusterIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewVenafiEnhancedClusterIssuerClient, &veiv1alpha1.VenafiClusterIssuerList{})
+		case clients.OriginCAIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewOriginCAIssuerClient, &origincaissuerv1.OriginIssuerList{})
+		case clients.SmallStepIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewSmallStepIssuerClient, &stepissuerv1beta1.StepIssuerList{})
+		case clients.SmallStepClusterIssuer:
+			objects, err = listObjects(ctx, cfg, clients.NewSmallStepClusterIssuerClient, &stepissuerv1beta1.StepClusterIssuerList{})
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+
+		for _, object := range objects {
+			issuers = append(issuers, issuerObject{kind: kind, object: object})
+		}
+	}
+
+	return issuers, nil
+}
+
+// listObjects lists all resources using a client created by newClient, returning them in their unstructured form so
+// that the metadata and conditions of issuers of different kinds can be read in the same way.
+func listObjects[T, ListT runtime.Object](ctx context.Context, cfg *rest.Config, newClient func(*rest.Config) (clients.Generic[T, ListT], error), list ListT) ([]map[string]interface{}, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if err := client.List(ctx, &clients.GenericRequestOptions{}, list); err != nil {
+		return nil, err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+func summarizeIssuer(kind clients.AnyIssuer, object map[string]interface{}) Issuer {
+	u := unstructured.Unstructured{Object: object}
+
+	issuer := Issuer{
+		// the kind is not always set on the items of a list
+		Kind:      kind.Kind(),
+		Group:     kind.Group(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Scope:     ScopeNamespaced,
+		Ready:     readyUnknown,
+		Created:   u.GetCreationTimestamp().UTC(),
+	}
+	if kind.ClusterScoped() {
+		issuer.Scope = ScopeCluster
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(object, "status", "conditions")
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok || c["type"] != "Ready" {
+			continue
+		}
+
+		issuer.Ready, _, _ = unstructured.NestedString(c, "status")
+		issuer.Reason, _, _ = unstructured.NestedString(c, "reason")
+		issuer.Message, _, _ = unstructured.NestedString(c, "message")
+	}
+
+	return issuer
+}
+
+// countCertificates sets the number of Certificates that reference each of the issuers.
+func countCertificates(ctx context.Context, cfg *rest.Config, issuers []Issuer) error {
+	certificateClient, err := clients.NewCertificateClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate client: %w", err)
+	}
+
+	var certificates cmapi.CertificateList
+	if err := certificateClient.List(ctx, &clients.GenericRequestOptions{}, &certificates); err != nil {
+		return fmt.Errorf("failed to list certificates: %w", err)
+	}
+
+	index := make(map[string]int, len(issuers))
+	for i, issuer := range issuers {
+		index[issuerKey(issuer.Group, issuer.Kind, issuer.Namespace, issuer.Name)] = i
+	}
+
+	for _, certificate := range certificates.Items {
+		ref := certificate.Spec.IssuerRef
+		// cert-manager defaults unset fields of the issuerRef to its own Issuer
+		group, kind := ref.Group, ref.Kind
+		if group == "" {
+			group = cmapi.SchemeGroupVersion.Group
+		}
+		if kind == "" {
+			kind = cmapi.IssuerKind
+		}
+
+		for _, namespace := range []string{certificate.Namespace, ""} {
+			if i, ok := index[issuerKey(group, kind, namespace, ref.Name)]; ok {
+				issuers[i].Certificates++
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func issuerKey(group, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+}
@@ -0,0 +1,97 @@
+package inventory
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestListIssuers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var fixture string
+		switch r.URL.Path {
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+			fixture = "fixtures/crd-list.json"
+		case "/apis/cert-manager.io/v1/issuers":
+			fixture = "fixtures/issuer-list.json"
+		case "/apis/cert-manager.io/v1/clusterissuers":
+			fixture = "fixtures/cluster-issuer-list.json"
+		case "/apis/cas-issuer.jetstack.io/v1beta1/googlecasissuers":
+			fixture = "fixtures/googlecasissuer-list.json"
+		case "/apis/cert-manager.io/v1/certificates":
+			fixture = "fixtures/certificate-list.json"
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+
+		data, err := os.ReadFile(fixture)
+		require.NoError(t, err)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	issuers, err := ListIssuers(context.Background(), &rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	created := func(value string) time.Time {
+		created, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return created
+	}
+
+	assert.Equal(t, []Issuer{
+		{
+			Kind:         "ClusterIssuer",
+			Group:        "cert-manager.io",
+			Name:         "ca",
+			Scope:        ScopeCluster,
+			Ready:        "True",
+			Reason:       "KeyPairVerified",
+			Message:      "Signing CA verified",
+			Created:      created("2022-11-08T14:22:44Z"),
+			Certificates: 2,
+		},
+		{
+			Kind:         "GoogleCASIssuer",
+			Group:        "cas-issuer.jetstack.io",
+			Name:         "cas",
+			Namespace:    "foo",
+			Scope:        ScopeNamespaced,
+			Ready:        "Unknown",
+			Created:      created("2022-11-08T14:23:44Z"),
+			Certificates: 1,
+		},
+		{
+			Kind:      "Issuer",
+			Group:     "cert-manager.io",
+			Name:      "ca",
+			Namespace: "bar",
+			Scope:     ScopeNamespaced,
+			Ready:     "True",
+			Reason:    "KeyPairVerified",
+			Message:   "Signing CA verified",
+			Created:   created("2022-11-08T14:21:47Z"),
+		},
+		{
+			Kind:         "Issuer",
+			Group:        "cert-manager.io",
+			Name:         "ca",
+			Namespace:    "foo",
+			Scope:        ScopeNamespaced,
+			Ready:        "False",
+			Reason:       "ErrGetKeyPair",
+			Message:      "Error getting keypair for CA issuer: secret \"ca-key-pair\" not found",
+			Created:      created("2022-11-08T14:20:47Z"),
+			Certificates: 2,
+		},
+	}, issuers)
+}
@@ -0,0 +1,140 @@
+// Package migrate contains functions for migrating cert-manager installed with Helm to an Installation managed by
+// the Jetstack Secure operator.
+package migrate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+const (
+	// helmReleaseSelector selects the Secrets Helm stores the deployed revision of each release in
+	helmReleaseSelector = "owner=helm,status=deployed"
+	// helmReleaseKey is the key of the encoded release in a Helm release Secret
+	helmReleaseKey = "release"
+	// CertManagerChart is the name of the cert-manager Helm chart
+	CertManagerChart = "cert-manager"
+)
+
+// gzipMagic is the header of gzip compressed data, Helm releases stored by older versions of Helm are not compressed
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease describes a deployed revision of a Helm release.
+type HelmRelease struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	Revision     int    `json:"revision"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chartVersion"`
+	AppVersion   string `json:"appVersion"`
+	// Values are the values the release was installed or upgraded with, not including the chart defaults
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// String returns the namespace and name of the release.
+func (r *HelmRelease) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// helmRelease is the subset of the release Helm stores that is needed to describe it
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// FindHelmReleases returns the deployed Helm releases of the chart in the cluster. Releases are found through the
+// Secrets Helm stores them in, so releases stored with other Helm storage drivers are not found. Secrets which cannot
+// be decoded, such as those of releases stored by other tools, are skipped with a warning.
+func FindHelmReleases(ctx context.Context, secretsClient clients.Generic[*corev1.Secret, *corev1.SecretList], chart string) ([]*HelmRelease, error) {
+	var secrets corev1.SecretList
+	err := secretsClient.List(ctx, &clients.GenericRequestOptions{LabelSelector: helmReleaseSelector}, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Helm release secrets: %w", err)
+	}
+
+	var releases []*HelmRelease
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+
+		release, err := DecodeHelmRelease(secret)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping Helm release secret %s/%s which could not be decoded: %s\n", secret.Namespace, secret.Name, err)
+			continue
+		}
+
+		if release.Chart == chart {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases, nil
+}
+
+// DecodeHelmRelease decodes the release stored in a Helm release Secret. Helm stores releases as base64 encoded,
+// gzip compressed JSON.
+func DecodeHelmRelease(secret *corev1.Secret) (*HelmRelease, error) {
+	encoded, ok := secret.Data[helmReleaseKey]
+	if !ok {
+		return nil, fmt.Errorf("secret has no %q key", helmReleaseKey)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding release: %w", err)
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing release: %w", err)
+		}
+		defer reader.Close()
+
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing release: %w", err)
+		}
+	}
+
+	var release helmRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("error unmarshalling release: %w", err)
+	}
+
+	namespace := release.Namespace
+	if namespace == "" {
+		namespace = secret.Namespace
+	}
+
+	return &HelmRelease{
+		Name:         release.Name,
+		Namespace:    namespace,
+		Revision:     release.Version,
+		Chart:        release.Chart.Metadata.Name,
+		ChartVersion: release.Chart.Metadata.Version,
+		AppVersion:   release.Chart.Metadata.AppVersion,
+		Values:       release.Config,
+	}, nil
+}
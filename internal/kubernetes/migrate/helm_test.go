@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+const certManagerReleaseJSON = `{
+  "name": "cert-manager",
+  "namespace": "cert-manager",
+  "version": 3,
+  "info": {"status": "deployed"},
+  "chart": {"metadata": {"name": "cert-manager", "version": "v1.11.0", "appVersion": "v1.11.0"}},
+  "config": {"installCRDs": true, "replicaCount": 2}
+}`
+
+func helmReleaseSecret(t *testing.T, name, namespace, release string, compress bool) corev1.Secret {
+	data := []byte(release)
+	if compress {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, err := writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		data = buf.Bytes()
+	}
+
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"owner": "helm", "status": "deployed"},
+		},
+		Type: "helm.sh/release.v1",
+		Data: map[string][]byte{
+			"release": []byte(base64.StdEncoding.EncodeToString(data)),
+		},
+	}
+}
+
+func TestDecodeHelmRelease(t *testing.T) {
+	expected := &HelmRelease{
+		Name:         "cert-manager",
+		Namespace:    "cert-manager",
+		Revision:     3,
+		Chart:        "cert-manager",
+		ChartVersion: "v1.11.0",
+		AppVersion:   "v1.11.0",
+		Values:       map[string]interface{}{"installCRDs": true, "replicaCount": float64(2)},
+	}
+
+	t.Run("compressed", func(t *testing.T) {
+		secret := helmReleaseSecret(t, "sh.helm.release.v1.cert-manager.v3", "cert-manager", certManagerReleaseJSON, true)
+
+		release, err := DecodeHelmRelease(&secret)
+		require.NoError(t, err)
+		assert.Equal(t, expected, release)
+	})
+
+	t.Run("uncompressed", func(t *testing.T) {
+		secret := helmReleaseSecret(t, "sh.helm.release.v1.cert-manager.v3", "cert-manager", certManagerReleaseJSON, false)
+
+		release, err := DecodeHelmRelease(&secret)
+		require.NoError(t, err)
+		assert.Equal(t, expected, release)
+	})
+
+	t.Run("missing release", func(t *testing.T) {
+		secret := corev1.Secret{Data: map[string][]byte{}}
+
+		_, err := DecodeHelmRelease(&secret)
+		assert.Error(t, err)
+	})
+}
+
+func TestFindHelmReleases(t *testing.T) {
+	otherRelease := `{"name": "ingress", "namespace": "ingress", "version": 1, "chart": {"metadata": {"name": "ingress-nginx"}}}`
+
+	secretsClient := &clients.FakeGeneric[*corev1.Secret, *corev1.SecretList]{
+		FakeList: func(ctx context.Context, options *clients.GenericRequestOptions, list *corev1.SecretList) error {
+			assert.Equal(t, "owner=helm,status=deployed", options.LabelSelector)
+			list.Items = []corev1.Secret{
+				helmReleaseSecret(t, "sh.helm.release.v1.ingress.v1", "ingress", otherRelease, true),
+				// a release that cannot be decoded does not stop the others from being found
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.broken.v1", Namespace: "broken"},
+					Data:       map[string][]byte{"release": []byte("not base64")},
+				},
+				helmReleaseSecret(t, "sh.helm.release.v1.cert-manager.v3", "cert-manager", certManagerReleaseJSON, true),
+			}
+			return nil
+		},
+	}
+
+	releases, err := FindHelmReleases(context.Background(), secretsClient, CertManagerChart)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "cert-manager/cert-manager", releases[0].String())
+	assert.Equal(t, 3, releases[0].Revision)
+}
@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+// defaultReplicas is the number of cert-manager controller replicas the Helm chart deploys unless replicaCount is set
+const defaultReplicas = 1
+
+// carriedControllerFlags are the cert-manager controller flags which do not need to be set on the Installation. The
+// Helm chart sets the first four by default and the operator configures them itself, and the certificate owner
+// reference flag is unset as part of the migration.
+var carriedControllerFlags = map[string]bool{
+	"v":                            true,
+	"leader-election-namespace":    true,
+	"acme-http01-solver-image":     true,
+	"max-concurrent-challenges":    true,
+	"enable-certificate-owner-ref": true,
+}
+
+// Plan describes the Installation a Helm release of cert-manager is migrated to.
+type Plan struct {
+	Release HelmRelease `json:"release"`
+
+	CertManagerVersion  string `json:"certManagerVersion"`
+	CertManagerReplicas int    `json:"certManagerReplicas"`
+
+	// UnsupportedFlags are the flags set on the cert-manager controller which cannot be set on an Installation, and
+	// so will not be carried over.
+	UnsupportedFlags []string `json:"unsupportedFlags,omitempty"`
+}
+
+// NewPlan returns the Plan to migrate the Helm release of cert-manager to an Installation matching the version,
+// replicas and flags of the cert-manager the release deployed, as described by status.
+func NewPlan(release *HelmRelease, status *components.CertManagerStatus) (*Plan, error) {
+	if status.Namespace() != release.Namespace {
+		return nil, fmt.Errorf("cert-manager is running in namespace %q, not in the namespace of Helm release %s", status.Namespace(), release)
+	}
+
+	plan := &Plan{
+		Release:             *release,
+		CertManagerVersion:  status.Version(),
+		CertManagerReplicas: defaultReplicas,
+	}
+	// the values are not needed once the plan has been made, and are left out of the plan as they may be sensitive
+	plan.Release.Values = nil
+
+	// the version is taken from the image tag of the controller, unless it was deployed by digest or without a
+	// controller
+	if !strings.HasPrefix(plan.CertManagerVersion, "v") {
+		plan.CertManagerVersion = release.AppVersion
+	}
+	if plan.CertManagerVersion == "" {
+		return nil, fmt.Errorf("could not determine the cert-manager version of Helm release %s", release)
+	}
+
+	if replicas, ok := release.Values["replicaCount"].(float64); ok && replicas >= 1 {
+		plan.CertManagerReplicas = int(replicas)
+	}
+
+	for _, arg := range status.ControllerArgs() {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if carriedControllerFlags[name] {
+			continue
+		}
+		// the chart sets the cluster resource namespace to the release namespace by default
+		if name == "cluster-resource-namespace" && (value == "$(POD_NAMESPACE)" || value == release.Namespace) {
+			continue
+		}
+
+		plan.UnsupportedFlags = append(plan.UnsupportedFlags, arg)
+	}
+	sort.Strings(plan.UnsupportedFlags)
+
+	return plan, nil
+}
+
+// InstallationOptions returns the options to apply the Installation with.
+func (p *Plan) InstallationOptions() operator.ApplyInstallationYAMLOptions {
+	return operator.ApplyInstallationYAMLOptions{
+		CertManagerVersion:  p.CertManagerVersion,
+		CertManagerReplicas: p.CertManagerReplicas,
+	}
+}
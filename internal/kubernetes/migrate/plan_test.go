@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+)
+
+func TestNewPlan(t *testing.T) {
+	release := &HelmRelease{
+		Name:       "cert-manager",
+		Namespace:  "cert-manager",
+		Revision:   3,
+		Chart:      "cert-manager",
+		AppVersion: "v1.11.0",
+		Values:     map[string]interface{}{"replicaCount": float64(3)},
+	}
+
+	t.Run("chart defaults", func(t *testing.T) {
+		status := components.NewCertManagerStatus("cert-manager", "v1.11.1", []string{
+			"--v=2",
+			"--cluster-resource-namespace=$(POD_NAMESPACE)",
+			"--leader-election-namespace=kube-system",
+			"--acme-http01-solver-image=quay.io/jetstack/cert-manager-acmesolver:v1.11.1",
+			"--max-concurrent-challenges=60",
+		})
+
+		plan, err := NewPlan(release, status)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.11.1", plan.CertManagerVersion)
+		assert.Equal(t, 3, plan.CertManagerReplicas)
+		assert.Empty(t, plan.UnsupportedFlags)
+		assert.Nil(t, plan.Release.Values)
+
+		options := plan.InstallationOptions()
+		assert.Equal(t, "v1.11.1", options.CertManagerVersion)
+		assert.Equal(t, 3, options.CertManagerReplicas)
+	})
+
+	t.Run("unsupported flags", func(t *testing.T) {
+		status := components.NewCertManagerStatus("cert-manager", "v1.11.1", []string{
+			"--v=2",
+			"--feature-gates=ExperimentalGatewayAPISupport=true",
+			"--cluster-resource-namespace=secrets",
+			"--enable-certificate-owner-ref=true",
+			"--dns01-recursive-nameservers-only",
+		})
+
+		plan, err := NewPlan(release, status)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"--cluster-resource-namespace=secrets",
+			"--dns01-recursive-nameservers-only",
+			"--feature-gates=ExperimentalGatewayAPISupport=true",
+		}, plan.UnsupportedFlags)
+	})
+
+	t.Run("version from chart", func(t *testing.T) {
+		status := components.NewCertManagerStatus("cert-manager", "sha256", nil)
+
+		plan, err := NewPlan(&HelmRelease{Name: "cert-manager", Namespace: "cert-manager", AppVersion: "v1.10.0"}, status)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.10.0", plan.CertManagerVersion)
+		assert.Equal(t, 1, plan.CertManagerReplicas)
+	})
+
+	t.Run("different namespace", func(t *testing.T) {
+		status := components.NewCertManagerStatus("other", "v1.11.1", nil)
+
+		_, err := NewPlan(release, status)
+		assert.Error(t, err)
+	})
+}
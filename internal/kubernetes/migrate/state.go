@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// State records the progress of a migration, so that it can be resumed if a step fails or is not confirmed. It is
+// created once the Helm release has been detected, as cert-manager can no longer be detected once the release has
+// been uninstalled.
+type State struct {
+	Plan Plan `json:"plan"`
+	// BackupFile is the path of the backup made of the cluster before the Helm release is uninstalled
+	BackupFile string `json:"backupFile"`
+	// Completed lists the names of the steps which have been completed, in the order they were completed
+	Completed []string `json:"completed,omitempty"`
+}
+
+// LoadState reads the State from the file at path. An error wrapping os.ErrNotExist is returned if the file does not
+// exist.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse migration state in %s: %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// Save writes the State to the file at path.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal migration state: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write migration state: %w", err)
+	}
+
+	return nil
+}
+
+// IsCompleted returns true if the step has been completed.
+func (s *State) IsCompleted(step string) bool {
+	for _, completed := range s.Completed {
+		if completed == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Complete records that the step has been completed.
+func (s *State) Complete(step string) {
+	if !s.IsCompleted(step) {
+		s.Completed = append(s.Completed, step)
+	}
+}
@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migration.json")
+
+	_, err := LoadState(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	state := &State{
+		Plan: Plan{
+			Release:             HelmRelease{Name: "cert-manager", Namespace: "cert-manager", Revision: 3},
+			CertManagerVersion:  "v1.11.0",
+			CertManagerReplicas: 2,
+		},
+		BackupFile: "backup.yaml",
+	}
+	state.Complete("cleanup")
+	state.Complete("verify")
+	state.Complete("cleanup")
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+	assert.Equal(t, []string{"cleanup", "verify"}, loaded.Completed)
+	assert.True(t, loaded.IsCompleted("verify"))
+	assert.False(t, loaded.IsCompleted("backup"))
+}
@@ -0,0 +1,323 @@
+// Package patch contains functions for applying user-provided patches to generated Kubernetes manifests, so that
+// fields jsctl does not model can be changed before the manifests are applied.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	goyaml "github.com/go-yaml/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	internalyaml "github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+const (
+	// TypeJSON6902 is the type of patches that contain a list of RFC 6902 JSON patch operations.
+	TypeJSON6902 = "json6902"
+	// TypeStrategicMerge is the type of patches that are a partial object, merged into the target object. Objects of
+	// kinds that are not built into Kubernetes, such as the operator's Installation, are merged using JSON merge
+	// patch semantics, as kubectl does.
+	TypeStrategicMerge = "strategic-merge"
+)
+
+type (
+	// The Patch type is a single patch loaded from a patch file, along with the objects it targets.
+	Patch struct {
+		Source string // The file the patch was loaded from
+		Index  int    // The position of the patch within its file, starting at 1
+		Type   string // Either TypeJSON6902 or TypeStrategicMerge
+		Target Target // The objects the patch applies to
+
+		data []byte
+	}
+
+	// The Target type identifies the objects a patch applies to. Blank fields match any value.
+	Target struct {
+		APIVersion string `json:"apiVersion,omitempty"`
+		Kind       string `json:"kind"`
+		Name       string `json:"name,omitempty"`
+		Namespace  string `json:"namespace,omitempty"`
+	}
+
+	// json6902Document is the format of documents in patch files that contain JSON6902 patches, which do not
+	// identify their target themselves.
+	json6902Document struct {
+		Target *Target          `json:"target"`
+		Patch  *json.RawMessage `json:"patch"`
+	}
+)
+
+// LoadFile reads all patches within the file at the given path. Patch files can contain multiple YAML or JSON
+// documents, each of which is a patch. Strategic merge patches are partial objects that identify their target using
+// their apiVersion, kind, metadata.name and metadata.namespace. JSON6902 patches are written as
+//
+//	target:
+//	  kind: Installation
+//	  name: jetstack-secure
+//	patch:
+//	- op: replace
+//	  path: /spec/certManager/version
+//	  value: v1.11.0
+func LoadFile(path string) ([]*Patch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch file: %w", err)
+	}
+
+	return Load(path, bytes.NewReader(data))
+}
+
+// LoadFiles reads all patches within the files at the given paths, in order.
+func LoadFiles(paths []string) ([]*Patch, error) {
+	var patches []*Patch
+	for _, path := range paths {
+		filePatches, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		patches = append(patches, filePatches...)
+	}
+
+	return patches, nil
+}
+
+// Load reads all patches from r, source is used to identify the patches in errors.
+func Load(source string, r io.Reader) ([]*Patch, error) {
+	var patches []*Patch
+
+	decoder := goyaml.NewDecoder(r)
+	for index := 1; ; index++ {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch %s: failed to read document %d: %w", source, index, err)
+		}
+		if document == nil {
+			continue
+		}
+
+		patch, err := parseDocument(source, index, document)
+		if err != nil {
+			return nil, err
+		}
+
+		patches = append(patches, patch)
+	}
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("patch %s: no patches found", source)
+	}
+
+	return patches, nil
+}
+
+func parseDocument(source string, index int, document interface{}) (*Patch, error) {
+	patch := &Patch{Source: source, Index: index}
+
+	if _, ok := document.([]interface{}); ok {
+		return nil, fmt.Errorf("%s: JSON6902 patches must specify the objects they apply to, write them as a 'target' and a list of operations under 'patch'", patch)
+	}
+
+	documentYAML, err := goyaml.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", patch, err)
+	}
+
+	documentJSON, err := yaml.YAMLToJSON(documentYAML)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", patch, err)
+	}
+
+	var json6902 json6902Document
+	if err := json.Unmarshal(documentJSON, &json6902); err != nil {
+		return nil, fmt.Errorf("%s: %w", patch, err)
+	}
+
+	if json6902.Target != nil || json6902.Patch != nil {
+		if json6902.Target == nil || json6902.Target.Kind == "" {
+			return nil, fmt.Errorf("%s: target.kind must be set", patch)
+		}
+		if json6902.Patch == nil {
+			return nil, fmt.Errorf("%s: patch must contain a list of operations", patch)
+		}
+		if _, err := jsonpatch.DecodePatch(*json6902.Patch); err != nil {
+			return nil, fmt.Errorf("%s: invalid JSON6902 operations: %w", patch, err)
+		}
+
+		patch.Type = TypeJSON6902
+		patch.Target = *json6902.Target
+		patch.data = *json6902.Patch
+
+		return patch, nil
+	}
+
+	var object unstructured.Unstructured
+	if err := object.UnmarshalJSON(documentJSON); err != nil {
+		return nil, fmt.Errorf("%s: strategic merge patches must be partial objects with an apiVersion, kind and metadata.name: %w", patch, err)
+	}
+
+	patch.Type = TypeStrategicMerge
+	patch.Target = Target{
+		APIVersion: object.GetAPIVersion(),
+		Kind:       object.GetKind(),
+		Name:       object.GetName(),
+		Namespace:  object.GetNamespace(),
+	}
+	patch.data = documentJSON
+
+	return patch, nil
+}
+
+func (p *Patch) String() string {
+	return fmt.Sprintf("patch %s (document %d)", p.Source, p.Index)
+}
+
+// Matches returns true if the object is targeted by the patch.
+func (p *Patch) Matches(object *unstructured.Unstructured) bool {
+	target := p.Target
+
+	switch {
+	case target.Kind != object.GetKind():
+		return false
+	case target.APIVersion != "" && target.APIVersion != object.GetAPIVersion():
+		return false
+	case target.Name != "" && target.Name != object.GetName():
+		return false
+	case target.Namespace != "" && target.Namespace != object.GetNamespace():
+		return false
+	default:
+		return true
+	}
+}
+
+// Apply applies each patch, in order, to every object that it targets. Objects are modified in place. An error is
+// returned if a patch cannot be applied to an object, or if a patch does not target any of the objects.
+func Apply(objects []*unstructured.Unstructured, patches []*Patch) error {
+	for _, patch := range patches {
+		var matched bool
+		for _, object := range objects {
+			if !patch.Matches(object) {
+				continue
+			}
+			matched = true
+
+			if err := patch.apply(object); err != nil {
+				return fmt.Errorf("%s failed on %s: %w", patch, describe(object), err)
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%s did not match any objects, expected %s", patch, describeTarget(patch.Target))
+		}
+	}
+
+	return nil
+}
+
+// ApplyYAML applies the patches to the stream of YAML encoded objects read from r, returning the patched stream.
+func ApplyYAML(r io.Reader, patches []*Patch) (io.Reader, error) {
+	if len(patches) == 0 {
+		return r, nil
+	}
+
+	objects, err := internalyaml.Load(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests to patch: %w", err)
+	}
+
+	if err := Apply(objects, patches); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for _, object := range objects {
+		data, err := yaml.Marshal(object.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal patched %s: %w", describe(object), err)
+		}
+
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+
+	return buf, nil
+}
+
+func (p *Patch) apply(object *unstructured.Unstructured) error {
+	original, err := object.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch p.Type {
+	case TypeJSON6902:
+		operations, err := jsonpatch.DecodePatch(p.data)
+		if err != nil {
+			return err
+		}
+
+		patched, err = operations.Apply(original)
+		if err != nil {
+			return err
+		}
+	case TypeStrategicMerge:
+		gvk := schema.FromAPIVersionAndKind(object.GetAPIVersion(), object.GetKind())
+
+		typed, err := scheme.Scheme.New(gvk)
+		if err == nil {
+			patched, err = strategicpatch.StrategicMergePatch(original, p.data, typed)
+		} else {
+			// kinds unknown to the built-in scheme have no patch strategy metadata, so are merged as kubectl does
+			// for custom resources
+			patched, err = jsonpatch.MergePatch(original, p.data)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown patch type %q", p.Type)
+	}
+
+	var result unstructured.Unstructured
+	if err := result.UnmarshalJSON(patched); err != nil {
+		return fmt.Errorf("patched object is invalid: %w", err)
+	}
+
+	object.Object = result.Object
+	return nil
+}
+
+func describe(object *unstructured.Unstructured) string {
+	if object.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", object.GetKind(), object.GetName())
+	}
+
+	return fmt.Sprintf("%s %s/%s", object.GetKind(), object.GetNamespace(), object.GetName())
+}
+
+func describeTarget(target Target) string {
+	description := "kind " + target.Kind
+	if target.Name != "" {
+		description += " named " + target.Name
+	}
+	if target.Namespace != "" {
+		description += " in namespace " + target.Namespace
+	}
+
+	return description
+}
@@ -0,0 +1,199 @@
+package patch_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
+	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: agent
+  namespace: jetstack-secure
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        image: quay.io/jetstack/preflight:v0.1.38
+      - name: sidecar
+        image: example.com/sidecar:v1
+---
+apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  certManager:
+    version: v1.10.0
+  approverPolicy: {}
+`
+
+func TestApplyYAML(t *testing.T) {
+	t.Parallel()
+
+	apply := func(t *testing.T, patchYAML string) ([]*unstructured.Unstructured, error) {
+		patches, err := patch.Load("patch.yaml", strings.NewReader(patchYAML))
+		require.NoError(t, err)
+
+		r, err := patch.ApplyYAML(strings.NewReader(manifests), patches)
+		if err != nil {
+			return nil, err
+		}
+
+		objects, err := yaml.Load(r)
+		require.NoError(t, err)
+
+		return objects, nil
+	}
+
+	t.Run("It should merge containers by name with a strategic merge patch", func(t *testing.T) {
+		objects, err := apply(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: agent
+  namespace: jetstack-secure
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        resources:
+          requests:
+            memory: 200Mi
+`)
+		require.NoError(t, err)
+
+		containers, _, err := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		require.NoError(t, err)
+		require.Len(t, containers, 2)
+
+		agent := containers[0].(map[string]interface{})
+		assert.Equal(t, "quay.io/jetstack/preflight:v0.1.38", agent["image"])
+		memory, _, _ := unstructured.NestedString(agent, "resources", "requests", "memory")
+		assert.Equal(t, "200Mi", memory)
+	})
+
+	t.Run("It should merge custom resources using JSON merge patch semantics", func(t *testing.T) {
+		objects, err := apply(t, `
+apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  componentNamespace: cert-manager
+`)
+		require.NoError(t, err)
+
+		namespace, _, _ := unstructured.NestedString(objects[1].Object, "spec", "componentNamespace")
+		assert.Equal(t, "cert-manager", namespace)
+		version, _, _ := unstructured.NestedString(objects[1].Object, "spec", "certManager", "version")
+		assert.Equal(t, "v1.10.0", version)
+	})
+
+	t.Run("It should apply JSON6902 patches to their target", func(t *testing.T) {
+		objects, err := apply(t, `
+target:
+  kind: Installation
+  name: jetstack-secure
+patch:
+- op: replace
+  path: /spec/certManager/version
+  value: v1.11.0
+- op: remove
+  path: /spec/approverPolicy
+`)
+		require.NoError(t, err)
+
+		version, _, _ := unstructured.NestedString(objects[1].Object, "spec", "certManager", "version")
+		assert.Equal(t, "v1.11.0", version)
+		_, found, _ := unstructured.NestedMap(objects[1].Object, "spec", "approverPolicy")
+		assert.False(t, found)
+	})
+
+	t.Run("It should report which patch failed on which object", func(t *testing.T) {
+		_, err := apply(t, `
+target:
+  kind: Installation
+patch:
+- op: replace
+  path: /spec/trustManager/version
+  value: v0.4.0
+`)
+		assert.ErrorContains(t, err, "patch patch.yaml (document 1) failed on Installation jetstack-secure")
+	})
+
+	t.Run("It should report patches that do not match any objects", func(t *testing.T) {
+		_, err := apply(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: not-the-agent
+`)
+		assert.ErrorContains(t, err, "did not match any objects")
+	})
+
+	t.Run("It should leave manifests untouched without patches", func(t *testing.T) {
+		r, err := patch.ApplyYAML(strings.NewReader(manifests), nil)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, manifests, string(data))
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should load multiple patches from a file", func(t *testing.T) {
+		patches, err := patch.Load("patches.yaml", strings.NewReader(`
+target:
+  kind: Installation
+patch:
+- op: add
+  path: /spec/componentNamespace
+  value: cert-manager
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+  namespace: jetstack-secure
+`))
+		require.NoError(t, err)
+		require.Len(t, patches, 2)
+
+		assert.Equal(t, patch.TypeJSON6902, patches[0].Type)
+		assert.Equal(t, patch.TypeStrategicMerge, patches[1].Type)
+		assert.Equal(t, patch.Target{APIVersion: "v1", Kind: "Secret", Name: "example", Namespace: "jetstack-secure"}, patches[1].Target)
+	})
+
+	t.Run("It should reject JSON6902 patches without a target", func(t *testing.T) {
+		_, err := patch.Load("patch.yaml", strings.NewReader(`
+- op: add
+  path: /spec/componentNamespace
+  value: cert-manager
+`))
+		assert.ErrorContains(t, err, "must specify the objects they apply to")
+	})
+
+	t.Run("It should reject invalid JSON6902 operations", func(t *testing.T) {
+		_, err := patch.Load("patch.yaml", strings.NewReader(`
+target:
+  kind: Installation
+patch:
+  op: add
+`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,305 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// The results of restoring a single resource
+const (
+	ResultCreated = "created"
+	ResultUpdated = "updated"
+	ResultSkipped = "skipped"
+	ResultFailed  = "failed"
+)
+
+// ResourceResult describes what happened when a resource in a backup was restored.
+type ResourceResult struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Result    string `json:"result"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ClusterRestoreReport lists the result of restoring each resource, in the order they were restored.
+type ClusterRestoreReport struct {
+	Resources []ResourceResult `json:"resources"`
+}
+
+// Count returns the number of resources with the given result.
+func (r *ClusterRestoreReport) Count(result string) int {
+	count := 0
+	for _, resource := range r.Resources {
+		if resource.Result == result {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ClusterRestoreOptions configure how a backup is restored.
+type ClusterRestoreOptions struct {
+	// Overwrite, if set, will replace resources that already exist in the cluster rather than skipping them
+	Overwrite bool
+	// SkipMissingTypes, if set, will skip the resources whose types are not served by the cluster rather than
+	// failing before anything is restored
+	SkipMissingTypes bool
+}
+
+// MissingResourceTypesError is returned when a backup contains resources whose types are not served by the cluster,
+// usually because the CRDs of cert-manager or an external issuer are not installed.
+type MissingResourceTypesError struct {
+	Types []string
+}
+
+func (e *MissingResourceTypesError) Error() string {
+	return fmt.Sprintf("the cluster does not serve the resource types: %s, install the CRDs and try again", strings.Join(e.Types, ", "))
+}
+
+// ClusterRestorer creates the resources of a backup in a cluster.
+type ClusterRestorer struct {
+	client    dynamic.Interface
+	discovery discovery.DiscoveryInterface
+	mapper    meta.RESTMapper
+}
+
+// NewClusterRestorer returns a ClusterRestorer for the cluster. The resource types served by the cluster are
+// discovered when it is created, so CRDs installed afterwards will not be seen until Refresh is called.
+func NewClusterRestorer(cfg *rest.Config) (*ClusterRestorer, error) {
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	restorer := &ClusterRestorer{
+		client:    client,
+		discovery: clientSet.Discovery(),
+	}
+	if err := restorer.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return restorer, nil
+}
+
+// Refresh discovers the resource types served by the cluster again.
+func (r *ClusterRestorer) Refresh() error {
+	groupResources, err := restmapper.GetAPIGroupResources(r.discovery)
+	if err != nil {
+		return fmt.Errorf("failed to discover resource types: %w", err)
+	}
+	r.mapper = restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	return nil
+}
+
+// Restore creates the resources in the cluster in dependency order: namespaces, issuers, Secrets, Certificates,
+// policies and then the certificate intent of Ingresses and Gateways, which is applied to those that exist already.
+// Namespaces that resources are in are created if they do not exist. Nothing is created if the
+// cluster does not serve the types of all resources, in which case a MissingResourceTypesError is returned, unless
+// opts.SkipMissingTypes is set. Otherwise, a failure to restore a resource is recorded in the report and the remaining
+// resources are still restored.
+func (r *ClusterRestorer) Restore(ctx context.Context, resources []*unstructured.Unstructured, opts ClusterRestoreOptions) (*ClusterRestoreReport, error) {
+	mappings := make(map[schema.GroupVersionKind]*meta.RESTMapping)
+	var missing []string
+	for _, resource := range resources {
+		gvk := resource.GroupVersionKind()
+		if gvk == shimIntentGVK {
+			// intents are applied to the resources they were recorded from, so those must be served instead
+			targetGVK, err := shimIntentTargetGVK(resource)
+			if err != nil {
+				return nil, err
+			}
+			gvk = targetGVK
+		}
+		if _, ok := mappings[gvk]; ok {
+			continue
+		}
+
+		mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", gvk.Kind, gvk.GroupVersion()))
+			mappings[gvk] = nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find resource type of %s: %w", gvk, err)
+		}
+		mappings[gvk] = mapping
+	}
+	if len(missing) > 0 && !opts.SkipMissingTypes {
+		sort.Strings(missing)
+		return nil, &MissingResourceTypesError{Types: missing}
+	}
+
+	namespaceGVK := corev1.SchemeGroupVersion.WithKind("Namespace")
+	if _, ok := mappings[namespaceGVK]; !ok {
+		mapping, err := r.mapper.RESTMapping(namespaceGVK.GroupKind(), namespaceGVK.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find resource type of namespaces: %w", err)
+		}
+		mappings[namespaceGVK] = mapping
+	}
+
+	ordered, generatedNamespaces := withNamespaces(resources, mappings)
+
+	report := &ClusterRestoreReport{}
+	for _, resource := range ordered {
+		resourceOpts := opts
+		if resource.GroupVersionKind() == namespaceGVK && generatedNamespaces[resource.GetName()] {
+			// namespaces which are not in the backup must not replace existing namespaces
+			resourceOpts.Overwrite = false
+		}
+
+		result := ResourceResult{
+			Kind:      resource.GetKind(),
+			Namespace: resource.GetNamespace(),
+			Name:      resource.GetName(),
+		}
+		gvk := resource.GroupVersionKind()
+		if gvk == shimIntentGVK {
+			gvk, _ = shimIntentTargetGVK(resource)
+		}
+		switch {
+		case mappings[gvk] == nil:
+			result.Result, result.Message = ResultSkipped, fmt.Sprintf("%s (%s) is not served by the cluster", gvk.Kind, gvk.GroupVersion())
+		case resource.GroupVersionKind() == shimIntentGVK:
+			result.Result, result.Message = r.restoreShimIntent(ctx, mappings[gvk], resource, resourceOpts)
+		default:
+			result.Result, result.Message = r.restoreResource(ctx, mappings[gvk], resource, resourceOpts)
+		}
+		report.Resources = append(report.Resources, result)
+	}
+
+	return report, nil
+}
+
+func (r *ClusterRestorer) restoreResource(ctx context.Context, mapping *meta.RESTMapping, resource *unstructured.Unstructured, opts ClusterRestoreOptions) (string, string) {
+	var client dynamic.ResourceInterface = r.client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		client = r.client.Resource(mapping.Resource).Namespace(resource.GetNamespace())
+	}
+
+	object := cleanObject(resource)
+
+	_, err := client.Create(ctx, object, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		return ResultCreated, ""
+	case !apierrors.IsAlreadyExists(err):
+		return ResultFailed, err.Error()
+	case !opts.Overwrite:
+		return ResultSkipped, "already exists"
+	}
+
+	existing, err := client.Get(ctx, object.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return ResultFailed, err.Error()
+	}
+	object.SetResourceVersion(existing.GetResourceVersion())
+
+	if _, err := client.Update(ctx, object, metav1.UpdateOptions{}); err != nil {
+		return ResultFailed, err.Error()
+	}
+
+	return ResultUpdated, ""
+}
+
+// cleanObject returns a copy of the resource without the fields set by the API server of the backed up cluster,
+// which would stop it being created. Backups made with --format-resources do not have them. Owner references are
+// removed too, as they refer to the UIDs of the backed up cluster and would get the resource garbage collected.
+func cleanObject(resource *unstructured.Unstructured) *unstructured.Unstructured {
+	object := resource.DeepCopy()
+	object.SetResourceVersion("")
+	object.SetUID("")
+	object.SetOwnerReferences(nil)
+	object.SetCreationTimestamp(metav1.Time{})
+	object.SetManagedFields(nil)
+	unstructured.RemoveNestedField(object.Object, "status")
+
+	return object
+}
+
+// withNamespaces returns the resources sorted in dependency order, preceded by the namespaces that the namespaced
+// resources are in, unless the backup contains those namespaces already. The names of the namespaces which were added
+// are returned too.
+func withNamespaces(resources []*unstructured.Unstructured, mappings map[schema.GroupVersionKind]*meta.RESTMapping) ([]*unstructured.Unstructured, map[string]bool) {
+	included := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.GroupVersionKind().GroupKind() == (schema.GroupKind{Kind: "Namespace"}) {
+			included[resource.GetName()] = true
+		}
+	}
+
+	var namespaces []string
+	for _, resource := range resources {
+		namespace := resource.GetNamespace()
+		mapping := mappings[resource.GroupVersionKind()]
+		if namespace == "" || included[namespace] || mapping == nil || mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			continue
+		}
+		included[namespace] = true
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	generated := make(map[string]bool, len(namespaces))
+	ordered := make([]*unstructured.Unstructured, 0, len(namespaces)+len(resources))
+	for _, namespace := range namespaces {
+		generated[namespace] = true
+		object := &unstructured.Unstructured{}
+		object.SetAPIVersion("v1")
+		object.SetKind("Namespace")
+		object.SetName(namespace)
+		ordered = append(ordered, object)
+	}
+	ordered = append(ordered, resources...)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return restoreOrder(ordered[i]) < restoreOrder(ordered[j])
+	})
+
+	return ordered, generated
+}
+
+// restoreOrder ranks resources so that those referenced by others are restored first.
+func restoreOrder(resource *unstructured.Unstructured) int {
+	gvk := resource.GroupVersionKind()
+	switch {
+	case gvk.Group == "" && gvk.Kind == "Namespace":
+		return 0
+	case strings.HasSuffix(gvk.Kind, "Issuer"):
+		return 1
+	case gvk.Group == "" && gvk.Kind == "Secret":
+		return 2
+	case gvk.Group == "cert-manager.io" && gvk.Kind == "Certificate":
+		return 3
+	case gvk.Group == "policy.cert-manager.io":
+		return 4
+	case gvk == shimIntentGVK:
+		return 6
+	default:
+		return 5
+	}
+}
@@ -0,0 +1,145 @@
+package restore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestClusterRestorer_Restore(t *testing.T) {
+	newObject := func(apiVersion, kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		object.SetAPIVersion(apiVersion)
+		object.SetKind(kind)
+		object.SetNamespace(namespace)
+		object.SetName(name)
+		return object
+	}
+
+	// resources are in the opposite of dependency order, as the order of a backup cannot be relied on
+	backup := []*unstructured.Unstructured{
+		newObject("policy.cert-manager.io/v1alpha1", "CertificateRequestPolicy", "", "policy", map[string]interface{}{}),
+		newObject("cert-manager.io/v1", "Certificate", "jetstack-secure", "example-com", map[string]interface{}{"secretName": "example-com-tls"}),
+		newObject("v1", "Secret", "jetstack-secure", "example-com-tls", nil),
+		newObject("cert-manager.io/v1", "Issuer", "jetstack-secure", "ca-issuer", map[string]interface{}{"ca": map[string]interface{}{"secretName": "ca-key-pair"}}),
+	}
+	backup[0].Object["status"] = map[string]interface{}{"conditions": []interface{}{}}
+	backup[1].SetResourceVersion("123")
+	backup[2].SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "cert-manager.io/v1", Kind: "Certificate", Name: "example-com", UID: "backed-up-cluster-uid"},
+	})
+
+	newMapper := func(crds bool) meta.RESTMapper {
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+		if crds {
+			mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "policy.cert-manager.io", Version: "v1alpha1", Kind: "CertificateRequestPolicy"}, meta.RESTScopeRoot)
+		}
+		return mapper
+	}
+
+	t.Run("fails without creating anything if CRDs are missing", func(t *testing.T) {
+		client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		restorer := &ClusterRestorer{client: client, mapper: newMapper(false)}
+
+		_, err := restorer.Restore(context.Background(), backup, ClusterRestoreOptions{})
+		require.EqualError(t, err, "the cluster does not serve the resource types: Certificate (cert-manager.io/v1), CertificateRequestPolicy (policy.cert-manager.io/v1alpha1), Issuer (cert-manager.io/v1), install the CRDs and try again")
+		assert.Empty(t, client.Actions())
+	})
+
+	t.Run("skips resources of missing types if asked to", func(t *testing.T) {
+		client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		restorer := &ClusterRestorer{client: client, mapper: newMapper(false)}
+
+		report, err := restorer.Restore(context.Background(), backup, ClusterRestoreOptions{SkipMissingTypes: true})
+		require.NoError(t, err)
+		assert.Equal(t, []ResourceResult{
+			{Kind: "Namespace", Name: "jetstack-secure", Result: ResultCreated},
+			{Kind: "Issuer", Namespace: "jetstack-secure", Name: "ca-issuer", Result: ResultSkipped, Message: "Issuer (cert-manager.io/v1) is not served by the cluster"},
+			{Kind: "Secret", Namespace: "jetstack-secure", Name: "example-com-tls", Result: ResultCreated},
+			{Kind: "Certificate", Namespace: "jetstack-secure", Name: "example-com", Result: ResultSkipped, Message: "Certificate (cert-manager.io/v1) is not served by the cluster"},
+			{Kind: "CertificateRequestPolicy", Name: "policy", Result: ResultSkipped, Message: "CertificateRequestPolicy (policy.cert-manager.io/v1alpha1) is not served by the cluster"},
+		}, report.Resources)
+	})
+
+	existingIssuer := newObject("cert-manager.io/v1", "Issuer", "jetstack-secure", "ca-issuer", map[string]interface{}{"selfSigned": map[string]interface{}{}})
+	existingIssuer.SetResourceVersion("1")
+	existingNamespace := newObject("v1", "Namespace", "", "jetstack-secure", nil)
+	existingNamespace.SetLabels(map[string]string{"team": "platform"})
+
+	testCases := map[string]struct {
+		overwrite       bool
+		expectedResults []ResourceResult
+		expectedIssuer  map[string]interface{}
+	}{
+		"skips existing resources": {
+			expectedResults: []ResourceResult{
+				{Kind: "Namespace", Name: "jetstack-secure", Result: ResultSkipped, Message: "already exists"},
+				{Kind: "Issuer", Namespace: "jetstack-secure", Name: "ca-issuer", Result: ResultSkipped, Message: "already exists"},
+				{Kind: "Secret", Namespace: "jetstack-secure", Name: "example-com-tls", Result: ResultCreated},
+				{Kind: "Certificate", Namespace: "jetstack-secure", Name: "example-com", Result: ResultCreated},
+				{Kind: "CertificateRequestPolicy", Name: "policy", Result: ResultCreated},
+			},
+			expectedIssuer: map[string]interface{}{"selfSigned": map[string]interface{}{}},
+		},
+		"overwrites existing resources": {
+			overwrite: true,
+			expectedResults: []ResourceResult{
+				{Kind: "Namespace", Name: "jetstack-secure", Result: ResultSkipped, Message: "already exists"},
+				{Kind: "Issuer", Namespace: "jetstack-secure", Name: "ca-issuer", Result: ResultUpdated},
+				{Kind: "Secret", Namespace: "jetstack-secure", Name: "example-com-tls", Result: ResultCreated},
+				{Kind: "Certificate", Namespace: "jetstack-secure", Name: "example-com", Result: ResultCreated},
+				{Kind: "CertificateRequestPolicy", Name: "policy", Result: ResultCreated},
+			},
+			expectedIssuer: map[string]interface{}{"ca": map[string]interface{}{"secretName": "ca-key-pair"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existingIssuer.DeepCopy(), existingNamespace.DeepCopy())
+			restorer := &ClusterRestorer{client: client, mapper: newMapper(true)}
+
+			report, err := restorer.Restore(context.Background(), backup, ClusterRestoreOptions{Overwrite: tc.overwrite})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResults, report.Resources)
+			assert.Equal(t, 3, report.Count(ResultCreated))
+
+			issuer, err := client.Resource(schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}).
+				Namespace("jetstack-secure").Get(context.Background(), "ca-issuer", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedIssuer, issuer.Object["spec"])
+
+			namespace, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).
+				Get(context.Background(), "jetstack-secure", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"team": "platform"}, namespace.GetLabels())
+
+			certificate, err := client.Resource(schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}).
+				Namespace("jetstack-secure").Get(context.Background(), "example-com", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.NotEqual(t, "123", certificate.GetResourceVersion())
+
+			secret, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "secrets"}).
+				Namespace("jetstack-secure").Get(context.Background(), "example-com-tls", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, secret.GetOwnerReferences())
+
+			policy, err := client.Resource(schema.GroupVersionResource{Group: "policy.cert-manager.io", Version: "v1alpha1", Resource: "certificaterequestpolicies"}).
+				Get(context.Background(), "policy", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.NotContains(t, policy.Object, "status")
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package restore
+
+import (
+	"fmt"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// legacyVersions are the cert-manager.io API versions before v1 that resources can be converted from
+var legacyVersions = map[string]bool{
+	"v1alpha2": true,
+	"v1alpha3": true,
+	"v1beta1":  true,
+}
+
+// convertibleKinds are the kinds of cert-manager.io resources that can be converted to v1
+var convertibleKinds = map[string]bool{
+	"Issuer":        true,
+	"ClusterIssuer": true,
+	"Certificate":   true,
+}
+
+// legacyKeyAlgorithms and legacyKeyEncodings map the private key settings of v1alpha2 and v1alpha3 Certificates to
+// their v1 values. Unknown values are kept as they are, and will fail validation when applied.
+var (
+	legacyKeyAlgorithms = map[string]cmapi.PrivateKeyAlgorithm{
+		"rsa":   cmapi.RSAKeyAlgorithm,
+		"ecdsa": cmapi.ECDSAKeyAlgorithm,
+	}
+	legacyKeyEncodings = map[string]cmapi.PrivateKeyEncoding{
+		"pkcs1": cmapi.PKCS1,
+		"pkcs8": cmapi.PKCS8,
+	}
+)
+
+// IsConvertibleToV1 returns true if resources of the type are cert-manager.io Issuers, ClusterIssuers or Certificates
+// of an API version before v1 that ConvertToV1 can convert.
+func IsConvertibleToV1(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == cmapi.SchemeGroupVersion.Group && legacyVersions[gvk.Version] && convertibleKinds[gvk.Kind]
+}
+
+// ConvertToV1 returns a copy of a v1alpha2, v1alpha3 or v1beta1 cert-manager.io Issuer, ClusterIssuer or Certificate
+// converted to cert-manager.io/v1, in the same way as the cert-manager conversion webhook would convert it. The
+// fields of Issuers are unchanged between these versions, while the fields of Certificates that were renamed or moved
+// are converted.
+func ConvertToV1(resource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	gvk := resource.GroupVersionKind()
+	if !IsConvertibleToV1(gvk) {
+		return nil, fmt.Errorf("cannot convert %s %s to cert-manager.io/v1", gvk.Kind, gvk.GroupVersion())
+	}
+
+	converted := resource.DeepCopy()
+	converted.SetAPIVersion(cmapi.SchemeGroupVersion.String())
+
+	if gvk.Kind != "Certificate" {
+		return converted, nil
+	}
+
+	spec, ok, err := unstructured.NestedMap(converted.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec of Certificate %s: %w", resource.GetName(), err)
+	}
+	if !ok {
+		return converted, nil
+	}
+
+	// the organization field was moved to the subject in v1alpha3
+	if organization, ok := spec["organization"]; ok {
+		delete(spec, "organization")
+		if !isEmpty(organization) {
+			subject, _ := spec["subject"].(map[string]interface{})
+			if subject == nil {
+				subject = map[string]interface{}{}
+			}
+			subject["organizations"] = organization
+			spec["subject"] = subject
+		}
+	}
+
+	// the private key settings were moved to privateKey in v1beta1, with upper case values
+	algorithm, encoding, size := spec["keyAlgorithm"], spec["keyEncoding"], spec["keySize"]
+	delete(spec, "keyAlgorithm")
+	delete(spec, "keyEncoding")
+	delete(spec, "keySize")
+	if !isEmpty(algorithm) || !isEmpty(encoding) || !isEmpty(size) {
+		privateKey, _ := spec["privateKey"].(map[string]interface{})
+		if privateKey == nil {
+			privateKey = map[string]interface{}{}
+		}
+		if !isEmpty(algorithm) {
+			privateKey["algorithm"] = convertLegacyValue(algorithm, func(value string) (string, bool) {
+				v, ok := legacyKeyAlgorithms[strings.ToLower(value)]
+				return string(v), ok
+			})
+		}
+		if !isEmpty(encoding) {
+			privateKey["encoding"] = convertLegacyValue(encoding, func(value string) (string, bool) {
+				v, ok := legacyKeyEncodings[strings.ToLower(value)]
+				return string(v), ok
+			})
+		}
+		if !isEmpty(size) {
+			privateKey["size"] = size
+		}
+		spec["privateKey"] = privateKey
+	}
+
+	// the subject alternative name fields were renamed in v1
+	for legacy, current := range map[string]string{"uriSANs": "uris", "emailSANs": "emailAddresses"} {
+		if value, ok := spec[legacy]; ok {
+			delete(spec, legacy)
+			if !isEmpty(value) {
+				spec[current] = value
+			}
+		}
+	}
+
+	if err := unstructured.SetNestedMap(converted.Object, spec, "spec"); err != nil {
+		return nil, fmt.Errorf("failed to set spec of Certificate %s: %w", resource.GetName(), err)
+	}
+
+	return converted, nil
+}
+
+// ConvertLegacyResources returns the resources with those that IsConvertibleToV1 converted to cert-manager.io/v1,
+// and the names of the converted resources in the form kind/name. Other resources are returned unchanged.
+func ConvertLegacyResources(resources []*unstructured.Unstructured) ([]*unstructured.Unstructured, []string, error) {
+	converted := make([]*unstructured.Unstructured, 0, len(resources))
+	var names []string
+	for _, resource := range resources {
+		if !IsConvertibleToV1(resource.GroupVersionKind()) {
+			converted = append(converted, resource)
+			continue
+		}
+
+		resource, err := ConvertToV1(resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		converted = append(converted, resource)
+		names = append(names, fmt.Sprintf("%s/%s", resource.GetKind(), resource.GetName()))
+	}
+
+	return converted, names, nil
+}
+
+func convertLegacyValue(value interface{}, convert func(string) (string, bool)) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if converted, ok := convert(s); ok {
+		return converted
+	}
+
+	return s
+}
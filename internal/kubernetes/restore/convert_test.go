@@ -0,0 +1,75 @@
+package restore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+)
+
+func TestConvertLegacyResources(t *testing.T) {
+	resources, err := LoadBackupFile("fixtures/legacy-backup.yaml")
+	require.NoError(t, err)
+
+	converted, names, err := ConvertLegacyResources(resources)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ClusterIssuer/outdated-cm-issuer",
+		"Issuer/cm-issuer-sample",
+		"Certificate/example-com",
+		"Certificate/example-org",
+		"Certificate/example-net",
+	}, names)
+
+	clusterBackup := make(backup.ClusterBackup, 0, len(converted))
+	for _, resource := range converted {
+		clusterBackup = append(clusterBackup, resource.Object)
+	}
+	data, err := clusterBackup.ToYAML()
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("fixtures/legacy-backup-converted.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+
+	// the resources in the backup are not modified
+	assert.Equal(t, "cert-manager.io/v1alpha2", resources[2].GetAPIVersion())
+	_, ok, _ := unstructured.NestedString(resources[2].Object, "spec", "keyAlgorithm")
+	assert.True(t, ok)
+}
+
+func TestIsConvertibleToV1(t *testing.T) {
+	testCases := map[string]struct {
+		gvk      schema.GroupVersionKind
+		expected bool
+	}{
+		"v1alpha2 Certificate": {
+			gvk:      schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1alpha2", Kind: "Certificate"},
+			expected: true,
+		},
+		"v1beta1 ClusterIssuer": {
+			gvk:      schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1beta1", Kind: "ClusterIssuer"},
+			expected: true,
+		},
+		"v1 Issuer": {
+			gvk: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"},
+		},
+		"v1alpha2 CertificateRequest": {
+			gvk: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1alpha2", Kind: "CertificateRequest"},
+		},
+		"external issuer": {
+			gvk: schema.GroupVersionKind{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsConvertibleToV1(tc.gvk))
+		})
+	}
+}
@@ -13,7 +13,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	sigsyaml "sigs.k8s.io/yaml"
 
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
 )
 
@@ -25,61 +28,35 @@ type RestoredIssuers struct {
 	VenafiIssuers             []*veiv1alpha1.VenafiIssuer
 	VenafiClusterIssuers      []*veiv1alpha1.VenafiClusterIssuer
 
+	// ExternalIssuers are the issuers of the supported external issuer types, which the operator does not manage.
+	// They are restored as they are once their controllers are installed.
+	ExternalIssuers []*unstructured.Unstructured
+
 	// Missed is a list of issuers that are not supported for restore.
 	Missed []string
 
 	// NeedsConversion is a list of issuers that are not supported for restore
-	// but could be if converted
+	// but could be if converted. Issuers of the cert-manager.io API versions
+	// that ConvertToV1 supports are converted instead.
 	NeedsConversion []string
 }
 
 func ExtractOperatorManageableIssuersFromBackupFile(backupFilePath string) (*RestoredIssuers, error) {
 	var restoredIssuers RestoredIssuers
 
-	file, err := os.Open(backupFilePath)
+	resources, err := LoadBackupFile(backupFilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open backup file: %w", err)
+		return nil, err
 	}
 
-	var resources []*unstructured.Unstructured
-	// handle both JSON and YAML backup formats
-	// JSON backups are formatted as a Kubernetes v1 List, this is so that the
-	// file can be applied to the cluster using kubectl apply -f <file>. This
-	// does however make the unmarsalling of the file marginally more
-	// complicated as we see here.
-	if strings.HasSuffix(strings.ToLower(backupFilePath), ".json") {
-		rawJSON, err := io.ReadAll(file)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read backup file: %w", err)
-		}
-
-		var list corev1.List
-		err = json.Unmarshal(rawJSON, &list)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal backup file as corev1.List: %w", err)
-		}
-
-		for _, item := range list.Items {
-			decoder := json.NewDecoder(bytes.NewReader(item.Raw))
-
-			var parsedItem unstructured.Unstructured
-			err := decoder.Decode(&parsedItem)
+	for _, resource := range resources {
+		if IsConvertibleToV1(resource.GroupVersionKind()) {
+			resource, err = ConvertToV1(resource)
 			if err != nil {
-				return nil, fmt.Errorf("failed to decode item from backup file: %w", err)
+				return nil, err
 			}
-
-			resources = append(resources, &parsedItem)
 		}
-	} else if strings.HasSuffix(strings.ToLower(backupFilePath), ".yaml") {
-		resources, err = yaml.Load(file)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load backup file: %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("unsupported backup format for: %q, must be JSON or YAML file", backupFilePath)
-	}
 
-	for _, resource := range resources {
 		switch resource.GroupVersionKind().Group {
 		case "cert-manager.io":
 			if resource.GetAPIVersion() != "cert-manager.io/v1" {
@@ -128,7 +105,10 @@ func ExtractOperatorManageableIssuersFromBackupFile(backupFilePath string) (*Res
 				restoredIssuers.VenafiClusterIssuers = append(restoredIssuers.VenafiClusterIssuers, issuer)
 			}
 		default:
-			if strings.Contains(resource.GroupVersionKind().Kind, "Issuer") {
+			switch {
+			case isExternalIssuer(resource.GroupVersionKind()):
+				restoredIssuers.ExternalIssuers = append(restoredIssuers.ExternalIssuers, resource)
+			case strings.Contains(resource.GroupVersionKind().Kind, "Issuer"):
 				restoredIssuers.Missed = append(restoredIssuers.Missed, fmt.Sprintf("%s/%s", resource.GetKind(), resource.GetName()))
 			}
 		}
@@ -136,3 +116,95 @@ func ExtractOperatorManageableIssuersFromBackupFile(backupFilePath string) (*Res
 
 	return &restoredIssuers, nil
 }
+
+// isExternalIssuer returns true if the resource is of one of the supported external issuer types that the operator
+// does not manage.
+func isExternalIssuer(gvk schema.GroupVersionKind) bool {
+	issuer, ok := issuerType(gvk)
+	if !ok {
+		return false
+	}
+	switch issuer {
+	case clients.CertManagerIssuer, clients.CertManagerClusterIssuer, clients.VenafiEnhancedIssuer, clients.VenafiEnhancedClusterIssuer:
+		return false
+	}
+
+	return true
+}
+
+// issuerType returns the supported issuer type of the resource, if it is an issuer.
+func issuerType(gvk schema.GroupVersionKind) (clients.AnyIssuer, bool) {
+	for _, issuer := range clients.AllIssuersList {
+		if gvk.Group == issuer.Group() && gvk.Kind == issuer.Kind() {
+			return issuer, true
+		}
+	}
+
+	return 0, false
+}
+
+// ManifestsYAML returns the resources as a multi-document YAML manifest, without the fields set by the API server of
+// the backed up cluster, so that they can be applied to another cluster.
+func ManifestsYAML(resources []*unstructured.Unstructured) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, resource := range resources {
+		data, err := sigsyaml.Marshal(cleanObject(resource).Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", resource.GetKind(), resource.GetName(), err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// LoadBackupFile reads the resources in a backup file written in either of the formats of a ClusterBackup, chosen
+// by the file's extension.
+func LoadBackupFile(backupFilePath string) ([]*unstructured.Unstructured, error) {
+	file, err := os.Open(backupFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	var resources []*unstructured.Unstructured
+	// handle both JSON and YAML backup formats
+	// JSON backups are formatted as a Kubernetes v1 List, this is so that the
+	// file can be applied to the cluster using kubectl apply -f <file>. This
+	// does however make the unmarsalling of the file marginally more
+	// complicated as we see here.
+	if strings.HasSuffix(strings.ToLower(backupFilePath), ".json") {
+		rawJSON, err := io.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup file: %w", err)
+		}
+
+		var list corev1.List
+		err = json.Unmarshal(rawJSON, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal backup file as corev1.List: %w", err)
+		}
+
+		for _, item := range list.Items {
+			decoder := json.NewDecoder(bytes.NewReader(item.Raw))
+
+			var parsedItem unstructured.Unstructured
+			err := decoder.Decode(&parsedItem)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode item from backup file: %w", err)
+			}
+
+			resources = append(resources, &parsedItem)
+		}
+	} else if strings.HasSuffix(strings.ToLower(backupFilePath), ".yaml") {
+		resources, err = yaml.Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load backup file: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("unsupported backup format for: %q, must be JSON or YAML file", backupFilePath)
+	}
+
+	return resources, nil
+}
@@ -1,6 +1,8 @@
 package restore
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 
 	certmanageracmev1 "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
@@ -9,6 +11,7 @@ import (
 	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestExtractOperatorManageableIssuersFromBackupFile(t *testing.T) {
@@ -24,13 +27,6 @@ func TestExtractOperatorManageableIssuersFromBackupFile(t *testing.T) {
 	}
 
 	expectedIssuers := &RestoredIssuers{
-		Missed: []string{
-			"AWSPCAIssuer/pca-sample",
-			"GoogleCASIssuer/googlecasissuer-sample",
-		},
-		NeedsConversion: []string{
-			"ClusterIssuer/outdated-cm-issuer",
-		},
 		CertManagerIssuers: []*cmapi.Issuer{
 			{
 				TypeMeta: metav1.TypeMeta{
@@ -73,6 +69,29 @@ func TestExtractOperatorManageableIssuersFromBackupFile(t *testing.T) {
 					},
 				},
 			},
+			// converted from cert-manager.io/v1beta1
+			{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ClusterIssuer",
+					APIVersion: "cert-manager.io/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "outdated-cm-issuer",
+				},
+				Spec: cmapi.IssuerSpec{
+					IssuerConfig: cmapi.IssuerConfig{
+						ACME: &certmanageracmev1.ACMEIssuer{
+							Email:  "dummy-email@example.com",
+							Server: "https://",
+							PrivateKey: certmanagermetav1.SecretKeySelector{
+								LocalObjectReference: certmanagermetav1.LocalObjectReference{
+									Name: "example",
+								},
+							},
+						},
+					},
+				},
+			},
 		},
 		VenafiIssuers: []*veiv1alpha1.VenafiIssuer{
 			{
@@ -115,7 +134,53 @@ func TestExtractOperatorManageableIssuersFromBackupFile(t *testing.T) {
 			issuers, err := ExtractOperatorManageableIssuersFromBackupFile(testCase.backupFilePath)
 			require.NoError(t, err)
 
+			// external issuers are kept as they are in the backup
+			var externalIssuers []string
+			for _, issuer := range issuers.ExternalIssuers {
+				externalIssuers = append(externalIssuers, fmt.Sprintf("%s/%s/%s", issuer.GetAPIVersion(), issuer.GetKind(), issuer.GetName()))
+			}
+			require.Equal(t, []string{
+				"awspca.cert-manager.io/v1beta1/AWSPCAIssuer/pca-sample",
+				"cas-issuer.jetstack.io/v1beta1/GoogleCASIssuer/googlecasissuer-sample",
+			}, externalIssuers)
+			issuers.ExternalIssuers = nil
+
+			// the order of the fixtures differs between formats
+			sort.Slice(issuers.CertManagerClusterIssuers, func(i, j int) bool {
+				return issuers.CertManagerClusterIssuers[i].Name < issuers.CertManagerClusterIssuers[j].Name
+			})
+
 			require.Equal(t, expectedIssuers, issuers)
 		})
 	}
 }
+
+func TestManifestsYAML(t *testing.T) {
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "awspca.cert-manager.io/v1beta1",
+		"kind":       "AWSPCAIssuer",
+		"metadata": map[string]interface{}{
+			"name":              "pca-sample",
+			"namespace":         "jetstack-secure",
+			"creationTimestamp": "2023-02-03T04:05:06Z",
+			"resourceVersion":   "123",
+			"uid":               "6d5e1d6c-0b8a-4e1d-9f33-4cbd0b0bb6b1",
+		},
+		"spec":   map[string]interface{}{"arn": "abc"},
+		"status": map[string]interface{}{},
+	}}
+
+	data, err := ManifestsYAML([]*unstructured.Unstructured{issuer, issuer})
+	require.NoError(t, err)
+
+	manifest := `---
+apiVersion: awspca.cert-manager.io/v1beta1
+kind: AWSPCAIssuer
+metadata:
+  name: pca-sample
+  namespace: jetstack-secure
+spec:
+  arn: abc
+`
+	require.Equal(t, manifest+manifest, string(data))
+}
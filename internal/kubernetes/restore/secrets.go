@@ -0,0 +1,51 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+)
+
+// ErrEncryptedSecrets is returned when a backup contains encrypted Secrets but no passphrase was given to decrypt
+// them.
+var ErrEncryptedSecrets = errors.New("backup contains encrypted secrets, a passphrase is needed to decrypt them")
+
+// DecryptSecrets returns the resources with each EncryptedSecret replaced by the Secret that was encrypted, using
+// the passphrase the backup was made with. Other resources are returned unchanged.
+func DecryptSecrets(resources []*unstructured.Unstructured, passphrase []byte) ([]*unstructured.Unstructured, error) {
+	decrypter := backup.NewDecrypter(passphrase)
+
+	decrypted := make([]*unstructured.Unstructured, 0, len(resources))
+	for _, resource := range resources {
+		if resource.GetAPIVersion() != backup.EncryptedSecretAPIVersion || resource.GetKind() != backup.EncryptedSecretKind {
+			decrypted = append(decrypted, resource)
+			continue
+		}
+
+		if len(passphrase) == 0 {
+			return nil, ErrEncryptedSecrets
+		}
+
+		var encrypted backup.EncryptedSecret
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object, &encrypted); err != nil {
+			return nil, fmt.Errorf("failed to convert unstructured to EncryptedSecret: %w", err)
+		}
+
+		secret, err := decrypter.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s/%s: %w", resource.GetNamespace(), resource.GetName(), err)
+		}
+
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert secret %s/%s to unstructured: %w", resource.GetNamespace(), resource.GetName(), err)
+		}
+		decrypted = append(decrypted, &unstructured.Unstructured{Object: object})
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,52 @@
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+)
+
+func TestDecryptSecrets(t *testing.T) {
+	secret := corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-key-pair", Namespace: "jetstack-secure"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.key": []byte("key")},
+	}
+
+	encrypter, err := backup.NewEncrypter([]byte("passphrase"))
+	require.NoError(t, err)
+	encrypted, err := encrypter.Encrypt(secret)
+	require.NoError(t, err)
+	encryptedObject, err := runtime.DefaultUnstructuredConverter.ToUnstructured(encrypted)
+	require.NoError(t, err)
+
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Issuer",
+		"metadata":   map[string]interface{}{"name": "cm-issuer-sample", "namespace": "jetstack-secure"},
+	}}
+	resources := []*unstructured.Unstructured{issuer, {Object: encryptedObject}}
+
+	_, err = DecryptSecrets(resources, nil)
+	assert.ErrorIs(t, err, ErrEncryptedSecrets)
+
+	_, err = DecryptSecrets(resources, []byte("wrong"))
+	assert.ErrorIs(t, err, backup.ErrDecryptionFailed)
+
+	decrypted, err := DecryptSecrets(resources, []byte("passphrase"))
+	require.NoError(t, err)
+	require.Len(t, decrypted, 2)
+	assert.Equal(t, issuer, decrypted[0])
+
+	var decryptedSecret corev1.Secret
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(decrypted[1].Object, &decryptedSecret))
+	assert.Equal(t, secret, decryptedSecret)
+}
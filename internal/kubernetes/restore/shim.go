@@ -0,0 +1,184 @@
+package restore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+)
+
+var shimIntentGVK = schema.FromAPIVersionAndKind(backup.ShimIntentAPIVersion, backup.ShimIntentKind)
+
+// shimIntentTargetGVK returns the type of the resource a ShimIntent is applied to.
+func shimIntentTargetGVK(resource *unstructured.Unstructured) (schema.GroupVersionKind, error) {
+	apiVersion, _, _ := unstructured.NestedString(resource.Object, "target", "apiVersion")
+	kind, _, _ := unstructured.NestedString(resource.Object, "target", "kind")
+	if apiVersion == "" || kind == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("%s %s/%s has no target", backup.ShimIntentKind, resource.GetNamespace(), resource.GetName())
+	}
+
+	return schema.FromAPIVersionAndKind(apiVersion, kind), nil
+}
+
+// restoreShimIntent sets the cert-manager annotations and TLS configuration of an existing Ingress or Gateway to
+// those in the intent. Unless overwriting, the target is left alone if it has different annotations or TLS
+// configuration already. The TLS configuration of Gateway listeners which are not in the intent is not changed.
+func (r *ClusterRestorer) restoreShimIntent(ctx context.Context, mapping *meta.RESTMapping, resource *unstructured.Unstructured, opts ClusterRestoreOptions) (string, string) {
+	var intent backup.ShimIntent
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object, &intent); err != nil {
+		return ResultFailed, fmt.Sprintf("invalid %s: %s", backup.ShimIntentKind, err)
+	}
+
+	client := r.client.Resource(mapping.Resource).Namespace(intent.Namespace)
+	target, err := client.Get(ctx, intent.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ResultSkipped, fmt.Sprintf("%s does not exist, restore it and run restore again", intent.Target.Kind)
+	}
+	if err != nil {
+		return ResultFailed, err.Error()
+	}
+
+	changes, conflicts, err := applyShimIntent(target, &intent)
+	if err != nil {
+		return ResultFailed, err.Error()
+	}
+	if !changes {
+		return ResultSkipped, "already applied"
+	}
+	if conflicts && !opts.Overwrite {
+		return ResultSkipped, fmt.Sprintf("%s has different cert-manager annotations or TLS configuration", intent.Target.Kind)
+	}
+
+	if _, err := client.Update(ctx, target, metav1.UpdateOptions{}); err != nil {
+		return ResultFailed, err.Error()
+	}
+
+	return ResultUpdated, ""
+}
+
+// applyShimIntent sets the annotations and TLS configuration of the target to those in the intent. It returns
+// whether the target was changed, and whether any of the values replaced were set to something else before.
+func applyShimIntent(target *unstructured.Unstructured, intent *backup.ShimIntent) (bool, bool, error) {
+	var changes, conflicts bool
+	set := func(current, desired interface{}) {
+		if reflect.DeepEqual(current, desired) {
+			return
+		}
+		changes = true
+		if !isEmpty(current) {
+			conflicts = true
+		}
+	}
+
+	annotations := target.GetAnnotations()
+	current := make(map[string]interface{})
+	desired := make(map[string]interface{})
+	for key, value := range annotations {
+		if backup.IsShimAnnotation(key) {
+			current[key] = value
+			delete(annotations, key)
+		}
+	}
+	for key, value := range intent.Annotations {
+		desired[key] = value
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[key] = value
+	}
+	set(current, desired)
+	target.SetAnnotations(annotations)
+
+	switch intent.Target.Kind {
+	case "Ingress":
+		desiredTLS, err := toUnstructuredValue(intent.IngressTLS)
+		if err != nil {
+			return false, false, err
+		}
+		currentTLS, _, _ := unstructured.NestedFieldNoCopy(target.Object, "spec", "tls")
+		set(currentTLS, desiredTLS)
+		if isEmpty(desiredTLS) {
+			unstructured.RemoveNestedField(target.Object, "spec", "tls")
+		} else if err := unstructured.SetNestedField(target.Object, desiredTLS, "spec", "tls"); err != nil {
+			return false, false, err
+		}
+	case "Gateway":
+		listeners, _, err := unstructured.NestedSlice(target.Object, "spec", "listeners")
+		if err != nil {
+			return false, false, err
+		}
+		for _, listenerTLS := range intent.GatewayListeners {
+			desiredTLS, err := toUnstructuredValue(listenerTLS.TLS)
+			if err != nil {
+				return false, false, err
+			}
+
+			found := false
+			for _, listener := range listeners {
+				listener, ok := listener.(map[string]interface{})
+				if !ok || listener["name"] != string(listenerTLS.Name) {
+					continue
+				}
+				found = true
+				set(listener["tls"], desiredTLS)
+				listener["tls"] = desiredTLS
+			}
+			if !found {
+				return false, false, fmt.Errorf("Gateway has no listener named %q", listenerTLS.Name)
+			}
+		}
+		if len(intent.GatewayListeners) > 0 {
+			if err := unstructured.SetNestedSlice(target.Object, listeners, "spec", "listeners"); err != nil {
+				return false, false, err
+			}
+		}
+	default:
+		return false, false, fmt.Errorf("unsupported target kind %q", intent.Target.Kind)
+	}
+
+	return changes, conflicts, nil
+}
+
+// toUnstructuredValue converts a typed value to the form it has in unstructured objects.
+func toUnstructuredValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return result, nil
+}
+
+// isEmpty returns true for the values of unstructured fields which are omitted when empty.
+func isEmpty(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case int64:
+		return value == 0
+	case float64:
+		return value == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+package restore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+)
+
+func TestClusterRestorer_RestoreShimIntent(t *testing.T) {
+	ingressGVR := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	gatewayGVR := schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1beta1", Resource: "gateways"}
+
+	annotations := map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"}
+	tls := []networkingv1.IngressTLS{{Hosts: []string{"app.example.com"}, SecretName: "app-tls"}}
+	ingressIntent := backup.IngressShimIntent(networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app", Annotations: annotations},
+		Spec:       networkingv1.IngressSpec{TLS: tls},
+	})
+	gatewayTLS := &gatewayv1beta1.GatewayTLSConfig{CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "gateway-tls"}}}
+	gatewayIntent := backup.GatewayShimIntent(gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "gateway", Annotations: annotations},
+		Spec: gatewayv1beta1.GatewaySpec{Listeners: []gatewayv1beta1.Listener{
+			{Name: "http"},
+			{Name: "https", TLS: gatewayTLS},
+		}},
+	})
+	resources, err := (&backup.ClusterBackup{ingressIntent, gatewayIntent}).ToUnstructured()
+	require.NoError(t, err)
+
+	newIngress := func(annotations map[string]interface{}, tls []interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata":   map[string]interface{}{"namespace": "team-a", "name": "app", "annotations": annotations},
+			"spec":       map[string]interface{}{"rules": []interface{}{}, "tls": tls},
+		}}
+	}
+	newGateway := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "Gateway",
+			"metadata":   map[string]interface{}{"namespace": "team-b", "name": "gateway"},
+			"spec": map[string]interface{}{"listeners": []interface{}{
+				map[string]interface{}{"name": "http", "port": int64(80)},
+				map[string]interface{}{"name": "https", "port": int64(443)},
+			}},
+		}}
+	}
+	restoredTLS := []interface{}{map[string]interface{}{"hosts": []interface{}{"app.example.com"}, "secretName": "app-tls"}}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, meta.RESTScopeNamespace)
+
+	t.Run("fails if the target types are not served", func(t *testing.T) {
+		client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		restorer := &ClusterRestorer{client: client, mapper: mapper}
+
+		_, err := restorer.Restore(context.Background(), resources, ClusterRestoreOptions{})
+		require.EqualError(t, err, "the cluster does not serve the resource types: Gateway (gateway.networking.k8s.io/v1beta1), install the CRDs and try again")
+		assert.Empty(t, client.Actions())
+	})
+
+	// the default plural of gateway is wrong, so the resource is registered explicitly
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"}, gatewayGVR, gatewayGVR.GroupVersion().WithResource("gateway"), meta.RESTScopeNamespace)
+
+	testCases := map[string]struct {
+		existing        []*unstructured.Unstructured
+		overwrite       bool
+		expectedResults []ResourceResult
+		expectedIngress *unstructured.Unstructured
+	}{
+		"skips missing targets": {
+			expectedResults: []ResourceResult{
+				{Kind: "ShimIntent", Namespace: "team-a", Name: "app", Result: ResultSkipped, Message: "Ingress does not exist, restore it and run restore again"},
+				{Kind: "ShimIntent", Namespace: "team-b", Name: "gateway", Result: ResultSkipped, Message: "Gateway does not exist, restore it and run restore again"},
+			},
+		},
+		"applies intent to targets": {
+			existing: []*unstructured.Unstructured{
+				newIngress(map[string]interface{}{"nginx.ingress.kubernetes.io/ssl-redirect": "true"}, nil),
+				newGateway(),
+			},
+			expectedResults: []ResourceResult{
+				{Kind: "ShimIntent", Namespace: "team-a", Name: "app", Result: ResultUpdated},
+				{Kind: "ShimIntent", Namespace: "team-b", Name: "gateway", Result: ResultUpdated},
+			},
+			expectedIngress: newIngress(map[string]interface{}{"nginx.ingress.kubernetes.io/ssl-redirect": "true", "cert-manager.io/cluster-issuer": "letsencrypt"}, restoredTLS),
+		},
+		"skips targets with the intent applied": {
+			existing: []*unstructured.Unstructured{
+				newIngress(map[string]interface{}{"cert-manager.io/cluster-issuer": "letsencrypt"}, restoredTLS),
+			},
+			expectedResults: []ResourceResult{
+				{Kind: "ShimIntent", Namespace: "team-a", Name: "app", Result: ResultSkipped, Message: "already applied"},
+				{Kind: "ShimIntent", Namespace: "team-b", Name: "gateway", Result: ResultSkipped, Message: "Gateway does not exist, restore it and run restore again"},
+			},
+			expectedIngress: newIngress(map[string]interface{}{"cert-manager.io/cluster-issuer": "letsencrypt"}, restoredTLS),
+		},
+		"skips targets with different intent": {
+			existing: []*unstructured.Unstructured{
+				newIngress(map[string]interface{}{"cert-manager.io/issuer": "ca"}, nil),
+			},
+			expectedResults: []ResourceResult{
+				{Kind: "ShimIntent", Namespace: "team-a", Name: "app", Result: ResultSkipped, Message: "Ingress has different cert-manager annotations or TLS configuration"},
+				{Kind: "ShimIntent", Namespace: "team-b", Name: "gateway", Result: ResultSkipped, Message: "Gateway does not exist, restore it and run restore again"},
+			},
+			expectedIngress: newIngress(map[string]interface{}{"cert-manager.io/issuer": "ca"}, nil),
+		},
+		"overwrites targets with different intent": {
+			existing: []*unstructured.Unstructured{
+				newIngress(map[string]interface{}{"cert-manager.io/issuer": "ca"}, nil),
+			},
+			overwrite: true,
+			expectedResults: []ResourceResult{
+				{Kind: "ShimIntent", Namespace: "team-a", Name: "app", Result: ResultUpdated},
+				{Kind: "ShimIntent", Namespace: "team-b", Name: "gateway", Result: ResultSkipped, Message: "Gateway does not exist, restore it and run restore again"},
+			},
+			expectedIngress: newIngress(map[string]interface{}{"cert-manager.io/cluster-issuer": "letsencrypt"}, restoredTLS),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				ingressGVR: "IngressList",
+				gatewayGVR: "GatewayList",
+			})
+			for _, object := range tc.existing {
+				gvr := ingressGVR
+				if object.GetKind() == "Gateway" {
+					gvr = gatewayGVR
+				}
+				_, err := client.Resource(gvr).Namespace(object.GetNamespace()).Create(context.Background(), object, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			restorer := &ClusterRestorer{client: client, mapper: mapper}
+
+			report, err := restorer.Restore(context.Background(), resources, ClusterRestoreOptions{Overwrite: tc.overwrite})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResults, report.Resources)
+
+			if tc.expectedIngress != nil {
+				ingress, err := client.Resource(ingressGVR).Namespace("team-a").Get(context.Background(), "app", metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedIngress.GetAnnotations(), ingress.GetAnnotations())
+				tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+				expectedTLS, _, _ := unstructured.NestedSlice(tc.expectedIngress.Object, "spec", "tls")
+				assert.Equal(t, expectedTLS, tls)
+			}
+
+			if name == "applies intent to targets" {
+				gateway, err := client.Resource(gatewayGVR).Namespace("team-b").Get(context.Background(), "gateway", metav1.GetOptions{})
+				require.NoError(t, err)
+				listeners, _, err := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+				require.NoError(t, err)
+				assert.NotContains(t, listeners[0], "tls")
+				assert.Equal(t, map[string]interface{}{"certificateRefs": []interface{}{map[string]interface{}{"group": nil, "kind": nil, "name": "gateway-tls"}}}, listeners[1].(map[string]interface{})["tls"])
+			}
+		})
+	}
+}
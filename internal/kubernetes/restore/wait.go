@@ -0,0 +1,169 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+)
+
+// issuerTypesPollInterval is how often IssuerTypesWaiter checks whether the issuer types are ready.
+var issuerTypesPollInterval = 2 * time.Second
+
+// issuerController is implemented by the status components that detect the controllers of issuer types from the
+// images of their pods.
+type issuerController interface {
+	Name() string
+	Match(md *components.MatchData) (bool, error)
+}
+
+// IssuerTypesWaiter waits for the types of issuers to be ready to be restored: their CRDs must be established and
+// their controllers running, so that the issuers are served and reconciled once they are created.
+type IssuerTypesWaiter struct {
+	crdClient        clients.Generic[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList]
+	deploymentClient clients.Generic[*appsv1.Deployment, *appsv1.DeploymentList]
+}
+
+// NewIssuerTypesWaiter returns an IssuerTypesWaiter for the cluster.
+func NewIssuerTypesWaiter(cfg *rest.Config) (*IssuerTypesWaiter, error) {
+	crdClient, err := clients.NewCRDClient(rest.CopyConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRD client: %w", err)
+	}
+
+	deploymentClient, err := clients.NewDeploymentClient(rest.CopyConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployment client: %w", err)
+	}
+
+	return &IssuerTypesWaiter{
+		crdClient:        crdClient,
+		deploymentClient: deploymentClient,
+	}, nil
+}
+
+// Wait waits until the CRD of the type of each issuer is established and a Deployment running the type's
+// controller is available, checking at least once. If the types are still not ready when the timeout expires, it
+// returns why for each of them rather than an error.
+func (w *IssuerTypesWaiter) Wait(ctx context.Context, issuers []*unstructured.Unstructured, timeout time.Duration) ([]string, error) {
+	var types []clients.AnyIssuer
+	seen := make(map[clients.AnyIssuer]bool)
+	for _, issuer := range issuers {
+		anyIssuer, ok := issuerType(issuer.GroupVersionKind())
+		if !ok || seen[anyIssuer] {
+			continue
+		}
+		seen[anyIssuer] = true
+		types = append(types, anyIssuer)
+	}
+
+	var notReady []string
+	err := wait.PollImmediateWithContext(ctx, issuerTypesPollInterval, timeout, func(ctx context.Context) (bool, error) {
+		var err error
+		notReady, err = w.notReady(ctx, types)
+		if err != nil {
+			return false, err
+		}
+		return len(notReady) == 0, nil
+	})
+	if err != nil && !errors.Is(err, wait.ErrWaitTimeout) {
+		return nil, err
+	}
+
+	return notReady, nil
+}
+
+// notReady returns why each of the issuer types is not ready yet.
+func (w *IssuerTypesWaiter) notReady(ctx context.Context, types []clients.AnyIssuer) ([]string, error) {
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	if err := w.crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds); err != nil {
+		return nil, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+	established := make(map[string]bool)
+	for _, crd := range crds.Items {
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+				established[crd.Name] = true
+			}
+		}
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := w.deploymentClient.List(ctx, &clients.GenericRequestOptions{}, &deployments); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	// the controllers are detected from the pod templates of the available Deployments, as they are by status
+	available := &components.MatchData{}
+	for _, deployment := range deployments.Items {
+		if !deploymentAvailable(deployment) {
+			continue
+		}
+		available.Pods = append(available.Pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: deployment.Namespace, Name: deployment.Name},
+			Spec:       deployment.Spec.Template.Spec,
+		})
+	}
+
+	var notReady []string
+	for _, issuer := range types {
+		if !established[issuer.String()] {
+			notReady = append(notReady, fmt.Sprintf("%s: the CRD %s is not established", issuer.Kind(), issuer.String()))
+			continue
+		}
+
+		controller := controllerOf(issuer)
+		found, err := controller.Match(available)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect the %s controller: %w", controller.Name(), err)
+		}
+		if !found {
+			notReady = append(notReady, fmt.Sprintf("%s: no Deployment of the %s controller is available", issuer.Kind(), controller.Name()))
+		}
+	}
+	sort.Strings(notReady)
+
+	return notReady, nil
+}
+
+func deploymentAvailable(deployment appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// controllerOf returns the status component that detects the controller of the issuer type.
+func controllerOf(issuer clients.AnyIssuer) issuerController {
+	switch issuer {
+	case clients.VenafiEnhancedIssuer, clients.VenafiEnhancedClusterIssuer:
+		return &components.VenafiEnhancedIssuerStatus{}
+	case clients.AWSPCAIssuer, clients.AWSPCAClusterIssuer:
+		return &components.AWSPCAIssuerStatus{}
+	case clients.KMSIssuer:
+		return &components.KMSIssuerStatus{}
+	case clients.GoogleCASIssuer, clients.GoogleCASClusterIssuer:
+		return &components.GoogleCASIssuerStatus{}
+	case clients.OriginCAIssuer:
+		return &components.OriginCAIssuerStatus{}
+	case clients.SmallStepIssuer, clients.SmallStepClusterIssuer:
+		return &components.SmallStepIssuerStatus{}
+	default:
+		// cert-manager's own issuers
+		return &components.CertManagerStatus{}
+	}
+}
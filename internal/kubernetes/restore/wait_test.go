@@ -0,0 +1,138 @@
+package restore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestIssuerTypesWaiter_Wait(t *testing.T) {
+	issuerTypesPollInterval = time.Millisecond
+
+	crd := func(name string, established bool) apiextensionsv1.CustomResourceDefinition {
+		status := apiextensionsv1.ConditionFalse
+		if established {
+			status = apiextensionsv1.ConditionTrue
+		}
+		return apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{{Type: apiextensionsv1.Established, Status: status}},
+			},
+		}
+	}
+	deployment := func(image string, available bool) appsv1.Deployment {
+		status := corev1.ConditionFalse
+		if available {
+			status = corev1.ConditionTrue
+		}
+		return appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Image: image}},
+			}}},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: status}},
+			},
+		}
+	}
+
+	newIssuer := func(apiVersion, kind string) *unstructured.Unstructured {
+		issuer := &unstructured.Unstructured{}
+		issuer.SetAPIVersion(apiVersion)
+		issuer.SetKind(kind)
+		issuer.SetName("issuer")
+		return issuer
+	}
+	awsPCAIssuer := newIssuer("awspca.cert-manager.io/v1beta1", "AWSPCAClusterIssuer")
+	googleCASIssuer := newIssuer("cas-issuer.jetstack.io/v1beta1", "GoogleCASIssuer")
+
+	testCases := map[string]struct {
+		issuer *unstructured.Unstructured
+		// crds and deployments are returned in turn by each poll, repeating the last
+		crds             [][]apiextensionsv1.CustomResourceDefinition
+		deployments      [][]appsv1.Deployment
+		expectedNotReady []string
+	}{
+		"ready": {
+			issuer:      awsPCAIssuer,
+			crds:        [][]apiextensionsv1.CustomResourceDefinition{{crd("awspcaclusterissuers.awspca.cert-manager.io", true)}},
+			deployments: [][]appsv1.Deployment{{deployment("public.ecr.aws/k1n1h4h4/cert-manager-aws-privateca-issuer:v1.2.2", true)}},
+		},
+		"ready once the CRD is established and the controller available": {
+			issuer: awsPCAIssuer,
+			crds: [][]apiextensionsv1.CustomResourceDefinition{
+				{},
+				{crd("awspcaclusterissuers.awspca.cert-manager.io", false)},
+				{crd("awspcaclusterissuers.awspca.cert-manager.io", true)},
+			},
+			deployments: [][]appsv1.Deployment{
+				{},
+				{},
+				{deployment("public.ecr.aws/k1n1h4h4/cert-manager-aws-privateca-issuer:v1.2.2", false)},
+				{deployment("public.ecr.aws/k1n1h4h4/cert-manager-aws-privateca-issuer:v1.2.2", true)},
+			},
+		},
+		"ready with the images of the status fixtures": {
+			issuer:      googleCASIssuer,
+			crds:        [][]apiextensionsv1.CustomResourceDefinition{{crd("googlecasissuers.cas-issuer.jetstack.io", true)}},
+			deployments: [][]appsv1.Deployment{{deployment("quay.io/jetstack/cert-manager-google-cas-issuer:v0.6.0", true)}},
+		},
+		"ready with a mirrored image": {
+			issuer:      googleCASIssuer,
+			crds:        [][]apiextensionsv1.CustomResourceDefinition{{crd("googlecasissuers.cas-issuer.jetstack.io", true)}},
+			deployments: [][]appsv1.Deployment{{deployment("registry.internal/mirror/jetstack-google-cas-issuer:v0.6.0", true)}},
+		},
+		"CRD not established": {
+			issuer:           awsPCAIssuer,
+			crds:             [][]apiextensionsv1.CustomResourceDefinition{{crd("awspcaclusterissuers.awspca.cert-manager.io", false)}},
+			deployments:      [][]appsv1.Deployment{{deployment("public.ecr.aws/k1n1h4h4/cert-manager-aws-privateca-issuer:v1.2.2", true)}},
+			expectedNotReady: []string{"AWSPCAClusterIssuer: the CRD awspcaclusterissuers.awspca.cert-manager.io is not established"},
+		},
+		"controller not available": {
+			issuer:           awsPCAIssuer,
+			crds:             [][]apiextensionsv1.CustomResourceDefinition{{crd("awspcaclusterissuers.awspca.cert-manager.io", true)}},
+			deployments:      [][]appsv1.Deployment{{deployment("quay.io/jetstack/cert-manager-controller:v1.11.0", true)}},
+			expectedNotReady: []string{"AWSPCAClusterIssuer: no Deployment of the aws-pca-issuer controller is available"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			crds, deployments := tc.crds, tc.deployments
+			waiter := &IssuerTypesWaiter{
+				crdClient: &clients.FakeGeneric[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *apiextensionsv1.CustomResourceDefinitionList) error {
+						result.Items = crds[0]
+						if len(crds) > 1 {
+							crds = crds[1:]
+						}
+						return nil
+					},
+				},
+				deploymentClient: &clients.FakeGeneric[*appsv1.Deployment, *appsv1.DeploymentList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *appsv1.DeploymentList) error {
+						result.Items = deployments[0]
+						if len(deployments) > 1 {
+							deployments = deployments[1:]
+						}
+						return nil
+					},
+				},
+			}
+
+			notReady, err := waiter.Wait(context.Background(), []*unstructured.Unstructured{tc.issuer, tc.issuer}, 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedNotReady, notReady)
+		})
+	}
+}
@@ -37,6 +37,11 @@ func (c *CertManagerStatus) MarshalYAML() (interface{}, error) {
 	}, nil
 }
 
+// ControllerArgs returns the args of the cert-manager controller container.
+func (c *CertManagerStatus) ControllerArgs() []string {
+	return c.controllerArgs
+}
+
 func (c *CertManagerStatus) GetControllerFlagValue(flag string) (bool, string) {
 	for _, arg := range c.controllerArgs {
 		if strings.HasPrefix(arg, "--"+flag) {
@@ -0,0 +1,500 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+const (
+	// ScriptFormatSkopeo is the script format that uses skopeo to copy images.
+	ScriptFormatSkopeo = "skopeo"
+	// ScriptFormatCrane is the script format that uses crane to copy images.
+	ScriptFormatCrane = "crane"
+
+	installationsCRDName = "installations.operator.jetstack.io"
+	versionPattern       = `v\d+(?:\.\d+)*(?:-[0-9A-Za-z]+(?:\.[0-9A-Za-z]+)*)?`
+)
+
+// componentImages maps the name of each component that can be configured in an Installation to the names of the
+// images that the operator deploys for it. Image names are relative to the registry configured in the Installation.
+// When the operator starts to deploy a new image for a component, it must be added here so that it is included in
+// image bundles.
+var componentImages = map[string][]string{
+	"cert-manager": {
+		"cert-manager-controller",
+		"cert-manager-webhook",
+		"cert-manager-cainjector",
+		"cert-manager-acmesolver",
+		"cert-manager-ctl",
+	},
+	"approver-policy":            {"cert-manager-approver-policy"},
+	"approver-policy-enterprise": {"approver-policy-enterprise"},
+	"csi-driver":                 {"cert-manager-csi-driver"},
+	"csi-driver-spiffe": {
+		"cert-manager-csi-driver-spiffe",
+		"cert-manager-csi-driver-spiffe-approver",
+	},
+	"istio-csr":              {"cert-manager-istio-csr"},
+	"trust-manager":          {"trust-manager"},
+	"venafi-oauth-helper":    {"venafi-oauth-helper"},
+	"cert-discovery-venafi":  {"cert-discovery-venafi"},
+	"venafi-enhanced-issuer": {"venafi-enhanced-issuer"},
+}
+
+// componentVersionFields maps the name of each component to the path of its properties within the schema of the
+// Installation spec. The description of the version field at that path documents the default version the operator
+// deploys.
+var componentVersionFields = map[string][]string{
+	"cert-manager":               {"certManager"},
+	"approver-policy":            {"approverPolicy"},
+	"approver-policy-enterprise": {"approverPolicyEnterprise"},
+	"csi-driver":                 {"csiDrivers", "certManager"},
+	"csi-driver-spiffe":          {"csiDrivers", "certManagerSpiffe"},
+	"istio-csr":                  {"istioCSR"},
+	"trust-manager":              {"trustManager"},
+	"venafi-oauth-helper":        {"venafiOauthHelper"},
+	"cert-discovery-venafi":      {"certDiscoveryVenafi"},
+	"venafi-enhanced-issuer":     {"venafiEnhancedIssuer"},
+}
+
+var (
+	defaultVersionRegex    = regexp.MustCompile(`Defaults?(?: version:| to|:)\s+(` + versionPattern + `)`)
+	supportedVersionsRegex = regexp.MustCompile(`Supported [Vv]ersions(?::| are)\s+(.*)`)
+	versionRegex           = regexp.MustCompile(versionPattern)
+)
+
+type (
+	// The BundleImagesOptions type contains fields used to determine the set of images required to run the
+	// Jetstack Secure operator and the components configured in an Installation.
+	BundleImagesOptions struct {
+		Version       string // The version of the operator to use
+		ImageRegistry string // The image registry that images are sourced from
+		// Installation contains the options used to generate the Installation resource, the components enabled here
+		// determine the component images that are included in the bundle.
+		Installation ApplyInstallationYAMLOptions
+		// ComponentVersions is a map of component names to the versions of their images. Component versions that
+		// are set in the generated Installation take precedence.
+		ComponentVersions map[string]string
+		// Source provides the installer manifests, defaults to the manifests embedded in jsctl.
+		Source ManifestSource
+	}
+
+	// The Image type describes a single container image reference required by the operator or one of its
+	// components.
+	Image struct {
+		Component  string // The name of the component that uses the image
+		Repository string // The full image repository, including the registry
+		Tag        string // The image tag or digest
+	}
+
+	// bufferApplier is an Applier implementation that retains all applied data in memory.
+	bufferApplier struct {
+		data bytes.Buffer
+	}
+)
+
+// String returns the image reference, the tag is omitted if it is not known.
+func (i Image) String() string {
+	switch {
+	case i.Tag == "":
+		return i.Repository
+	case strings.HasPrefix(i.Tag, "@"):
+		return i.Repository + i.Tag
+	default:
+		return i.Repository + ":" + i.Tag
+	}
+}
+
+// Name returns the last path element of the image repository, e.g. cert-manager-controller.
+func (i Image) Name() string {
+	return path.Base(i.Repository)
+}
+
+func (b *bufferApplier) Apply(_ context.Context, r io.Reader) error {
+	_, err := io.Copy(&b.data, r)
+	return err
+}
+
+// Components returns the names of all components that can be included in an image bundle, ordered by name.
+func Components() []string {
+	names := make([]string, 0, len(componentImages))
+	for name := range componentImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// BundleImages returns every container image referenced by the operator's installer manifest for the given version,
+// along with the images of the components that would be deployed by the Installation generated from the provided
+// options. Component versions are taken from the Installation, then from the provided component versions and finally
+// from the defaults documented by the operator's Installation CRD. An error is returned if the version of a component
+// cannot be determined. Images are ordered by component and name.
+func BundleImages(ctx context.Context, options BundleImagesOptions) ([]Image, error) {
+	for component := range options.ComponentVersions {
+		if _, ok := componentImages[component]; !ok {
+			return nil, fmt.Errorf("unknown component %q, valid components are: %s", component, strings.Join(Components(), ", "))
+		}
+	}
+
+	operatorApplier := &bufferApplier{}
+	err := ApplyOperatorYAML(ctx, operatorApplier, ApplyOperatorYAMLOptions{
+		Version:       options.Version,
+		ImageRegistry: options.ImageRegistry,
+		Source:        options.Source,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := yaml.Load(&operatorApplier.data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading operator manifest: %w", err)
+	}
+
+	images, err := operatorImages(objects)
+	if err != nil {
+		return nil, fmt.Errorf("error reading images from operator manifest: %w", err)
+	}
+
+	defaultVersions, err := defaultComponentVersions(objects)
+	if err != nil {
+		return nil, fmt.Errorf("error reading default component versions from operator manifest: %w", err)
+	}
+
+	installationOptions := options.Installation
+	installationOptions.ImageRegistry = options.ImageRegistry
+
+	installationApplier := &bufferApplier{}
+	if err := ApplyInstallationYAML(ctx, installationApplier, installationOptions); err != nil {
+		return nil, fmt.Errorf("error generating installation: %w", err)
+	}
+
+	installation, err := installationFromManifests(&installationApplier.data)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := options.ImageRegistry
+	if installation.Spec.Images != nil && installation.Spec.Images.Registry != "" {
+		registry = installation.Spec.Images.Registry
+	}
+
+	unpinned := make([]string, 0)
+	for component, version := range installationComponents(installation) {
+		if version == "" {
+			version = options.ComponentVersions[component]
+		}
+		if version == "" {
+			version = defaultVersions[component]
+		}
+		if version == "" {
+			unpinned = append(unpinned, component)
+			continue
+		}
+
+		for _, name := range componentImages[component] {
+			images = append(images, Image{
+				Component:  component,
+				Repository: path.Join(registry, name),
+				Tag:        version,
+			})
+		}
+	}
+
+	if len(unpinned) > 0 {
+		sort.Strings(unpinned)
+		return nil, fmt.Errorf("no version known for components %s, set one with --component-versions", strings.Join(unpinned, ", "))
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Component != images[j].Component {
+			return images[i].Component < images[j].Component
+		}
+		return images[i].Repository < images[j].Repository
+	})
+
+	return images, nil
+}
+
+// operatorImages returns the images of all containers found in the workloads of the operator's installer manifest.
+func operatorImages(objects []*unstructured.Unstructured) ([]Image, error) {
+	seen := make(map[string]bool)
+	images := make([]Image, 0)
+	for _, object := range objects {
+		var containerPaths [][]string
+		switch object.GetKind() {
+		case "Deployment", "DaemonSet", "StatefulSet", "Job":
+			containerPaths = [][]string{
+				{"spec", "template", "spec", "initContainers"},
+				{"spec", "template", "spec", "containers"},
+			}
+		case "Pod":
+			containerPaths = [][]string{
+				{"spec", "initContainers"},
+				{"spec", "containers"},
+			}
+		default:
+			continue
+		}
+
+		for _, fields := range containerPaths {
+			containers, _, err := unstructured.NestedSlice(object.Object, fields...)
+			if err != nil {
+				return nil, fmt.Errorf("error reading containers of %s %s: %w", object.GetKind(), object.GetName(), err)
+			}
+
+			for _, container := range containers {
+				c, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				reference, ok := c["image"].(string)
+				if !ok || reference == "" || seen[reference] {
+					continue
+				}
+				seen[reference] = true
+
+				repository, tag := splitImageReference(reference)
+				images = append(images, Image{
+					Component:  "operator",
+					Repository: repository,
+					Tag:        tag,
+				})
+			}
+		}
+	}
+
+	return images, nil
+}
+
+// defaultComponentVersions returns the default version of each component, as documented by the version fields of
+// the Installation CRD found in the operator's installer manifest. Where the documented default is not one of the
+// documented supported versions, the first supported version is used instead.
+func defaultComponentVersions(objects []*unstructured.Unstructured) (map[string]string, error) {
+	var crd *unstructured.Unstructured
+	for _, object := range objects {
+		if object.GetKind() == "CustomResourceDefinition" && object.GetName() == installationsCRDName {
+			crd = object
+			break
+		}
+	}
+	if crd == nil {
+		return nil, fmt.Errorf("manifest did not contain the %s CustomResourceDefinition", installationsCRDName)
+	}
+
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil || len(versions) == 0 {
+		return nil, fmt.Errorf("%s CustomResourceDefinition has no versions", installationsCRDName)
+	}
+
+	version, ok := versions[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s CustomResourceDefinition has an invalid version", installationsCRDName)
+	}
+
+	defaults := make(map[string]string)
+	for component, fields := range componentVersionFields {
+		schemaFields := []string{"schema", "openAPIV3Schema", "properties", "spec"}
+		for _, field := range fields {
+			schemaFields = append(schemaFields, "properties", field)
+		}
+		schemaFields = append(schemaFields, "properties", "version", "description")
+
+		description, _, err := unstructured.NestedString(version, schemaFields...)
+		if err != nil {
+			return nil, fmt.Errorf("error reading version description of %s: %w", component, err)
+		}
+
+		if v := defaultVersion(description); v != "" {
+			defaults[component] = v
+		}
+	}
+
+	return defaults, nil
+}
+
+// defaultVersion parses the default version from the description of a component version field.
+func defaultVersion(description string) string {
+	var supported []string
+	if match := supportedVersionsRegex.FindStringSubmatch(description); match != nil {
+		supported = versionRegex.FindAllString(match[1], -1)
+	}
+
+	match := defaultVersionRegex.FindStringSubmatch(description)
+	if match == nil {
+		if len(supported) > 0 {
+			return supported[0]
+		}
+		return ""
+	}
+
+	if len(supported) == 0 {
+		return match[1]
+	}
+	for _, v := range supported {
+		if v == match[1] {
+			return v
+		}
+	}
+
+	return supported[0]
+}
+
+// installationFromManifests finds the Installation resource within a YAML stream generated by ApplyInstallationYAML.
+func installationFromManifests(r io.Reader) (*operatorv1alpha1.Installation, error) {
+	objects, err := yaml.Load(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading generated manifests: %w", err)
+	}
+
+	for _, object := range objects {
+		if object.GroupVersionKind() != operatorv1alpha1.InstallationGVK {
+			continue
+		}
+
+		var installation operatorv1alpha1.Installation
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &installation)
+		if err != nil {
+			return nil, fmt.Errorf("error converting generated Installation: %w", err)
+		}
+
+		return &installation, nil
+	}
+
+	return nil, errors.New("generated manifests did not contain an Installation")
+}
+
+// installationComponents returns the names of all components enabled in the Installation mapped to the version
+// configured for them, if any.
+func installationComponents(installation *operatorv1alpha1.Installation) map[string]string {
+	spec := installation.Spec
+	components := make(map[string]string)
+
+	if spec.CertManager != nil {
+		components["cert-manager"] = spec.CertManager.Version
+	}
+	if spec.ApproverPolicy != nil {
+		components["approver-policy"] = spec.ApproverPolicy.Version
+	}
+	if spec.ApproverPolicyEnterprise != nil {
+		components["approver-policy-enterprise"] = spec.ApproverPolicyEnterprise.Version
+	}
+	if spec.CSIDrivers != nil && spec.CSIDrivers.CertManager != nil {
+		components["csi-driver"] = spec.CSIDrivers.CertManager.Version
+	}
+	if spec.CSIDrivers != nil && spec.CSIDrivers.CertManagerSpiffe != nil {
+		components["csi-driver-spiffe"] = spec.CSIDrivers.CertManagerSpiffe.Version
+	}
+	if spec.IstioCSR != nil {
+		components["istio-csr"] = spec.IstioCSR.Version
+	}
+	if spec.TrustManager != nil {
+		components["trust-manager"] = spec.TrustManager.Version
+	}
+	if spec.VenafiOauthHelper != nil {
+		components["venafi-oauth-helper"] = spec.VenafiOauthHelper.Version
+	}
+	if spec.CertDiscoveryVenafi != nil {
+		components["cert-discovery-venafi"] = spec.CertDiscoveryVenafi.Version
+	}
+	if spec.VenafiEnhancedIssuer != nil {
+		components["venafi-enhanced-issuer"] = spec.VenafiEnhancedIssuer.Version
+	}
+
+	// issuers backed by the venafi-enhanced-issuer cause the operator to deploy it, even if it's not configured
+	// explicitly
+	for _, issuer := range spec.Issuers {
+		if _, ok := components["venafi-enhanced-issuer"]; !ok && issuer.VenafiEnhancedIssuer != nil {
+			components["venafi-enhanced-issuer"] = ""
+		}
+	}
+
+	return components
+}
+
+// splitImageReference splits an image reference into its repository and tag. Digests are retained as part of the
+// tag, including the '@' separator.
+func splitImageReference(reference string) (string, string) {
+	if i := strings.Index(reference, "@"); i >= 0 {
+		return reference[:i], reference[i:]
+	}
+
+	i := strings.LastIndex(reference, ":")
+	if i < 0 || strings.Contains(reference[i:], "/") {
+		return reference, ""
+	}
+
+	return reference[:i], reference[i+1:]
+}
+
+// WriteImageList writes the references of the provided images to w, one per line.
+func WriteImageList(w io.Writer, images []Image) error {
+	for _, image := range images {
+		if _, err := fmt.Fprintln(w, image.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteSyncScript writes a shell script to w that copies each of the provided images into the destination
+// registry using the tool named by format. Images are copied to the same name within the destination, so that the
+// destination can be used as the image registry for the operator and its components. An error is returned for images
+// without a tag, as they cannot be copied.
+func WriteSyncScript(w io.Writer, format string, images []Image, destination string) error {
+	var copyCommand string
+	switch format {
+	case ScriptFormatSkopeo:
+		copyCommand = "skopeo copy --all docker://%s docker://%s\n"
+	case ScriptFormatCrane:
+		copyCommand = "crane copy %s %s\n"
+	default:
+		return fmt.Errorf("unknown script format %q, valid formats are: %s, %s", format, ScriptFormatSkopeo, ScriptFormatCrane)
+	}
+
+	destination = strings.TrimSuffix(destination, "/")
+	if destination == "" {
+		return errors.New("a destination registry is required to generate a sync script")
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	buf.WriteString("#!/usr/bin/env sh\n")
+	buf.WriteString("# Generated by jsctl. Copies Jetstack Secure images into " + destination + "\n")
+	buf.WriteString("set -eu\n\n")
+
+	for _, image := range images {
+		if image.Tag == "" {
+			return fmt.Errorf("image %s (%s) has no version, set one with --component-versions", image.Repository, image.Component)
+		}
+
+		target := Image{
+			Repository: path.Join(destination, image.Name()),
+			Tag:        image.Tag,
+		}
+		// digests cannot be pushed as a tag, so copy to the image name and let the registry address it by digest
+		if strings.HasPrefix(image.Tag, "@") {
+			target.Tag = ""
+		}
+
+		fmt.Fprintf(buf, copyCommand, image.String(), target.String())
+	}
+
+	_, err := io.Copy(w, buf)
+	return err
+}
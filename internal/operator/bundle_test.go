@@ -0,0 +1,155 @@
+package operator_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestBundleImages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("It should include the operator and default component images", func(t *testing.T) {
+		options := operator.BundleImagesOptions{
+			Version:       "v0.0.1-alpha.25",
+			ImageRegistry: "example.com/jetstack",
+			Installation: operator.ApplyInstallationYAMLOptions{
+				CertManagerVersion: "v1.11.0",
+			},
+		}
+
+		images, err := operator.BundleImages(ctx, options)
+		require.NoError(t, err)
+
+		references := make([]string, len(images))
+		for i, image := range images {
+			references[i] = image.String()
+		}
+
+		assert.Contains(t, references, "example.com/jetstack/js-operator:v0.0.1-alpha.25")
+		assert.Contains(t, references, "example.com/jetstack/cert-manager-controller:v1.11.0")
+		assert.Contains(t, references, "example.com/jetstack/cert-manager-approver-policy:v0.6.3")
+		assert.NotContains(t, references, "example.com/jetstack/cert-manager-istio-csr")
+	})
+
+	t.Run("It should include images for enabled components with pinned versions", func(t *testing.T) {
+		options := operator.BundleImagesOptions{
+			ImageRegistry: "example.com/jetstack",
+			Installation: operator.ApplyInstallationYAMLOptions{
+				InstallIstioCSR:        true,
+				InstallSpiffeCSIDriver: true,
+			},
+			ComponentVersions: map[string]string{
+				"istio-csr": "v0.5.0",
+			},
+		}
+
+		images, err := operator.BundleImages(ctx, options)
+		require.NoError(t, err)
+
+		var istioCSR, spiffe []operator.Image
+		for _, image := range images {
+			switch image.Component {
+			case "istio-csr":
+				istioCSR = append(istioCSR, image)
+			case "csi-driver-spiffe":
+				spiffe = append(spiffe, image)
+			}
+		}
+
+		if assert.Len(t, istioCSR, 1) {
+			assert.Equal(t, "example.com/jetstack/cert-manager-istio-csr:v0.5.0", istioCSR[0].String())
+		}
+		if assert.Len(t, spiffe, 2) {
+			assert.Equal(t, "v0.4.0", spiffe[0].Tag)
+		}
+	})
+
+	t.Run("It should tag every image with the default component versions of the operator", func(t *testing.T) {
+		options := operator.BundleImagesOptions{
+			Version:       "v0.0.1-alpha.24",
+			ImageRegistry: "example.com/jetstack",
+			Installation: operator.ApplyInstallationYAMLOptions{
+				InstallCSIDriver:                true,
+				InstallApproverPolicyEnterprise: true,
+				InstallVenafiOauthHelper:        true,
+			},
+		}
+
+		images, err := operator.BundleImages(ctx, options)
+		require.NoError(t, err)
+
+		versions := make(map[string]string)
+		for _, image := range images {
+			assert.NotEmpty(t, image.Tag, image.Repository)
+			versions[image.Component] = image.Tag
+		}
+
+		assert.Equal(t, "v1.11.0", versions["cert-manager"])
+		assert.Equal(t, "v0.7.2", versions["approver-policy-enterprise"])
+		assert.Equal(t, "v0.3.0", versions["venafi-oauth-helper"])
+		// the documented default of csi-driver is not a supported version, so the first supported version is used
+		assert.Equal(t, "v0.5.0", versions["csi-driver"])
+	})
+
+	t.Run("It should return an error for an unknown component version", func(t *testing.T) {
+		options := operator.BundleImagesOptions{
+			ComponentVersions: map[string]string{"not-a-component": "v1.0.0"},
+		}
+
+		_, err := operator.BundleImages(ctx, options)
+		assert.Error(t, err)
+	})
+
+	t.Run("It should return ErrNoManifest for a version that does not exist", func(t *testing.T) {
+		options := operator.BundleImagesOptions{
+			Version: "v99.99.99",
+		}
+
+		_, err := operator.BundleImages(ctx, options)
+		assert.ErrorIs(t, err, operator.ErrNoManifest)
+	})
+}
+
+func TestWriteSyncScript(t *testing.T) {
+	t.Parallel()
+
+	images := []operator.Image{
+		{Component: "operator", Repository: "example.com/jetstack/js-operator", Tag: "v0.0.1-alpha.25"},
+		{Component: "istio-csr", Repository: "example.com/jetstack/cert-manager-istio-csr", Tag: "v0.6.0"},
+	}
+
+	t.Run("It should generate a skopeo script", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		err := operator.WriteSyncScript(buf, operator.ScriptFormatSkopeo, images, "registry.internal/jetstack/")
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), "skopeo copy --all docker://example.com/jetstack/js-operator:v0.0.1-alpha.25 docker://registry.internal/jetstack/js-operator:v0.0.1-alpha.25\n")
+		assert.Contains(t, buf.String(), "skopeo copy --all docker://example.com/jetstack/cert-manager-istio-csr:v0.6.0 docker://registry.internal/jetstack/cert-manager-istio-csr:v0.6.0\n")
+	})
+
+	t.Run("It should generate a crane script", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		err := operator.WriteSyncScript(buf, operator.ScriptFormatCrane, images, "registry.internal/jetstack")
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), "crane copy example.com/jetstack/js-operator:v0.0.1-alpha.25 registry.internal/jetstack/js-operator:v0.0.1-alpha.25\n")
+	})
+
+	t.Run("It should return an error for an image without a tag", func(t *testing.T) {
+		untagged := append(images, operator.Image{Component: "trust-manager", Repository: "example.com/jetstack/trust-manager"})
+		err := operator.WriteSyncScript(bytes.NewBuffer([]byte{}), operator.ScriptFormatSkopeo, untagged, "registry.internal/jetstack")
+		assert.Error(t, err)
+	})
+
+	t.Run("It should return an error for an unknown format", func(t *testing.T) {
+		err := operator.WriteSyncScript(bytes.NewBuffer([]byte{}), "docker", images, "registry.internal/jetstack")
+		assert.Error(t, err)
+	})
+}
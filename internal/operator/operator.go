@@ -9,12 +9,8 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
 	"text/template"
 
-	"github.com/Masterminds/semver"
 	certmanageracmev1 "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -24,6 +20,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/jetstack/jsctl/internal/kubernetes/patch"
 	"github.com/jetstack/jsctl/internal/prompt"
 	"github.com/jetstack/jsctl/internal/registry"
 	"github.com/jetstack/jsctl/internal/venafi"
@@ -37,6 +34,9 @@ import (
 //go:embed installers/*.yaml
 var installers embed.FS
 
+// DefaultImageRegistry is the image registry the operator is deployed from unless another is specified.
+const DefaultImageRegistry = "eu.gcr.io/jetstack-secure-enterprise"
+
 // The Applier interface describes types that can Apply a stream of YAML-encoded Kubernetes resources.
 type Applier interface {
 	Apply(ctx context.Context, r io.Reader) error
@@ -49,6 +49,10 @@ type ApplyOperatorYAMLOptions struct {
 	ImageRegistry string // A custom image registry for the operator image
 	// RegistryCredentials is a string containing a GCP service account key to access the Jetstack Secure image registry.
 	RegistryCredentials string
+	// Source provides the installer manifests, defaults to the manifests embedded in jsctl.
+	Source ManifestSource
+	// Patches are applied to the generated resources before they are applied.
+	Patches []*patch.Patch
 }
 
 // ApplyOperatorYAML generates a YAML bundle that contains all Kubernetes resources required to run the Jetstack
@@ -81,22 +85,30 @@ func ApplyOperatorYAML(ctx context.Context, applier Applier, options ApplyOperat
 		buf.WriteString("---\n")
 	}
 
-	var file io.Reader
-	var err error
+	source := options.Source
+	if source == nil {
+		source = EmbeddedManifests()
+	}
 
-	if options.Version == "" {
-		file, err = latestManifest()
-	} else {
-		file, err = manifestVersion(options.Version)
+	version := options.Version
+	if version == "" {
+		versions, err := source.Versions(ctx)
+		if err != nil {
+			return fmt.Errorf("error determining manifest version: %w", err)
+		}
+		if len(versions) == 0 {
+			return ErrNoManifest
+		}
+
+		version = versions[len(versions)-1]
 	}
 
+	manifest, err := source.Manifest(ctx, version)
 	if err != nil {
 		return fmt.Errorf("error determining manifest version: %w", err)
 	}
 
-	if _, err = io.Copy(buf, file); err != nil {
-		return fmt.Errorf("error reading manifest contents: %w", err)
-	}
+	buf.Write(manifest)
 
 	tpl, err := template.New("install").Parse(buf.String())
 	if err != nil {
@@ -111,72 +123,20 @@ func ApplyOperatorYAML(ctx context.Context, applier Applier, options ApplyOperat
 		return fmt.Errorf("error parsing manifest template: %w", err)
 	}
 
-	return applier.Apply(ctx, output)
-}
-
-func latestManifest() (io.Reader, error) {
-	versions, err := Versions()
+	patched, err := patch.ApplyYAML(output, options.Patches)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("error patching manifests: %w", err)
 	}
 
-	latest := versions[len(versions)-1]
-	name := latest + ".yaml"
-
-	return installers.Open(filepath.Join("installers", name))
+	return applier.Apply(ctx, patched)
 }
 
 // ErrNoManifest is the error given when querying a kubernetes manifest that doesn't exit.
 var ErrNoManifest = errors.New("no manifest")
 
-func manifestVersion(version string) (io.Reader, error) {
-	name := version + ".yaml"
-	file, err := installers.Open(filepath.Join("installers", name))
-	switch {
-	case errors.Is(err, os.ErrNotExist):
-		return nil, ErrNoManifest
-	case err != nil:
-		return nil, err
-	default:
-		return file, nil
-	}
-}
-
 // Versions returns all available versions of the jetstack operator ordered semantically.
 func Versions() ([]string, error) {
-	entries, err := installers.ReadDir("installers")
-	if err != nil {
-		return nil, err
-	}
-
-	rawVersions := make([]string, 0)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		rawVersion := strings.TrimSuffix(filepath.Base(entry.Name()), ".yaml")
-		rawVersions = append(rawVersions, rawVersion)
-	}
-
-	parsedVersions := make([]*semver.Version, len(rawVersions))
-	for i, rawVersion := range rawVersions {
-		parsedVersion, err := semver.NewVersion(rawVersion)
-		if err != nil {
-			return nil, err
-		}
-
-		parsedVersions[i] = parsedVersion
-	}
-
-	sort.Sort(semver.Collection(parsedVersions))
-
-	versions := make([]string, len(parsedVersions))
-	for i, parsedVersion := range parsedVersions {
-		versions[i] = "v" + parsedVersion.String()
-	}
-
-	return versions, nil
+	return EmbeddedManifests().Versions(context.Background())
 }
 
 // ErrNoKeyFile is the error given when generating an image pull secret for a key that does not exist.
@@ -204,6 +164,11 @@ type (
 		CertManagerVersion      string // The version of cert-manager to deploy
 		IstioCSRReplicas        int    // The replica count for the istio-csr component.
 		SpiffeCSIDriverReplicas int    // The replica count for the csi-driver-spiffe component.
+		// ComponentOverrides contains settings for individual components, keyed by component name. They take
+		// precedence over the replica counts and versions above.
+		ComponentOverrides map[string]ComponentOverride
+		// Patches are applied to the generated resources before they are applied.
+		Patches []*patch.Patch
 
 		// ImportedCertManagerIssuers is a list of cert-manager issuers to include in
 		// the generated installation file
@@ -292,12 +257,21 @@ func ApplyInstallationYAML(ctx context.Context, applier Applier, options ApplyIn
 		return fmt.Errorf("error adding issuers to installation: %w", err)
 	}
 
+	if err := applyComponentOverrides(manifestTemplates, options); err != nil {
+		return fmt.Errorf("error applying component overrides: %w", err)
+	}
+
 	buf, err := marshalManifests(manifestTemplates)
 	if err != nil {
 		return fmt.Errorf("error marshalling manifests: %w", err)
 	}
 
-	return applier.Apply(ctx, buf)
+	patched, err := patch.ApplyYAML(buf, options.Patches)
+	if err != nil {
+		return fmt.Errorf("error patching manifests: %w", err)
+	}
+
+	return applier.Apply(ctx, patched)
 }
 
 func addIssuersToInstallation(
@@ -439,7 +413,10 @@ func generateVenafiIssuerManifests(mf *manifests, options ApplyInstallationYAMLO
 		if err != nil {
 			return fmt.Errorf("error generating manifests for Venafi issuer: %w", err)
 		}
-		mf.secrets = append(mf.secrets, secret)
+		// issuers using an existing Secret for their credentials do not need one generating
+		if secret != nil {
+			mf.secrets = append(mf.secrets, secret)
+		}
 		mf.installation.Spec.Issuers = append(mf.installation.Spec.Issuers, issuer)
 	}
 
@@ -0,0 +1,182 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// unsupportedOverrideFields lists the component settings that users commonly expect to configure but that the
+// operator's Installation resource does not support. They are recognised only so that a useful error can be given,
+// rather than them being silently dropped.
+var unsupportedOverrideFields = []string{
+	"affinity",
+	"nodeSelector",
+	"podDisruptionBudget",
+	"priorityClassName",
+	"resources",
+	"tolerations",
+}
+
+// The ComponentOverride type contains the settings of a single component that can be set in the Installation, in
+// addition to those set via ApplyInstallationYAMLOptions.
+type ComponentOverride struct {
+	// Replicas is the number of instances of the component to run. The csi-driver component runs on every node and
+	// does not support it.
+	Replicas *int `json:"replicas,omitempty"`
+	// Version is the version of the component to install.
+	Version string `json:"version,omitempty"`
+}
+
+// ParseComponentOverrides parses YAML encoded component overrides, keyed by component name, e.g.
+//
+//	cert-manager:
+//	  replicas: 3
+//	  version: v1.11.0
+//	istio-csr:
+//	  replicas: 2
+//
+// Fields that are not supported by the Installation resource result in an error.
+func ParseComponentOverrides(data []byte) (map[string]ComponentOverride, error) {
+	raw := make(map[string]map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid component overrides: %w", err)
+	}
+
+	for component, fields := range raw {
+		for field := range fields {
+			for _, unsupported := range unsupportedOverrideFields {
+				if field == unsupported {
+					return nil, fmt.Errorf("component %s: %s cannot be set, the operator's Installation resource does not support it", component, field)
+				}
+			}
+		}
+	}
+
+	overrides := make(map[string]ComponentOverride)
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid component overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// ValidateComponentOverrides checks that each override is for a known component and that its values would be
+// accepted by the operator.
+func ValidateComponentOverrides(overrides map[string]ComponentOverride) error {
+	components := make([]string, 0, len(overrides))
+	for component := range overrides {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	for _, component := range components {
+		override := overrides[component]
+
+		if _, ok := componentImages[component]; !ok {
+			return fmt.Errorf("unknown component %q, valid components are: %s", component, strings.Join(Components(), ", "))
+		}
+
+		if override.Replicas != nil {
+			if component == "csi-driver" {
+				return fmt.Errorf("component %s: replicas cannot be set, the CSI driver runs on every node", component)
+			}
+			if *override.Replicas < 1 {
+				return fmt.Errorf("component %s: replicas must be at least 1, got %d", component, *override.Replicas)
+			}
+		}
+
+		if override.Version != "" {
+			if !strings.HasPrefix(override.Version, "v") {
+				return fmt.Errorf("component %s: version %q must begin with 'v'", component, override.Version)
+			}
+			if _, err := semver.NewVersion(override.Version); err != nil {
+				return fmt.Errorf("component %s: invalid version %q: %w", component, override.Version, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyComponentOverrides sets the overrides on the corresponding component of the Installation. Overriding a
+// component that is not enabled is an error, as the operator would not deploy it.
+func applyComponentOverrides(manifests *manifests, options ApplyInstallationYAMLOptions) error {
+	if err := ValidateComponentOverrides(options.ComponentOverrides); err != nil {
+		return err
+	}
+
+	spec := &manifests.installation.Spec
+	for component, override := range options.ComponentOverrides {
+		var version *string
+		var replicas **int
+
+		switch component {
+		case "cert-manager":
+			version = &spec.CertManager.Version
+			if override.Replicas != nil {
+				spec.CertManager.Controller.ReplicaCount = override.Replicas
+				spec.CertManager.Webhook.ReplicaCount = override.Replicas
+			}
+		case "approver-policy":
+			if spec.ApproverPolicy != nil {
+				version, replicas = &spec.ApproverPolicy.Version, &spec.ApproverPolicy.ReplicaCount
+			}
+		case "approver-policy-enterprise":
+			if spec.ApproverPolicyEnterprise != nil {
+				version, replicas = &spec.ApproverPolicyEnterprise.Version, &spec.ApproverPolicyEnterprise.ReplicaCount
+			}
+		case "csi-driver":
+			if spec.CSIDrivers != nil && spec.CSIDrivers.CertManager != nil {
+				version = &spec.CSIDrivers.CertManager.Version
+			}
+		case "csi-driver-spiffe":
+			if spec.CSIDrivers != nil && spec.CSIDrivers.CertManagerSpiffe != nil {
+				version, replicas = &spec.CSIDrivers.CertManagerSpiffe.Version, &spec.CSIDrivers.CertManagerSpiffe.ReplicaCount
+			}
+		case "istio-csr":
+			if spec.IstioCSR != nil {
+				version, replicas = &spec.IstioCSR.Version, &spec.IstioCSR.ReplicaCount
+			}
+		case "trust-manager":
+			// trust-manager is deployed by default, so can be configured without being enabled explicitly
+			if spec.TrustManager == nil {
+				spec.TrustManager = &operatorv1alpha1.TrustManager{}
+			}
+			version, replicas = &spec.TrustManager.Version, &spec.TrustManager.ReplicaCount
+		case "venafi-oauth-helper":
+			if spec.VenafiOauthHelper != nil {
+				version, replicas = &spec.VenafiOauthHelper.Version, &spec.VenafiOauthHelper.ReplicaCount
+			}
+		case "cert-discovery-venafi":
+			if spec.CertDiscoveryVenafi != nil {
+				version, replicas = &spec.CertDiscoveryVenafi.Version, &spec.CertDiscoveryVenafi.ReplicaCount
+			}
+		case "venafi-enhanced-issuer":
+			// venafi-enhanced-issuer is deployed when any issuer uses it
+			if _, ok := installationComponents(manifests.installation)[component]; ok && spec.VenafiEnhancedIssuer == nil {
+				spec.VenafiEnhancedIssuer = &operatorv1alpha1.VenafiEnhancedIssuer{}
+			}
+			if spec.VenafiEnhancedIssuer != nil {
+				version, replicas = &spec.VenafiEnhancedIssuer.Version, &spec.VenafiEnhancedIssuer.ReplicaCount
+			}
+		}
+
+		if version == nil {
+			return fmt.Errorf("component %s is not enabled in the installation, so cannot be overridden", component)
+		}
+
+		if override.Version != "" {
+			*version = override.Version
+		}
+		if override.Replicas != nil && replicas != nil {
+			*replicas = override.Replicas
+		}
+	}
+
+	return nil
+}
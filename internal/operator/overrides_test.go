@@ -0,0 +1,145 @@
+package operator_test
+
+import (
+	"context"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestParseComponentOverrides(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should parse replicas and versions", func(t *testing.T) {
+		overrides, err := operator.ParseComponentOverrides([]byte(`
+cert-manager:
+  replicas: 3
+  version: v1.11.0
+istio-csr:
+  replicas: 1
+`))
+		require.NoError(t, err)
+
+		if assert.NotNil(t, overrides["cert-manager"].Replicas) {
+			assert.Equal(t, 3, *overrides["cert-manager"].Replicas)
+		}
+		assert.Equal(t, "v1.11.0", overrides["cert-manager"].Version)
+		assert.Empty(t, overrides["istio-csr"].Version)
+	})
+
+	t.Run("It should reject settings the Installation does not support", func(t *testing.T) {
+		_, err := operator.ParseComponentOverrides([]byte(`
+cert-manager:
+  tolerations:
+  - key: dedicated
+    operator: Exists
+`))
+		assert.ErrorContains(t, err, "tolerations cannot be set")
+	})
+
+	t.Run("It should reject unknown fields", func(t *testing.T) {
+		_, err := operator.ParseComponentOverrides([]byte(`
+cert-manager:
+  replica: 3
+`))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateComponentOverrides(t *testing.T) {
+	t.Parallel()
+
+	zero := 0
+	two := 2
+
+	tcs := map[string]struct {
+		overrides map[string]operator.ComponentOverride
+		valid     bool
+	}{
+		"valid overrides": {
+			overrides: map[string]operator.ComponentOverride{
+				"cert-manager": {Replicas: &two, Version: "v1.11.0"},
+				"csi-driver":   {Version: "v0.5.0"},
+			},
+			valid: true,
+		},
+		"unknown component": {
+			overrides: map[string]operator.ComponentOverride{"cert-manager-operator": {Replicas: &two}},
+		},
+		"zero replicas": {
+			overrides: map[string]operator.ComponentOverride{"istio-csr": {Replicas: &zero}},
+		},
+		"replicas for the csi-driver": {
+			overrides: map[string]operator.ComponentOverride{"csi-driver": {Replicas: &two}},
+		},
+		"version without a v prefix": {
+			overrides: map[string]operator.ComponentOverride{"trust-manager": {Version: "0.4.0"}},
+		},
+		"invalid version": {
+			overrides: map[string]operator.ComponentOverride{"trust-manager": {Version: "vlatest"}},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			err := operator.ValidateComponentOverrides(tc.overrides)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestApplyInstallationYAML_ComponentOverrides(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	three := 3
+
+	t.Run("It should set overrides on enabled components", func(t *testing.T) {
+		applier := &TestApplier{}
+		options := operator.ApplyInstallationYAMLOptions{
+			CertManagerReplicas: 2,
+			InstallIstioCSR:     true,
+			IstioCSRReplicas:    2,
+			ComponentOverrides: map[string]operator.ComponentOverride{
+				"cert-manager":  {Replicas: &three},
+				"istio-csr":     {Version: "v0.6.0"},
+				"trust-manager": {Replicas: &three, Version: "v0.4.0"},
+			},
+		}
+
+		err := operator.ApplyInstallationYAML(ctx, applier, options)
+		require.NoError(t, err)
+
+		var actual operatorv1alpha1.Installation
+		require.NoError(t, yaml.Unmarshal(applier.data.Bytes(), &actual))
+
+		assert.Equal(t, 3, *actual.Spec.CertManager.Controller.ReplicaCount)
+		assert.Equal(t, 3, *actual.Spec.CertManager.Webhook.ReplicaCount)
+		assert.Equal(t, "v0.6.0", actual.Spec.IstioCSR.Version)
+		assert.Equal(t, 2, *actual.Spec.IstioCSR.ReplicaCount)
+		if assert.NotNil(t, actual.Spec.TrustManager) {
+			assert.Equal(t, 3, *actual.Spec.TrustManager.ReplicaCount)
+			assert.Equal(t, "v0.4.0", actual.Spec.TrustManager.Version)
+		}
+	})
+
+	t.Run("It should return an error for components that are not enabled", func(t *testing.T) {
+		options := operator.ApplyInstallationYAMLOptions{
+			ComponentOverrides: map[string]operator.ComponentOverride{
+				"istio-csr": {Replicas: &three},
+			},
+		}
+
+		err := operator.ApplyInstallationYAML(ctx, &TestApplier{}, options)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,384 @@
+package operator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/jetstack/jsctl/internal/registry"
+)
+
+const (
+	// ChecksumsFile is the name of the file that lists the SHA-256 checksum of each installer manifest in a manifest
+	// source, in the format produced by sha256sum.
+	ChecksumsFile = "SHA256SUMS"
+	// SignatureFile is the name of the file that contains the base64 encoded ed25519 signature of ChecksumsFile.
+	SignatureFile = ChecksumsFile + ".sig"
+)
+
+// ErrManifestVerification is the error given when an installer manifest, or the checksums that describe it, cannot be
+// verified.
+var ErrManifestVerification = errors.New("manifest verification failed")
+
+// ErrNoPublicKey is the error given when a remote manifest source is used without a public key to verify it, and
+// verification has not been explicitly skipped.
+var ErrNoPublicKey = errors.New("a public key is required to verify manifests fetched from a remote source")
+
+type (
+	// The ManifestSource interface describes types that provide the installer manifests for versions of the Jetstack
+	// Secure operator. Manifests are templates, in the same format as those embedded in jsctl.
+	ManifestSource interface {
+		// Versions returns all versions of the operator available in the source, ordered semantically.
+		Versions(ctx context.Context) ([]string, error)
+		// Manifest returns the installer manifest for a version of the operator. ErrNoManifest is returned if the
+		// source does not contain the version.
+		Manifest(ctx context.Context, version string) ([]byte, error)
+	}
+
+	// The ManifestSourceOptions type contains fields used to configure the verification of manifest sources other
+	// than the embedded manifests.
+	ManifestSourceOptions struct {
+		// PublicKey is used to verify the signature of the source's checksums. It is required for https:// and oci://
+		// sources unless InsecureSkipVerification is set. If nil, checksums are still used to verify each manifest,
+		// but the checksums themselves are not verified, which only guards against corrupted downloads as the
+		// checksums are read from the same location as the manifests.
+		PublicKey ed25519.PublicKey
+		// InsecureSkipVerification allows https:// and oci:// sources to be used without a PublicKey.
+		InsecureSkipVerification bool
+		// HTTPClient is used for sources fetched via HTTPS.
+		HTTPClient *http.Client
+		// OCIClient is used for sources stored as OCI artifacts.
+		OCIClient *registry.OCIClient
+		// RegistryCredentials, if set, are used to pull sources stored as OCI artifacts when OCIClient is not set.
+		RegistryCredentials *registry.Credentials
+	}
+
+	embeddedSource struct{}
+
+	// verifiedSource is a ManifestSource that reads files from a fileStore and verifies each manifest against the
+	// store's checksums file, whose signature is verified if a public key is configured. The checksums are read once
+	// and used for the lifetime of the source.
+	verifiedSource struct {
+		store     fileStore
+		publicKey ed25519.PublicKey
+
+		mu     sync.Mutex
+		parsed map[string]string
+	}
+
+	// fileStore is a location containing installer manifests along with ChecksumsFile and, optionally, SignatureFile.
+	fileStore interface {
+		// open returns the contents of the named file, errors wrap os.ErrNotExist if the file does not exist.
+		open(ctx context.Context, name string) ([]byte, error)
+	}
+
+	directoryStore struct {
+		fs fs.FS
+	}
+
+	httpStore struct {
+		client  *http.Client
+		baseURL *url.URL
+	}
+
+	ociStore struct {
+		client    *registry.OCIClient
+		reference string
+	}
+)
+
+// EmbeddedManifests returns the ManifestSource for the installer manifests compiled into jsctl. It is the default
+// source used when none is specified.
+func EmbeddedManifests() ManifestSource {
+	return embeddedSource{}
+}
+
+// NewManifestSource returns a ManifestSource for the given location. Locations beginning with https:// are fetched
+// from a web server, locations beginning with oci:// are pulled as an OCI artifact whose layers are named by their
+// title annotation, and any other location is treated as a local directory. In each case, the location must
+// contain ChecksumsFile alongside one <version>.yaml file per operator version. Remote sources must be verified with
+// a public key, otherwise ErrNoPublicKey is returned unless options.InsecureSkipVerification is set.
+func NewManifestSource(location string, options ManifestSourceOptions) (ManifestSource, error) {
+	var store fileStore
+
+	remote := strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "oci://")
+	if remote && options.PublicKey == nil && !options.InsecureSkipVerification {
+		return nil, ErrNoPublicKey
+	}
+
+	switch {
+	case strings.HasPrefix(location, "https://"):
+		baseURL, err := url.Parse(strings.TrimSuffix(location, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest source URL: %w", err)
+		}
+
+		client := options.HTTPClient
+		if client == nil {
+			client = &http.Client{Timeout: time.Minute}
+		}
+
+		store = &httpStore{client: client, baseURL: baseURL}
+	case strings.HasPrefix(location, "http://"):
+		return nil, errors.New("manifest sources must be fetched using https")
+	case strings.HasPrefix(location, "oci://"):
+		reference := strings.TrimPrefix(location, "oci://")
+
+		client := options.OCIClient
+		if client == nil {
+			var clientOptions registry.OCIClientOptions
+			if options.RegistryCredentials != nil {
+				host, _, _ := strings.Cut(reference, "/")
+				clientOptions.Credentials = map[string]registry.Credentials{host: *options.RegistryCredentials}
+			}
+			client = registry.NewOCIClient(clientOptions)
+		}
+
+		store = &ociStore{client: client, reference: reference}
+	default:
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest source directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("manifest source %s is not a directory", location)
+		}
+
+		store = &directoryStore{fs: os.DirFS(location)}
+	}
+
+	return &verifiedSource{store: store, publicKey: options.PublicKey}, nil
+}
+
+// ParsePublicKey parses a PEM encoded ed25519 public key, such as one generated by
+// 'openssl genpkey -algorithm ed25519', for use in verifying manifest sources.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key must be an ed25519 key, got %T", key)
+	}
+
+	return publicKey, nil
+}
+
+func (embeddedSource) Versions(_ context.Context) ([]string, error) {
+	entries, err := installers.ReadDir("installers")
+	if err != nil {
+		return nil, err
+	}
+
+	rawVersions := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		rawVersion := strings.TrimSuffix(filepath.Base(entry.Name()), ".yaml")
+		rawVersions = append(rawVersions, rawVersion)
+	}
+
+	return sortVersions(rawVersions)
+}
+
+func (embeddedSource) Manifest(_ context.Context, version string) ([]byte, error) {
+	data, err := installers.ReadFile(path.Join("installers", version+".yaml"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, ErrNoManifest
+	case err != nil:
+		return nil, err
+	default:
+		return data, nil
+	}
+}
+
+func (s *verifiedSource) Versions(ctx context.Context) ([]string, error) {
+	checksums, err := s.checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawVersions := make([]string, 0, len(checksums))
+	for name := range checksums {
+		if strings.HasSuffix(name, ".yaml") {
+			rawVersions = append(rawVersions, strings.TrimSuffix(name, ".yaml"))
+		}
+	}
+
+	return sortVersions(rawVersions)
+}
+
+func (s *verifiedSource) Manifest(ctx context.Context, version string) ([]byte, error) {
+	checksums, err := s.checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := version + ".yaml"
+	expected, ok := checksums[name]
+	if !ok {
+		return nil, ErrNoManifest
+	}
+
+	data, err := s.store.open(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", name, err)
+	}
+
+	actual := sha256.Sum256(data)
+	if hex.EncodeToString(actual[:]) != expected {
+		return nil, fmt.Errorf("%w: checksum of %s does not match %s", ErrManifestVerification, name, ChecksumsFile)
+	}
+
+	return data, nil
+}
+
+// checksums returns the checksums of the store, reading them the first time they are needed. The returned map is
+// keyed by file name.
+func (s *verifiedSource) checksums(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.parsed != nil {
+		return s.parsed, nil
+	}
+
+	checksums, err := s.readChecksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.parsed = checksums
+
+	return checksums, nil
+}
+
+// readChecksums reads the checksums file of the store, verifying its signature if a public key is configured.
+func (s *verifiedSource) readChecksums(ctx context.Context) (map[string]string, error) {
+	data, err := s.store.open(ctx, ChecksumsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ChecksumsFile, err)
+	}
+
+	if s.publicKey != nil {
+		encoded, err := s.store.open(ctx, SignatureFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", SignatureFile, err)
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid signature encoding: %s", ErrManifestVerification, err)
+		}
+
+		if !ed25519.Verify(s.publicKey, data, signature) {
+			return nil, fmt.Errorf("%w: signature of %s is not valid for the provided public key", ErrManifestVerification, ChecksumsFile)
+		}
+	}
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: invalid line in %s: %q", ErrManifestVerification, ChecksumsFile, scanner.Text())
+		}
+
+		// sha256sum prefixes file names with '*' when run in binary mode
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	return checksums, scanner.Err()
+}
+
+func (s *directoryStore) open(_ context.Context, name string) ([]byte, error) {
+	return fs.ReadFile(s.fs, name)
+}
+
+func (s *httpStore) open(ctx context.Context, name string) ([]byte, error) {
+	u := s.baseURL.JoinPath(name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", u, os.ErrNotExist)
+	default:
+		return nil, fmt.Errorf("unexpected response fetching %s: %s", u, resp.Status)
+	}
+}
+
+func (s *ociStore) open(ctx context.Context, name string) ([]byte, error) {
+	data, err := s.client.PullArtifactFile(ctx, s.reference, name)
+	if errors.Is(err, registry.ErrArtifactFileNotFound) {
+		return nil, fmt.Errorf("%s: %w", err, os.ErrNotExist)
+	}
+
+	return data, err
+}
+
+// sortVersions parses the raw versions as semantic versions and returns them in ascending order.
+func sortVersions(rawVersions []string) ([]string, error) {
+	parsedVersions := make([]*semver.Version, len(rawVersions))
+	for i, rawVersion := range rawVersions {
+		parsedVersion, err := semver.NewVersion(rawVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		parsedVersions[i] = parsedVersion
+	}
+
+	sort.Sort(semver.Collection(parsedVersions))
+
+	versions := make([]string, len(parsedVersions))
+	for i, parsedVersion := range parsedVersions {
+		versions[i] = "v" + parsedVersion.String()
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,221 @@
+package operator_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+const testManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: js-operator
+spec:
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: {{ .ImageRegistry }}/js-operator:v0.0.2
+`
+
+// writeManifestSource writes the files of a manifest source containing versions v0.0.1 and v0.0.2 to dir, signing
+// the checksums with key if it is not nil.
+func writeManifestSource(t *testing.T, dir string, key ed25519.PrivateKey) {
+	checksums := bytes.NewBuffer([]byte{})
+	for _, version := range []string{"v0.0.2", "v0.0.1"} {
+		data := []byte(testManifest)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version+".yaml"), data, 0644))
+		fmt.Fprintf(checksums, "%x  %s.yaml\n", sha256.Sum256(data), version)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, operator.ChecksumsFile), checksums.Bytes(), 0644))
+
+	if key != nil {
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, checksums.Bytes()))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, operator.SignatureFile), []byte(signature), 0644))
+	}
+}
+
+func TestManifestSource(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("It should read manifests from a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, nil)
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{})
+		require.NoError(t, err)
+
+		versions, err := source.Versions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.0.1", "v0.0.2"}, versions)
+
+		manifest, err := source.Manifest(ctx, "v0.0.2")
+		require.NoError(t, err)
+		assert.Equal(t, testManifest, string(manifest))
+
+		_, err = source.Manifest(ctx, "v0.0.3")
+		assert.ErrorIs(t, err, operator.ErrNoManifest)
+	})
+
+	t.Run("It should reject manifests that do not match their checksum", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, nil)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "v0.0.2.yaml"), []byte("tampered"), 0644))
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{})
+		require.NoError(t, err)
+
+		_, err = source.Manifest(ctx, "v0.0.2")
+		assert.ErrorIs(t, err, operator.ErrManifestVerification)
+	})
+
+	t.Run("It should verify the signature of the checksums", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, privateKey)
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{PublicKey: publicKey})
+		require.NoError(t, err)
+
+		_, err = source.Manifest(ctx, "v0.0.1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("It should reject checksums signed by a different key", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		writeManifestSource(t, dir, otherKey)
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{PublicKey: publicKey})
+		require.NoError(t, err)
+
+		_, err = source.Versions(ctx)
+		assert.ErrorIs(t, err, operator.ErrManifestVerification)
+	})
+
+	t.Run("It should require a signature when a public key is provided", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, nil)
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{PublicKey: publicKey})
+		require.NoError(t, err)
+
+		_, err = source.Versions(ctx)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("It should fetch manifests over https", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, privateKey)
+
+		server := httptest.NewTLSServer(http.StripPrefix("/manifests/", http.FileServer(http.Dir(dir))))
+		defer server.Close()
+
+		source, err := operator.NewManifestSource(server.URL+"/manifests", operator.ManifestSourceOptions{
+			PublicKey:  publicKey,
+			HTTPClient: server.Client(),
+		})
+		require.NoError(t, err)
+
+		manifest, err := source.Manifest(ctx, "v0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, testManifest, string(manifest))
+	})
+
+	t.Run("It should require a public key for remote sources", func(t *testing.T) {
+		for _, location := range []string{"https://example.com/manifests", "oci://example.com/manifests:latest"} {
+			_, err := operator.NewManifestSource(location, operator.ManifestSourceOptions{})
+			assert.ErrorIs(t, err, operator.ErrNoPublicKey)
+		}
+	})
+
+	t.Run("It should read the checksums of a source once", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, nil)
+
+		var checksumRequests int32
+		files := http.StripPrefix("/manifests/", http.FileServer(http.Dir(dir)))
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/manifests/"+operator.ChecksumsFile {
+				atomic.AddInt32(&checksumRequests, 1)
+			}
+			files.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		source, err := operator.NewManifestSource(server.URL+"/manifests", operator.ManifestSourceOptions{
+			InsecureSkipVerification: true,
+			HTTPClient:               server.Client(),
+		})
+		require.NoError(t, err)
+
+		_, err = source.Versions(ctx)
+		require.NoError(t, err)
+		for _, version := range []string{"v0.0.1", "v0.0.2"} {
+			_, err = source.Manifest(ctx, version)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&checksumRequests))
+	})
+
+	t.Run("It should not allow manifests to be fetched over plain http", func(t *testing.T) {
+		_, err := operator.NewManifestSource("http://example.com/manifests", operator.ManifestSourceOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("It should install the latest version from the source", func(t *testing.T) {
+		dir := t.TempDir()
+		writeManifestSource(t, dir, nil)
+
+		source, err := operator.NewManifestSource(dir, operator.ManifestSourceOptions{})
+		require.NoError(t, err)
+
+		applier := &TestApplier{}
+		err = operator.ApplyOperatorYAML(ctx, applier, operator.ApplyOperatorYAMLOptions{
+			ImageRegistry: "example.com",
+			Source:        source,
+		})
+		require.NoError(t, err)
+		assert.Contains(t, applier.data.String(), "image: example.com/js-operator:v0.0.2")
+	})
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	parsed, err := operator.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, parsed)
+
+	_, err = operator.ParsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,780 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JetstackSecureEnterpriseRegistryHost is the host of the Jetstack Secure Enterprise registry, images hosted there
+// are pulled using the credentials returned by FetchOrLoadJetstackSecureEnterpriseRegistryCredentials.
+const JetstackSecureEnterpriseRegistryHost = "eu.gcr.io"
+
+const (
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// manifestMediaTypes are the manifest types that can be copied between registries, in order of preference.
+var manifestMediaTypes = []string{
+	mediaTypeOCIIndex,
+	mediaTypeDockerManifestList,
+	mediaTypeOCIManifest,
+	mediaTypeDockerManifest,
+}
+
+type (
+	// The Credentials type contains the username and password used to authenticate with a registry.
+	Credentials struct {
+		Username string
+		Password string
+	}
+
+	// The OCIClientOptions type contains fields used to configure an OCIClient.
+	OCIClientOptions struct {
+		// HTTPClient is used to perform requests, defaults to a client with a generous timeout for large layers.
+		HTTPClient *http.Client
+		// Credentials maps registry hosts to the credentials used to authenticate with them. Registries without
+		// credentials are accessed anonymously.
+		Credentials map[string]Credentials
+		// PlainHTTP lists registry hosts that are accessed over HTTP rather than HTTPS.
+		PlainHTTP []string
+	}
+
+	// The OCIClient type is a minimal client for the OCI distribution API, used to copy images between registries
+	// without requiring a container runtime.
+	OCIClient struct {
+		http        *http.Client
+		credentials map[string]Credentials
+		plainHTTP   map[string]bool
+
+		mu         sync.Mutex
+		challenges map[string]authChallenge
+		tokens     map[string]bearerToken
+	}
+
+	// The CopyResult type describes the outcome of copying a single image.
+	CopyResult struct {
+		Source      string // The source image reference
+		Destination string // The destination image reference
+		Digest      string // The digest of the copied manifest, identical in the source and destination
+		Skipped     bool   // True if the destination already contained the image
+	}
+
+	// imageReference is a parsed image reference. Reference is either a tag or a digest.
+	imageReference struct {
+		Host       string
+		Repository string
+		Reference  string
+	}
+
+	// bearerToken is a token issued by the token service of a registry, along with the time it should no longer be
+	// used by.
+	bearerToken struct {
+		value   string
+		expires time.Time
+	}
+
+	// authChallenge is the parsed WWW-Authenticate header returned by a registry for unauthenticated requests.
+	authChallenge struct {
+		Scheme  string
+		Realm   string
+		Service string
+	}
+
+	// manifest contains the fields of image manifests and indexes needed to find the content they reference.
+	manifest struct {
+		MediaType string       `json:"mediaType"`
+		Manifests []descriptor `json:"manifests"`
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+	}
+
+	descriptor struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Size        int64             `json:"size"`
+		URLs        []string          `json:"urls"`
+		Annotations map[string]string `json:"annotations"`
+	}
+)
+
+// annotationTitle is the annotation used to name the files stored as layers of an OCI artifact, as set by tools such
+// as oras.
+const annotationTitle = "org.opencontainers.image.title"
+
+const (
+	// defaultTokenLifetime is the lifetime of bearer tokens whose token response does not include expires_in, the
+	// distribution token specification requires tokens to be valid for at least this long.
+	defaultTokenLifetime = 60 * time.Second
+	// tokenExpiryMargin is how long before they expire bearer tokens are refreshed, so that they do not expire while
+	// a request is in flight.
+	tokenExpiryMargin = 10 * time.Second
+)
+
+// ErrArtifactFileNotFound is the error given when an OCI artifact does not contain a requested file.
+var ErrArtifactFileNotFound = errors.New("file not found in artifact")
+
+// NewOCIClient returns a new OCIClient configured using the provided options.
+func NewOCIClient(options OCIClientOptions) *OCIClient {
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Minute}
+	}
+
+	plainHTTP := make(map[string]bool)
+	for _, host := range options.PlainHTTP {
+		plainHTTP[host] = true
+	}
+
+	credentials := options.Credentials
+	if credentials == nil {
+		credentials = make(map[string]Credentials)
+	}
+
+	return &OCIClient{
+		http:        httpClient,
+		credentials: credentials,
+		plainHTTP:   plainHTTP,
+		challenges:  make(map[string]authChallenge),
+		tokens:      make(map[string]bearerToken),
+	}
+}
+
+// JetstackSecureEnterpriseCredentials returns the credentials used to pull images from the Jetstack Secure Enterprise
+// registry, given the key data returned by FetchOrLoadJetstackSecureEnterpriseRegistryCredentials.
+func JetstackSecureEnterpriseCredentials(keyData []byte) Credentials {
+	return Credentials{
+		Username: "_json_key",
+		Password: string(keyData),
+	}
+}
+
+// Copy copies the image at the source reference to the destination reference, including all platforms of
+// multi-arch images. Manifests and blobs that already exist in the destination are not copied again. Once pushed,
+// the destination is checked to resolve to the same digest as the source.
+func (c *OCIClient) Copy(ctx context.Context, source, destination string) (CopyResult, error) {
+	result := CopyResult{Source: source, Destination: destination}
+
+	src, err := parseImageReference(source)
+	if err != nil {
+		return result, err
+	}
+	dst, err := parseImageReference(destination)
+	if err != nil {
+		return result, err
+	}
+
+	// if the destination tag already resolves to the source digest then there's nothing to do
+	body, mediaType, err := c.getManifest(ctx, src)
+	if err != nil {
+		return result, fmt.Errorf("failed to get manifest for %s: %w", source, err)
+	}
+	result.Digest = digestOf(body)
+
+	existing, err := c.manifestDigest(ctx, dst)
+	if err != nil {
+		return result, fmt.Errorf("failed to check for existing manifest %s: %w", destination, err)
+	}
+	if existing == result.Digest {
+		result.Skipped = true
+		return result, nil
+	}
+
+	if err := c.copyManifestContent(ctx, src, dst, body); err != nil {
+		return result, err
+	}
+
+	if err := c.putManifest(ctx, dst, body, mediaType); err != nil {
+		return result, fmt.Errorf("failed to push manifest %s: %w", destination, err)
+	}
+
+	pushed, err := c.manifestDigest(ctx, dst)
+	if err != nil {
+		return result, fmt.Errorf("failed to verify manifest %s: %w", destination, err)
+	}
+	if pushed != result.Digest {
+		return result, fmt.Errorf("digest mismatch after pushing %s: expected %s, got %s", destination, result.Digest, pushed)
+	}
+
+	return result, nil
+}
+
+// PullArtifactFile returns the contents of the file with the given name from the OCI artifact at the reference. Files
+// are the layers of the artifact, named by their title annotation. The contents are checked against the digest
+// recorded in the artifact's manifest. ErrArtifactFileNotFound is returned if the artifact has no such file.
+func (c *OCIClient) PullArtifactFile(ctx context.Context, reference, name string) ([]byte, error) {
+	ref, err := parseImageReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := c.getManifest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest for %s: %w", reference, err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest for %s: %w", reference, err)
+	}
+
+	for _, layer := range m.Layers {
+		if layer.Annotations[annotationTitle] != name {
+			continue
+		}
+
+		req, err := c.newRequest(ctx, http.MethodGet, ref, "blobs/"+layer.Digest, nil, "pull")
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.do(ctx, req, ref, "pull")
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, responseError(resp)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if digestOf(data) != layer.Digest {
+			return nil, fmt.Errorf("digest mismatch for %s in %s: expected %s, got %s", name, reference, layer.Digest, digestOf(data))
+		}
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s in %s", ErrArtifactFileNotFound, name, reference)
+}
+
+// copyManifestContent copies everything referenced by the manifest body from the source repository to the
+// destination repository. For indexes this is each platform manifest, for image manifests it is the config and
+// layer blobs.
+func (c *OCIClient) copyManifestContent(ctx context.Context, src, dst imageReference, body []byte) error {
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("failed to parse manifest for %s: %w", src, err)
+	}
+
+	for _, child := range m.Manifests {
+		childSrc := src.withReference(child.Digest)
+		childDst := dst.withReference(child.Digest)
+
+		existing, err := c.manifestDigest(ctx, childDst)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing manifest %s: %w", childDst, err)
+		}
+		if existing == child.Digest {
+			continue
+		}
+
+		childBody, childMediaType, err := c.getManifest(ctx, childSrc)
+		if err != nil {
+			return fmt.Errorf("failed to get manifest for %s: %w", childSrc, err)
+		}
+		if digestOf(childBody) != child.Digest {
+			return fmt.Errorf("digest mismatch for %s: got %s", childSrc, digestOf(childBody))
+		}
+
+		if err := c.copyManifestContent(ctx, childSrc, childDst, childBody); err != nil {
+			return err
+		}
+
+		if err := c.putManifest(ctx, childDst, childBody, childMediaType); err != nil {
+			return fmt.Errorf("failed to push manifest %s: %w", childDst, err)
+		}
+	}
+
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]descriptor{*m.Config}, blobs...)
+	}
+
+	for _, blob := range blobs {
+		// non-distributable layers are fetched from their URLs at pull time and cannot be pushed
+		if len(blob.URLs) > 0 {
+			continue
+		}
+
+		if err := c.copyBlob(ctx, src, dst, blob); err != nil {
+			return fmt.Errorf("failed to copy blob %s to %s: %w", blob.Digest, dst, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *OCIClient) getManifest(ctx context.Context, ref imageReference) ([]byte, string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, ref, "manifests/"+ref.Reference, nil, "pull")
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	resp, err := c.do(ctx, req, ref, "pull")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", responseError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType == "" {
+		var m manifest
+		if err := json.Unmarshal(body, &m); err == nil {
+			mediaType = m.MediaType
+		}
+	}
+
+	if strings.HasPrefix(ref.Reference, "sha256:") && digestOf(body) != ref.Reference {
+		return nil, "", fmt.Errorf("digest mismatch: expected %s, got %s", ref.Reference, digestOf(body))
+	}
+
+	return body, mediaType, nil
+}
+
+// manifestDigest returns the digest of the manifest at the reference, or a blank string if it does not exist.
+func (c *OCIClient) manifestDigest(ctx context.Context, ref imageReference) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, ref, "manifests/"+ref.Reference, nil, "pull")
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	resp, err := c.do(ctx, req, ref, "pull")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header.Get("Docker-Content-Digest"), nil
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", responseError(resp)
+	}
+}
+
+func (c *OCIClient) putManifest(ctx context.Context, ref imageReference, body []byte, mediaType string) error {
+	req, err := c.newRequest(ctx, http.MethodPut, ref, "manifests/"+ref.Reference, bytes.NewReader(body), "pull,push")
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := c.do(ctx, req, ref, "pull,push")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" && digest != digestOf(body) {
+		return fmt.Errorf("registry reported digest %s, expected %s", digest, digestOf(body))
+	}
+
+	return nil
+}
+
+func (c *OCIClient) copyBlob(ctx context.Context, src, dst imageReference, blob descriptor) error {
+	req, err := c.newRequest(ctx, http.MethodHead, dst, "blobs/"+blob.Digest, nil, "pull,push")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+	default:
+		return responseError(resp)
+	}
+
+	req, err = c.newRequest(ctx, http.MethodGet, src, "blobs/"+blob.Digest, nil, "pull")
+	if err != nil {
+		return err
+	}
+
+	blobResp, err := c.do(ctx, req, src, "pull")
+	if err != nil {
+		return err
+	}
+	defer blobResp.Body.Close()
+
+	if blobResp.StatusCode != http.StatusOK {
+		return responseError(blobResp)
+	}
+
+	req, err = c.newRequest(ctx, http.MethodPost, dst, "blobs/uploads/", nil, "pull,push")
+	if err != nil {
+		return err
+	}
+
+	uploadResp, err := c.do(ctx, req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	uploadResp.Body.Close()
+
+	if uploadResp.StatusCode != http.StatusAccepted {
+		return responseError(uploadResp)
+	}
+
+	location, err := uploadResp.Request.URL.Parse(uploadResp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location: %w", err)
+	}
+
+	query := location.Query()
+	query.Set("digest", blob.Digest)
+	location.RawQuery = query.Encode()
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, location.String(), blobResp.Body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = blobResp.ContentLength
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := c.authorize(ctx, req, dst, "pull,push"); err != nil {
+		return err
+	}
+
+	putResp, err := c.do(ctx, req, dst, "pull,push")
+	if err != nil {
+		return err
+	}
+	defer putResp.Body.Close()
+
+	if putResp.StatusCode != http.StatusCreated {
+		return responseError(putResp)
+	}
+
+	return nil
+}
+
+// newRequest builds an authorized request for the given path within the repository of the image reference.
+func (c *OCIClient) newRequest(ctx context.Context, method string, ref imageReference, suffix string, body io.Reader, actions string) (*http.Request, error) {
+	scheme := "https"
+	if c.plainHTTP[ref.Host] {
+		scheme = "http"
+	}
+
+	u := url.URL{
+		Scheme: scheme,
+		Host:   ref.Host,
+		Path:   path.Join("/v2", ref.Repository, suffix),
+	}
+	// path.Join removes the trailing slash that is required when starting uploads
+	if strings.HasSuffix(suffix, "/") {
+		u.Path += "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.authorize(ctx, req, ref, actions); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// authorize adds the authorization header required by the registry to the request. The registry is first asked how
+// clients should authenticate, then either basic credentials or a bearer token scoped to the repository are used.
+func (c *OCIClient) authorize(ctx context.Context, req *http.Request, ref imageReference, actions string) error {
+	challenge, err := c.challenge(ctx, ref.Host)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with %s: %w", ref.Host, err)
+	}
+
+	credentials, hasCredentials := c.credentials[ref.Host]
+
+	switch strings.ToLower(challenge.Scheme) {
+	case "":
+		return nil
+	case "basic":
+		if hasCredentials {
+			req.SetBasicAuth(credentials.Username, credentials.Password)
+		}
+		return nil
+	case "bearer":
+		token, err := c.token(ctx, ref.Host, challenge, tokenScope(ref, actions))
+		if err != nil {
+			return fmt.Errorf("failed to authenticate with %s: %w", ref.Host, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	default:
+		return fmt.Errorf("registry %s requested unsupported authentication scheme %q", ref.Host, challenge.Scheme)
+	}
+}
+
+// challenge returns the authentication challenge for the registry host, determined by an unauthenticated request to
+// the API base. Challenges are cached for the lifetime of the client.
+func (c *OCIClient) challenge(ctx context.Context, host string) (authChallenge, error) {
+	c.mu.Lock()
+	challenge, ok := c.challenges[host]
+	c.mu.Unlock()
+	if ok {
+		return challenge, nil
+	}
+
+	scheme := "https"
+	if c.plainHTTP[host] {
+		scheme = "http"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+host+"/v2/", nil)
+	if err != nil {
+		return challenge, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return challenge, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		challenge = parseAuthChallenge(resp.Header.Get("WWW-Authenticate"))
+	default:
+		return challenge, responseError(resp)
+	}
+
+	c.mu.Lock()
+	c.challenges[host] = challenge
+	c.mu.Unlock()
+
+	return challenge, nil
+}
+
+// token fetches a bearer token for the scope from the token service named in the challenge, using the credentials
+// configured for the host if there are any.
+func (c *OCIClient) token(ctx context.Context, host string, challenge authChallenge, scope string) (string, error) {
+	key := host + "/" + scope
+
+	c.mu.Lock()
+	token, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(token.expires) {
+		return token.value, nil
+	}
+
+	u, err := url.Parse(challenge.Realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", challenge.Realm, err)
+	}
+
+	query := u.Query()
+	query.Set("scope", scope)
+	if challenge.Service != "" {
+		query.Set("service", challenge.Service)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if credentials, ok := c.credentials[host]; ok {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	token.value = tokenResponse.Token
+	if token.value == "" {
+		token.value = tokenResponse.AccessToken
+	}
+	if token.value == "" {
+		return "", errors.New("token response did not contain a token")
+	}
+
+	lifetime := time.Duration(tokenResponse.ExpiresIn) * time.Second
+	if lifetime < defaultTokenLifetime {
+		lifetime = defaultTokenLifetime
+	}
+	token.expires = time.Now().Add(lifetime - tokenExpiryMargin)
+
+	c.mu.Lock()
+	c.tokens[key] = token
+	c.mu.Unlock()
+
+	return token.value, nil
+}
+
+// dropToken removes the cached bearer token for the scope, so that a new one is fetched for the next request.
+func (c *OCIClient) dropToken(host, scope string) {
+	c.mu.Lock()
+	delete(c.tokens, host+"/"+scope)
+	c.mu.Unlock()
+}
+
+// do performs a request built by newRequest. If the registry rejects the bearer token the request was authorized
+// with, because it expired early or was revoked, the token is dropped and the request is retried once with a new
+// one. Requests whose body cannot be sent again are not retried.
+func (c *OCIClient) do(ctx context.Context, req *http.Request, ref imageReference, actions string) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	c.dropToken(ref.Host, tokenScope(ref, actions))
+
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.authorize(ctx, retry, ref, actions); err != nil {
+		return nil, err
+	}
+
+	return c.http.Do(retry)
+}
+
+// tokenScope returns the scope of the bearer token needed to perform the actions in the repository of the reference.
+func tokenScope(ref imageReference, actions string) string {
+	return fmt.Sprintf("repository:%s:%s", ref.Repository, actions)
+}
+
+// parseAuthChallenge parses a WWW-Authenticate header value, e.g. Bearer realm="https://host/token",service="host".
+func parseAuthChallenge(header string) authChallenge {
+	scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+	challenge := authChallenge{Scheme: scheme}
+
+	for _, param := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+
+		switch strings.ToLower(key) {
+		case "realm":
+			challenge.Realm = value
+		case "service":
+			challenge.Service = value
+		}
+	}
+
+	return challenge
+}
+
+// parseImageReference parses a fully qualified image reference, such as eu.gcr.io/jetstack-secure-enterprise/js-operator:v0.0.1
+// or registry.internal/jetstack/js-operator@sha256:abc. References without a tag or digest are rejected, as there
+// is no way to know which version should be copied.
+func parseImageReference(reference string) (imageReference, error) {
+	var ref imageReference
+
+	name := reference
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+
+	host, repository, ok := strings.Cut(name, "/")
+	if !ok || repository == "" || !(strings.ContainsAny(host, ".:") || host == "localhost") {
+		return ref, fmt.Errorf("invalid image reference %q, the registry host must be included", reference)
+	}
+	if ref.Reference == "" {
+		return ref, fmt.Errorf("invalid image reference %q, a tag or digest is required", reference)
+	}
+
+	ref.Host = host
+	ref.Repository = repository
+
+	return ref, nil
+}
+
+func (r imageReference) withReference(reference string) imageReference {
+	r.Reference = reference
+	return r
+}
+
+func (r imageReference) String() string {
+	if strings.HasPrefix(r.Reference, "sha256:") {
+		return r.Host + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Host + "/" + r.Repository + ":" + r.Reference
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	return fmt.Errorf("unexpected response %s: %s", resp.Status, message)
+}
@@ -0,0 +1,359 @@
+package registry_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/registry"
+)
+
+func TestOCIClient_Copy(t *testing.T) {
+	ctx := context.Background()
+
+	// the source requires a bearer token issued for the Jetstack Secure Enterprise service account credentials
+	source := newTestRegistry(t, "_json_key", "key-data")
+	destination := newTestRegistry(t, "", "")
+
+	indexDigest := source.seedMultiArchImage("jetstack-secure-enterprise/js-operator", "v0.0.1")
+
+	client := registry.NewOCIClient(registry.OCIClientOptions{
+		Credentials: map[string]registry.Credentials{
+			source.host: registry.JetstackSecureEnterpriseCredentials([]byte("key-data")),
+		},
+		PlainHTTP: []string{source.host, destination.host},
+	})
+
+	src := source.host + "/jetstack-secure-enterprise/js-operator:v0.0.1"
+	dst := destination.host + "/mirror/js-operator:v0.0.1"
+
+	t.Run("It should copy all platforms of a multi-arch image", func(t *testing.T) {
+		result, err := client.Copy(ctx, src, dst)
+		require.NoError(t, err)
+
+		assert.False(t, result.Skipped)
+		assert.Equal(t, indexDigest, result.Digest)
+		assert.Equal(t, indexDigest, destination.manifestDigest("mirror/js-operator", "v0.0.1"))
+
+		// two platform manifests with a config and a layer each
+		assert.Equal(t, 4, destination.blobUploads())
+		// the tag, the index digest and both platform digests
+		assert.Len(t, destination.manifests["mirror/js-operator"], 4)
+	})
+
+	t.Run("It should skip images that already exist in the destination", func(t *testing.T) {
+		result, err := client.Copy(ctx, src, dst)
+		require.NoError(t, err)
+
+		assert.True(t, result.Skipped)
+		assert.Equal(t, 4, destination.blobUploads())
+	})
+
+	t.Run("It should not upload existing blobs when tagging another version", func(t *testing.T) {
+		result, err := client.Copy(ctx, src, destination.host+"/mirror/js-operator:latest")
+		require.NoError(t, err)
+
+		assert.False(t, result.Skipped)
+		assert.Equal(t, 4, destination.blobUploads())
+		assert.Equal(t, indexDigest, destination.manifestDigest("mirror/js-operator", "latest"))
+	})
+
+	t.Run("It should fetch a new token once the registry rejects an expired one", func(t *testing.T) {
+		source.expireToken()
+
+		result, err := client.Copy(ctx, src, destination.host+"/mirror/js-operator:v0.0.1-refreshed")
+		require.NoError(t, err)
+		assert.Equal(t, indexDigest, result.Digest)
+	})
+
+	t.Run("It should fail without credentials for the source", func(t *testing.T) {
+		anonymous := registry.NewOCIClient(registry.OCIClientOptions{
+			PlainHTTP: []string{source.host, destination.host},
+		})
+
+		_, err := anonymous.Copy(ctx, src, dst)
+		assert.Error(t, err)
+	})
+
+	t.Run("It should reject references without a tag", func(t *testing.T) {
+		_, err := client.Copy(ctx, source.host+"/jetstack-secure-enterprise/js-operator", dst)
+		assert.Error(t, err)
+	})
+}
+
+func TestOCIClient_PullArtifactFile(t *testing.T) {
+	ctx := context.Background()
+
+	source := newTestRegistry(t, "", "")
+	source.seedArtifact("jetstack/manifests", "latest", map[string][]byte{
+		"SHA256SUMS":  []byte("checksums"),
+		"v0.0.1.yaml": []byte("manifest"),
+	})
+
+	client := registry.NewOCIClient(registry.OCIClientOptions{
+		PlainHTTP: []string{source.host},
+	})
+
+	t.Run("It should return the contents of a named file", func(t *testing.T) {
+		data, err := client.PullArtifactFile(ctx, source.host+"/jetstack/manifests:latest", "v0.0.1.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, "manifest", string(data))
+	})
+
+	t.Run("It should return ErrArtifactFileNotFound for a missing file", func(t *testing.T) {
+		_, err := client.PullArtifactFile(ctx, source.host+"/jetstack/manifests:latest", "v0.0.2.yaml")
+		assert.ErrorIs(t, err, registry.ErrArtifactFileNotFound)
+	})
+}
+
+// testRegistry is an in-memory implementation of the parts of the OCI distribution API used by the OCIClient, it
+// stands in for a registry:2 instance in tests.
+type testRegistry struct {
+	server   *httptest.Server
+	host     string
+	username string
+	password string
+
+	mu        sync.Mutex
+	token     string
+	tokens    int
+	blobs     map[string][]byte
+	manifests map[string]map[string]testManifest
+	uploads   int
+}
+
+type testManifest struct {
+	mediaType string
+	body      []byte
+}
+
+func newTestRegistry(t *testing.T, username, password string) *testRegistry {
+	r := &testRegistry{
+		username:  username,
+		password:  password,
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]map[string]testManifest),
+	}
+
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+
+	u, err := url.Parse(r.server.URL)
+	require.NoError(t, err)
+	r.host = u.Host
+
+	return r
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != r.username || password != r.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.tokens++
+		r.token = fmt.Sprintf("test-token-%d", r.tokens)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"token": r.token, "expires_in": 300})
+		return
+	}
+
+	if r.username != "" && (r.token == "" || req.Header.Get("Authorization") != "Bearer "+r.token) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	p := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasSuffix(p, "/blobs/uploads/") && req.Method == http.MethodPost:
+		w.Header().Set("Location", "/upload/"+strings.TrimSuffix(p, "/blobs/uploads/")+"?state=1")
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(req.URL.Path, "/upload/") && req.Method == http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		digest := req.URL.Query().Get("digest")
+		if digest != testDigest(body) || req.URL.Query().Get("state") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = body
+		r.uploads++
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(p, "/blobs/"):
+		digest := p[strings.LastIndex(p, "/blobs/")+len("/blobs/"):]
+		blob, ok := r.blobs[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(blob)))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(blob)
+		}
+	case strings.Contains(p, "/manifests/"):
+		i := strings.LastIndex(p, "/manifests/")
+		r.serveManifest(w, req, p[:i], p[i+len("/manifests/"):])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *testRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	switch req.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		var m struct {
+			Manifests []struct{ Digest string } `json:"manifests"`
+			Layers    []struct{ Digest string } `json:"layers"`
+		}
+		_ = json.Unmarshal(body, &m)
+		// like a real registry, reject manifests that reference missing content
+		for _, child := range m.Manifests {
+			if _, ok := r.manifests[repository][child.Digest]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		for _, layer := range m.Layers {
+			if _, ok := r.blobs[layer.Digest]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		r.putManifest(repository, reference, req.Header.Get("Content-Type"), body)
+		w.Header().Set("Docker-Content-Digest", testDigest(body))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		m, ok := r.manifests[repository][reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", testDigest(m.body))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(m.body)
+		}
+	}
+}
+
+func (r *testRegistry) putManifest(repository, reference, mediaType string, body []byte) {
+	if r.manifests[repository] == nil {
+		r.manifests[repository] = make(map[string]testManifest)
+	}
+	m := testManifest{mediaType: mediaType, body: body}
+	r.manifests[repository][reference] = m
+	r.manifests[repository][testDigest(body)] = m
+}
+
+// seedMultiArchImage adds an image index with two platforms to the registry, returning the digest of the index.
+func (r *testRegistry) seedMultiArchImage(repository, tag string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var platforms []map[string]interface{}
+	for _, arch := range []string{"amd64", "arm64"} {
+		config := []byte(fmt.Sprintf(`{"architecture":%q,"os":"linux"}`, arch))
+		layer := []byte("layer-" + arch)
+		r.blobs[testDigest(config)] = config
+		r.blobs[testDigest(layer)] = layer
+
+		manifest, _ := json.Marshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+			"config":        map[string]interface{}{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": testDigest(config), "size": len(config)},
+			"layers":        []map[string]interface{}{{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": testDigest(layer), "size": len(layer)}},
+		})
+		r.putManifest(repository, testDigest(manifest), "application/vnd.oci.image.manifest.v1+json", manifest)
+
+		platforms = append(platforms, map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest":    testDigest(manifest),
+			"size":      len(manifest),
+			"platform":  map[string]string{"architecture": arch, "os": "linux"},
+		})
+	}
+
+	index, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.index.v1+json",
+		"manifests":     platforms,
+	})
+	r.putManifest(repository, tag, "application/vnd.oci.image.index.v1+json", index)
+
+	return testDigest(index)
+}
+
+// seedArtifact adds an OCI artifact to the registry with one layer per file, named using the title annotation.
+func (r *testRegistry) seedArtifact(repository, tag string, files map[string][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config := []byte("{}")
+	r.blobs[testDigest(config)] = config
+
+	var layers []map[string]interface{}
+	for name, data := range files {
+		r.blobs[testDigest(data)] = data
+		layers = append(layers, map[string]interface{}{
+			"mediaType":   "application/octet-stream",
+			"digest":      testDigest(data),
+			"size":        len(data),
+			"annotations": map[string]string{"org.opencontainers.image.title": name},
+		})
+	}
+
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]interface{}{"mediaType": "application/vnd.oci.empty.v1+json", "digest": testDigest(config), "size": len(config)},
+		"layers":        layers,
+	})
+	r.putManifest(repository, tag, "application/vnd.oci.image.manifest.v1+json", manifest)
+}
+
+func (r *testRegistry) manifestDigest(repository, reference string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.manifests[repository][reference]
+	if !ok {
+		return ""
+	}
+	return testDigest(m.body)
+}
+
+// expireToken makes the registry reject the last token it issued, as it would once the token expired.
+func (r *testRegistry) expireToken() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.token = ""
+}
+
+func (r *testRegistry) blobUploads() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.uploads
+}
+
+func testDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
@@ -0,0 +1,110 @@
+package venafi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+const (
+	credentialSourceEnv  = "env:"
+	credentialSourceFile = "file:"
+	credentialSourceExec = "exec:"
+)
+
+// ResolveCredentials replaces the credentials of the connection with their values when they refer to an external
+// source rather than containing the value itself. Each credential can be written as:
+//
+//   - env:VAR to read the value from the VAR environment variable
+//   - file:/path to read the value from a file
+//   - exec:command args to use the output of a command, which is run without a shell. The command and its
+//     arguments are split on whitespace, as a shell would split them, so arguments containing spaces must be quoted,
+//     e.g. exec:vault kv get -field=password "secret/venafi tpp"
+//
+// Values without one of these prefixes are used as they are. Trailing newlines are removed from values read from
+// files and commands.
+func (vc *VenafiConnection) ResolveCredentials() error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{name: accessTokenKey, value: &vc.AccessToken},
+		{name: usernameKey, value: &vc.Username},
+		{name: passwordKey, value: &vc.Password},
+		{name: apiKeyKey, value: &vc.APIKey},
+	}
+
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+
+		if vc.CredentialsSecret != "" {
+			return fmt.Errorf("%s cannot be set alongside credentials-secret, the credentials are read from the existing Secret", field.name)
+		}
+
+		value, err := resolveCredential(*field.value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", field.name, err)
+		}
+
+		*field.value = value
+	}
+
+	return nil
+}
+
+func resolveCredential(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, credentialSourceEnv):
+		name := strings.TrimPrefix(value, credentialSourceEnv)
+		resolved, ok := os.LookupEnv(name)
+		if !ok || resolved == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+
+		return resolved, nil
+	case strings.HasPrefix(value, credentialSourceFile):
+		path := strings.TrimPrefix(value, credentialSourceFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+
+		return nonEmpty(strings.TrimRight(string(data), "\r\n"), "file "+path)
+	case strings.HasPrefix(value, credentialSourceExec):
+		args, err := shlex.Split(strings.TrimPrefix(value, credentialSourceExec))
+		if err != nil {
+			return "", fmt.Errorf("invalid command: %w", err)
+		}
+		if len(args) == 0 {
+			return "", errors.New("no command provided")
+		}
+
+		stdout := bytes.NewBuffer([]byte{})
+		stderr := bytes.NewBuffer([]byte{})
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("command %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+
+		return nonEmpty(strings.TrimRight(stdout.String(), "\r\n"), "command "+args[0])
+	default:
+		return value, nil
+	}
+}
+
+func nonEmpty(value, source string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("%s produced an empty value", source)
+	}
+
+	return value, nil
+}
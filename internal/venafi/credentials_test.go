@@ -0,0 +1,71 @@
+package venafi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	t.Setenv("JSCTL_TEST_VENAFI_PASSWORD", "from-env")
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from-file\n"), 0600))
+
+	tests := map[string]struct {
+		conn         *VenafiConnection
+		expectedConn *VenafiConnection
+		expectedErr  string
+	}{
+		"leave plain values as they are": {
+			conn:         &VenafiConnection{Username: "foo", Password: "bar"},
+			expectedConn: &VenafiConnection{Username: "foo", Password: "bar"},
+		},
+		"read a value from an environment variable": {
+			conn:         &VenafiConnection{Username: "foo", Password: "env:JSCTL_TEST_VENAFI_PASSWORD"},
+			expectedConn: &VenafiConnection{Username: "foo", Password: "from-env"},
+		},
+		"read a value from a file": {
+			conn:         &VenafiConnection{AccessToken: "file:" + passwordFile},
+			expectedConn: &VenafiConnection{AccessToken: "from-file"},
+		},
+		"read a value from the output of a command": {
+			conn:         &VenafiConnection{APIKey: "exec:echo from-exec"},
+			expectedConn: &VenafiConnection{APIKey: "from-exec"},
+		},
+		"read a value from a command with quoted arguments": {
+			conn:         &VenafiConnection{APIKey: `exec:echo "from  exec"`},
+			expectedConn: &VenafiConnection{APIKey: "from  exec"},
+		},
+		"error out if a command has unbalanced quotes": {
+			conn:        &VenafiConnection{APIKey: `exec:echo "from exec`},
+			expectedErr: "failed to resolve api-key: invalid command: EOF found when expecting closing quote",
+		},
+		"error out if an environment variable is not set": {
+			conn:        &VenafiConnection{Password: "env:JSCTL_TEST_VENAFI_MISSING"},
+			expectedErr: "failed to resolve password: environment variable JSCTL_TEST_VENAFI_MISSING is not set",
+		},
+		"error out if a command produces no output": {
+			conn:        &VenafiConnection{APIKey: "exec:true"},
+			expectedErr: "failed to resolve api-key: command true produced an empty value",
+		},
+		"error out if credentials are set alongside an existing Secret": {
+			conn:        &VenafiConnection{AccessToken: "foo", CredentialsSecret: "foo"},
+			expectedErr: "access-token cannot be set alongside credentials-secret, the credentials are read from the existing Secret",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.conn.ResolveCredentials()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedConn, test.conn)
+			} else {
+				assert.EqualError(t, err, test.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package venafi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EnhancedIssuerKind and EnhancedClusterIssuerKind are the kinds of the issuers provided by
+	// venafi-enhanced-issuer.
+	EnhancedIssuerKind        = "VenafiIssuer"
+	EnhancedClusterIssuerKind = "VenafiClusterIssuer"
+
+	// ManagedByLabel is set on the Secrets jsctl generates for venafi-enhanced-issuer issuers, so that they can be
+	// removed along with the issuer.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	managedByJsctl = "jsctl"
+
+	tppOAuthUsernamePassword = "UsernamePassword"
+	vaultOAuthOIDC           = "OIDC"
+)
+
+type (
+	// The EnhancedIssuerOptions type contains the settings used to generate a venafi-enhanced-issuer issuer from a
+	// VenafiConnection.
+	EnhancedIssuerOptions struct {
+		Name       string            // The name of the issuer
+		Namespace  string            // The namespace of the issuer, a VenafiClusterIssuer is generated if blank
+		IssuerType string            // Either 'tpp' or 'vaas'
+		Connection *VenafiConnection // The Venafi server and credentials the issuer uses
+	}
+)
+
+// GenerateEnhancedIssuerManifests generates a venafi-enhanced-issuer VenafiIssuer, or VenafiClusterIssuer if no
+// namespace is set, that uses the server and zone of the connection. The connection's credentials are placed in the
+// returned Secret, which the issuer reads them from.
+func GenerateEnhancedIssuerManifests(options EnhancedIssuerOptions) (*unstructured.Unstructured, *corev1.Secret, error) {
+	vc := options.Connection
+	if vc == nil {
+		return nil, nil, errors.New("a Venafi connection is required")
+	}
+	if vc.CredentialsSecret != "" {
+		return nil, nil, errors.New("credentials-secret cannot be used for venafi-enhanced-issuer issuers, as the fields of the Secret are not known, write the issuer in a file instead")
+	}
+
+	kind := EnhancedIssuerKind
+	secretNamespace := options.Namespace
+	if options.Namespace == "" {
+		kind = EnhancedClusterIssuerKind
+		secretNamespace = clusterNamespace
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(issuerSecretNameTemplate, options.Name),
+			Namespace: secretNamespace,
+			Labels:    map[string]string{ManagedByLabel: managedByJsctl},
+		},
+		Data: make(map[string][]byte),
+	}
+
+	var spec veiv1alpha1.VenafiCertificateSource
+	switch options.IssuerType {
+	case tppType:
+		spec.Tpp = &veiv1alpha1.TppCertificateIssuer{
+			Url:      vc.URL,
+			PolicyDn: tppPolicyDN(vc.Zone),
+		}
+
+		switch {
+		case len(vc.APIKey) > 0:
+			return nil, nil, errors.New(errMsgUnexpectedAPIKey)
+		case len(vc.AccessToken) > 0:
+			secret.Data[accessTokenKey] = []byte(vc.AccessToken)
+			spec.Tpp.AccessToken = []veiv1alpha1.SecretSource{
+				{Secret: &veiv1alpha1.Secret{Name: secret.Name, Fields: []string{accessTokenKey}}},
+			}
+		case len(vc.Username) > 0 && len(vc.Password) > 0:
+			secret.Data[usernameKey] = []byte(vc.Username)
+			secret.Data[passwordKey] = []byte(vc.Password)
+			spec.Tpp.AccessToken = []veiv1alpha1.SecretSource{
+				{Secret: &veiv1alpha1.Secret{Name: secret.Name, Fields: []string{usernameKey, passwordKey}}},
+				{TppOAuth: &veiv1alpha1.TppOAuth{AuthInputType: tppOAuthUsernamePassword, Url: vc.URL}},
+			}
+		default:
+			return nil, nil, missingTPPCredsError(vc)
+		}
+	case vaasType:
+		if len(vc.AccessToken) > 0 || len(vc.Username) > 0 || len(vc.Password) > 0 {
+			return nil, nil, errors.New(errMsgUnexpectedTPPCreds)
+		}
+		if len(vc.APIKey) == 0 {
+			return nil, nil, errors.New(errMsgMissingAPIKey)
+		}
+
+		application, template, err := vaasZone(vc.Zone)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		secret.Data[apiKeyKey] = []byte(vc.APIKey)
+		spec.Vaas = &veiv1alpha1.VaasCertificateIssuer{
+			Url:         vc.URL,
+			Application: application,
+			Template:    template,
+			ApiKey: []veiv1alpha1.SecretSource{
+				{Secret: &veiv1alpha1.Secret{Name: secret.Name, Fields: []string{apiKeyKey}}},
+			},
+		}
+	default:
+		return nil, nil, fmt.Errorf(errMsgInvalidIssuerType, options.IssuerType)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var specMap map[string]interface{}
+	if err := json.Unmarshal(specJSON, &specMap); err != nil {
+		return nil, nil, err
+	}
+
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{"spec": specMap}}
+	issuer.SetAPIVersion(veiv1alpha1.SchemeGroupVersion.String())
+	issuer.SetKind(kind)
+	issuer.SetName(options.Name)
+	issuer.SetNamespace(options.Namespace)
+
+	if err := ValidateEnhancedIssuer(issuer); err != nil {
+		return nil, nil, err
+	}
+
+	return issuer, secret, nil
+}
+
+// ParseEnhancedIssuer parses a YAML or JSON encoded VenafiIssuer or VenafiClusterIssuer and validates it.
+func ParseEnhancedIssuer(data []byte) (*unstructured.Unstructured, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer: %w", err)
+	}
+
+	issuer := &unstructured.Unstructured{}
+	if err := issuer.UnmarshalJSON(jsonData); err != nil {
+		return nil, fmt.Errorf("invalid issuer: %w", err)
+	}
+
+	if err := ValidateEnhancedIssuer(issuer); err != nil {
+		return nil, err
+	}
+
+	return issuer, nil
+}
+
+// ValidateEnhancedIssuer checks that the issuer is a venafi-enhanced-issuer issuer that the issuer's controller
+// would accept, including that its steps to obtain credentials are in an order that can succeed.
+func ValidateEnhancedIssuer(issuer *unstructured.Unstructured) error {
+	if issuer.GetAPIVersion() != veiv1alpha1.SchemeGroupVersion.String() {
+		return fmt.Errorf("apiVersion must be %s, got %q", veiv1alpha1.SchemeGroupVersion, issuer.GetAPIVersion())
+	}
+
+	switch issuer.GetKind() {
+	case EnhancedIssuerKind:
+		if issuer.GetNamespace() == "" {
+			return fmt.Errorf("a %s must have a namespace", EnhancedIssuerKind)
+		}
+	case EnhancedClusterIssuerKind:
+		if issuer.GetNamespace() != "" {
+			return fmt.Errorf("a %s is cluster scoped and cannot have a namespace", EnhancedClusterIssuerKind)
+		}
+	default:
+		return fmt.Errorf("kind must be %s or %s, got %q", EnhancedIssuerKind, EnhancedClusterIssuerKind, issuer.GetKind())
+	}
+
+	if issuer.GetName() == "" {
+		return errors.New("the issuer must have a name")
+	}
+
+	rawSpec, ok := issuer.Object["spec"]
+	if !ok {
+		return errors.New("the issuer must have a spec")
+	}
+	specJSON, err := json.Marshal(rawSpec)
+	if err != nil {
+		return err
+	}
+
+	// fields added by newer versions of venafi-enhanced-issuer are left for its API server to validate
+	var spec veiv1alpha1.VenafiCertificateSource
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	switch {
+	case spec.Tpp != nil && spec.Vaas != nil:
+		return errors.New("spec must set only one of tpp or vaas")
+	case spec.Tpp != nil:
+		if spec.Tpp.PolicyDn == "" {
+			return errors.New("spec.tpp.policyDN is required")
+		}
+		return validateSecretSources("spec.tpp.accessToken", spec.Tpp.AccessToken, true)
+	case spec.Vaas != nil:
+		if spec.Vaas.Application == "" {
+			return errors.New("spec.vaas.application is required")
+		}
+		if spec.Vaas.Template == "" {
+			return errors.New("spec.vaas.template is required")
+		}
+		return validateSecretSources("spec.vaas.apiKey", spec.Vaas.ApiKey, false)
+	default:
+		return errors.New("spec must set one of tpp or vaas")
+	}
+}
+
+// validateSecretSources checks each step used to obtain credentials. Secret and ServiceAccountToken steps provide
+// the input for the steps that follow them, and a TPP OAuth step, only valid for TPP, exchanges its input for an
+// access token so must come last.
+func validateSecretSources(path string, sources []veiv1alpha1.SecretSource, tpp bool) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("%s must contain at least one step", path)
+	}
+
+	for i, source := range sources {
+		stepPath := fmt.Sprintf("%s[%d]", path, i)
+
+		set := 0
+		for _, isSet := range []bool{
+			source.Secret != nil,
+			source.ServiceAccountToken != nil,
+			source.TppOAuth != nil,
+			source.HashicorpVaultOAuth != nil,
+			source.HashicorpVaultSecret != nil,
+		} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("%s must have exactly one field set", stepPath)
+		}
+
+		switch {
+		case source.Secret != nil:
+			if source.Secret.Name == "" || len(source.Secret.Fields) == 0 {
+				return fmt.Errorf("%s.secret must have a name and fields", stepPath)
+			}
+		case source.ServiceAccountToken != nil:
+			if source.ServiceAccountToken.Name == "" || len(source.ServiceAccountToken.Audiences) == 0 {
+				return fmt.Errorf("%s.serviceAccountToken must have a name and audiences", stepPath)
+			}
+		case source.HashicorpVaultOAuth != nil:
+			if i == 0 {
+				return fmt.Errorf("%s.hashicorpVaultOAuth needs a previous step to provide its token", stepPath)
+			}
+			if source.HashicorpVaultOAuth.AuthInputType != vaultOAuthOIDC {
+				return fmt.Errorf("%s.hashicorpVaultOAuth.authInputType must be %s", stepPath, vaultOAuthOIDC)
+			}
+			if source.HashicorpVaultOAuth.Role == "" || source.HashicorpVaultOAuth.AuthPath == "" {
+				return fmt.Errorf("%s.hashicorpVaultOAuth must have a role and authPath", stepPath)
+			}
+		case source.HashicorpVaultSecret != nil:
+			if i == 0 {
+				return fmt.Errorf("%s.hashicorpVaultSecret needs a previous step to provide a Vault token", stepPath)
+			}
+			if source.HashicorpVaultSecret.SecretPath == "" || len(source.HashicorpVaultSecret.Fields) == 0 {
+				return fmt.Errorf("%s.hashicorpVaultSecret must have a secretPath and fields", stepPath)
+			}
+		case source.TppOAuth != nil:
+			if !tpp {
+				return fmt.Errorf("%s.tppOAuth can only be used for TPP", stepPath)
+			}
+			if i == 0 || i != len(sources)-1 {
+				return fmt.Errorf("%s.tppOAuth must be the last step, after a step providing a username and password", stepPath)
+			}
+			if source.TppOAuth.AuthInputType != tppOAuthUsernamePassword {
+				return fmt.Errorf("%s.tppOAuth.authInputType must be %s", stepPath, tppOAuthUsernamePassword)
+			}
+		}
+	}
+
+	return nil
+}
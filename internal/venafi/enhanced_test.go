@@ -0,0 +1,239 @@
+package venafi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGenerateEnhancedIssuerManifests(t *testing.T) {
+	tests := map[string]struct {
+		options        EnhancedIssuerOptions
+		expectedKind   string
+		expectedSpec   map[string]interface{}
+		expectedSecret map[string]string
+		expectedErr    string
+	}{
+		"a TPP issuer using an access token": {
+			options: EnhancedIssuerOptions{
+				Name:       "foo",
+				Namespace:  "bar",
+				IssuerType: "tpp",
+				Connection: &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: `cert-manager\prod`, AccessToken: "token"},
+			},
+			expectedKind: EnhancedIssuerKind,
+			expectedSpec: map[string]interface{}{
+				"tpp": map[string]interface{}{
+					"url":      "https://tpp.example.com/vedsdk",
+					"policyDN": `\VED\Policy\cert-manager\prod`,
+					"accessToken": []interface{}{
+						map[string]interface{}{"secret": map[string]interface{}{"name": "foo-jsctl", "fields": []interface{}{"access-token"}}},
+					},
+				},
+			},
+			expectedSecret: map[string]string{"access-token": "token"},
+		},
+		"a TPP cluster issuer using a username and password": {
+			options: EnhancedIssuerOptions{
+				Name:       "foo",
+				IssuerType: "tpp",
+				Connection: &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: `\VED\Policy\prod`, Username: "user", Password: "pass"},
+			},
+			expectedKind: EnhancedClusterIssuerKind,
+			expectedSpec: map[string]interface{}{
+				"tpp": map[string]interface{}{
+					"url":      "https://tpp.example.com/vedsdk",
+					"policyDN": `\VED\Policy\prod`,
+					"accessToken": []interface{}{
+						map[string]interface{}{"secret": map[string]interface{}{"name": "foo-jsctl", "fields": []interface{}{"username", "password"}}},
+						map[string]interface{}{"tppOAuth": map[string]interface{}{"authInputType": "UsernamePassword", "url": "https://tpp.example.com/vedsdk"}},
+					},
+				},
+			},
+			expectedSecret: map[string]string{"username": "user", "password": "pass"},
+		},
+		"a VaaS issuer using an API key": {
+			options: EnhancedIssuerOptions{
+				Name:       "foo",
+				Namespace:  "bar",
+				IssuerType: "vaas",
+				Connection: &VenafiConnection{Zone: `app\template`, APIKey: "key"},
+			},
+			expectedKind: EnhancedIssuerKind,
+			expectedSpec: map[string]interface{}{
+				"vaas": map[string]interface{}{
+					"application": "app",
+					"template":    "template",
+					"apiKey": []interface{}{
+						map[string]interface{}{"secret": map[string]interface{}{"name": "foo-jsctl", "fields": []interface{}{"api-key"}}},
+					},
+				},
+			},
+			expectedSecret: map[string]string{"api-key": "key"},
+		},
+		"error out on a VaaS zone without a template": {
+			options: EnhancedIssuerOptions{
+				Name:       "foo",
+				IssuerType: "vaas",
+				Connection: &VenafiConnection{Zone: "app", APIKey: "key"},
+			},
+			expectedErr: errMsgMissingVaaSZone,
+		},
+		"error out on an existing credentials Secret": {
+			options: EnhancedIssuerOptions{
+				Name:       "foo",
+				IssuerType: "tpp",
+				Connection: &VenafiConnection{Zone: "prod", CredentialsSecret: "creds"},
+			},
+			expectedErr: "credentials-secret cannot be used for venafi-enhanced-issuer issuers, as the fields of the Secret are not known, write the issuer in a file instead",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			issuer, secret, err := GenerateEnhancedIssuerManifests(test.options)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedKind, issuer.GetKind())
+			assert.Equal(t, test.options.Namespace, issuer.GetNamespace())
+			assert.Equal(t, test.expectedSpec, issuer.Object["spec"])
+
+			assert.Equal(t, "foo-jsctl", secret.Name)
+			assert.Equal(t, "jsctl", secret.Labels[ManagedByLabel])
+			data := make(map[string]string)
+			for k, v := range secret.Data {
+				data[k] = string(v)
+			}
+			assert.Equal(t, test.expectedSecret, data)
+		})
+	}
+}
+
+func TestParseEnhancedIssuer(t *testing.T) {
+	tests := map[string]struct {
+		manifest    string
+		expectedErr string
+	}{
+		"an issuer using a Vault secret": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiIssuer
+metadata:
+  name: foo
+  namespace: bar
+spec:
+  tpp:
+    url: https://tpp.example.com/vedsdk
+    policyDN: \VED\Policy\prod
+    accessToken:
+    - serviceAccountToken:
+        name: foo
+        audiences: [vault]
+    - hashicorpVaultOAuth:
+        authInputType: OIDC
+        role: foo
+        authPath: /v1/auth/jwt/login
+        url: https://vault.example.com
+    - hashicorpVaultSecret:
+        secretPath: /v1/secret/data/tpp
+        fields: [username, password]
+        url: https://vault.example.com
+    - tppOAuth:
+        authInputType: UsernamePassword
+        url: https://tpp.example.com/vedsdk
+`,
+		},
+		"error out on a namespaced cluster issuer": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiClusterIssuer
+metadata:
+  name: foo
+  namespace: bar
+spec:
+  vaas:
+    application: app
+    template: template
+    apiKey:
+    - secret: {name: foo, fields: [api-key]}
+`,
+			expectedErr: "a VenafiClusterIssuer is cluster scoped and cannot have a namespace",
+		},
+		"error out on a Vault secret step without a Vault token": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiClusterIssuer
+metadata:
+  name: foo
+spec:
+  tpp:
+    policyDN: \VED\Policy\prod
+    accessToken:
+    - hashicorpVaultSecret:
+        secretPath: /v1/secret/data/tpp
+        fields: [access-token]
+`,
+			expectedErr: "spec.tpp.accessToken[0].hashicorpVaultSecret needs a previous step to provide a Vault token",
+		},
+		"error out on a TPP OAuth step for VaaS": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiClusterIssuer
+metadata:
+  name: foo
+spec:
+  vaas:
+    application: app
+    template: template
+    apiKey:
+    - secret: {name: foo, fields: [username, password]}
+    - tppOAuth: {authInputType: UsernamePassword}
+`,
+			expectedErr: "spec.vaas.apiKey[1].tppOAuth can only be used for TPP",
+		},
+		"an issuer with fields unknown to jsctl": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiClusterIssuer
+metadata:
+  name: foo
+spec:
+  tpp:
+    policyDN: \VED\Policy\prod
+    caBundle: Zm9v
+    accessToken:
+    - secret: {name: foo, fields: [access-token]}
+`,
+		},
+		"error out on a field of the wrong type": {
+			manifest: `
+apiVersion: jetstack.io/v1alpha1
+kind: VenafiClusterIssuer
+metadata:
+  name: foo
+spec:
+  tpp:
+    policyDN: [\VED\Policy\prod]
+    accessToken:
+    - secret: {name: foo, fields: [access-token]}
+`,
+			expectedErr: "invalid spec: json: cannot unmarshal array into Go struct field VenafiCertificateSource.tpp.policyDN of type string",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			issuer, err := ParseEnhancedIssuer([]byte(test.manifest))
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, &unstructured.Unstructured{}, issuer)
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// tppClientID and tppIssuerScope are those cert-manager uses when authenticating with a username and password,
+	// so that verification exercises the same permissions as an issuer
+	tppClientID    = "cert-manager.io"
+	tppIssuerScope = "certificate:manage"
+
+	tppPolicyRoot = `\VED\Policy`
+)
+
+var (
+	// ErrTPPUnauthorized is returned when a TPP server rejects the credentials of a connection.
+	ErrTPPUnauthorized = errors.New("unauthorized")
+	// ErrTPPCredentialsSecret is returned when a connection references a Secret for its credentials, which are only
+	// available within the cluster.
+	ErrTPPCredentialsSecret = errors.New("connection uses credentials-secret, so its credentials are only available within the cluster")
+)
+
+type (
+	// The TPPVerification type contains the results of verifying a VenafiConnection against the TPP REST API.
+	TPPVerification struct {
+		Identity    string              // The identity the credentials authenticate as
+		Application string              // The application integration the token was issued to
+		Scopes      map[string][]string // The privileges granted to the token, keyed by scope
+		Expires     time.Time           // When the token expires, zero if unknown
+		Zone        string              // The policy folder the connection's zone refers to
+		ZoneError   string              // Why the zone cannot be used, blank if it exists
+		Zones       []string            // The zones available to the connection
+		ZonesError  error               // Why the zones could not be listed, if they could not
+	}
+
+	tppClient struct {
+		http    *http.Client
+		baseURL string
+		token   string
+	}
+
+	tppError struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+)
+
+// VerifyTPPConnection authenticates against the TPP server of the connection using its credentials, then checks
+// that the token is valid for issuing certificates and that the connection's zone exists. The zones available to the
+// connection are listed where the token permits it. An error is returned if the server cannot be reached or
+// rejects the credentials, problems with the token or zone are reported by the TPPVerification.
+func VerifyTPPConnection(ctx context.Context, httpClient *http.Client, vc *VenafiConnection) (*TPPVerification, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+
+	switch {
+	case vc.URL == "":
+		return nil, errors.New("connection has no url")
+	case vc.CredentialsSecret != "":
+		return nil, ErrTPPCredentialsSecret
+	case vc.APIKey != "":
+		return nil, errors.New("connection has an api-key, only TPP connections can be verified")
+	}
+
+	client := &tppClient{
+		http:    httpClient,
+		baseURL: tppBaseURL(vc.URL),
+		token:   vc.AccessToken,
+	}
+
+	switch {
+	case vc.AccessToken != "":
+	case vc.Username != "" && vc.Password != "":
+		if err := client.authorize(ctx, vc.Username, vc.Password); err != nil {
+			return nil, err
+		}
+		// the token only exists for verification, so should not outlive it
+		defer client.revoke(ctx)
+	default:
+		return nil, missingTPPCredsError(vc)
+	}
+
+	verification, err := client.verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.Zone = tppPolicyDN(vc.Zone)
+	if vc.Zone == "" {
+		verification.ZoneError = "connection has no zone"
+	} else if verification.ZoneError, err = client.checkPolicy(ctx, verification.Zone); err != nil {
+		return nil, err
+	}
+
+	verification.Zones, verification.ZonesError = client.zones(ctx)
+
+	return verification, nil
+}
+
+// Problems returns the reasons the connection could not be used by an issuer.
+func (v *TPPVerification) Problems() []string {
+	var problems []string
+
+	if !v.HasScope("certificate", "manage") {
+		problems = append(problems, fmt.Sprintf("token does not have the %s scope required to issue certificates", tppIssuerScope))
+	}
+	if v.ZoneError != "" {
+		problems = append(problems, fmt.Sprintf("zone %s cannot be used: %s", v.Zone, v.ZoneError))
+	}
+
+	return problems
+}
+
+// HasScope returns true if the token was granted the privilege within the scope.
+func (v *TPPVerification) HasScope(scope, privilege string) bool {
+	for _, granted := range v.Scopes[scope] {
+		if granted == privilege {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *tppClient) authorize(ctx context.Context, username, password string) error {
+	request := map[string]string{
+		"client_id": tppClientID,
+		"username":  username,
+		"password":  password,
+		"scope":     tppIssuerScope,
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/vedauth/authorize/oauth", request, &response); err != nil {
+		return fmt.Errorf("failed to authenticate with username and password: %w", err)
+	}
+
+	c.token = response.AccessToken
+	return nil
+}
+
+func (c *tppClient) revoke(ctx context.Context) {
+	_ = c.do(ctx, http.MethodGet, "/vedauth/revoke/token", nil, nil)
+}
+
+func (c *tppClient) verify(ctx context.Context) (*TPPVerification, error) {
+	var response struct {
+		Application string `json:"application"`
+		Expires     string `json:"expires"`
+		Identity    string `json:"identity"`
+		Scope       string `json:"scope"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/vedauth/authorize/verify", nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to verify access token: %w", err)
+	}
+
+	verification := &TPPVerification{
+		Identity:    response.Identity,
+		Application: response.Application,
+		Scopes:      parseTPPScope(response.Scope),
+	}
+
+	if response.Expires != "" {
+		expires, err := time.Parse(time.RFC3339, response.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse token expiry %q: %w", response.Expires, err)
+		}
+		verification.Expires = expires
+	}
+
+	return verification, nil
+}
+
+// checkPolicy returns the reason the policy folder cannot be used to issue certificates, or a blank string if it
+// can.
+func (c *tppClient) checkPolicy(ctx context.Context, policyDN string) (string, error) {
+	var response struct {
+		Error string `json:"Error"`
+	}
+	err := c.do(ctx, http.MethodPost, "/vedsdk/certificates/checkpolicy", map[string]string{"PolicyDN": policyDN}, &response)
+	if errors.Is(err, ErrTPPUnauthorized) {
+		return "the token is not permitted to read its policy", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check zone: %w", err)
+	}
+
+	return response.Error, nil
+}
+
+func (c *tppClient) zones(ctx context.Context) ([]string, error) {
+	request := map[string]string{
+		"Class":    "Policy",
+		"ObjectDN": tppPolicyRoot,
+	}
+
+	var response struct {
+		Objects []struct {
+			DN string `json:"DN"`
+		} `json:"Objects"`
+		Result int `json:"Result"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/vedsdk/config/findobjectsofclass", request, &response); err != nil {
+		if errors.Is(err, ErrTPPUnauthorized) {
+			return nil, errors.New("listing zones requires a token with the configuration scope")
+		}
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	if response.Result != 1 {
+		return nil, fmt.Errorf("failed to list zones: TPP returned result code %d", response.Result)
+	}
+
+	zones := make([]string, 0, len(response.Objects))
+	for _, object := range response.Objects {
+		zones = append(zones, strings.TrimPrefix(object.DN, tppPolicyRoot+`\`))
+	}
+	sort.Strings(zones)
+
+	return zones, nil
+}
+
+func (c *tppClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrTPPUnauthorized, tppErrorMessage(resp, data))
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected response from %s: %s", path, tppErrorMessage(resp, data))
+	case out == nil:
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func tppErrorMessage(resp *http.Response, data []byte) string {
+	var tppErr tppError
+	if err := json.Unmarshal(data, &tppErr); err == nil && tppErr.Error != "" {
+		if tppErr.ErrorDescription != "" {
+			return fmt.Sprintf("%s: %s", tppErr.Error, tppErr.ErrorDescription)
+		}
+		return tppErr.Error
+	}
+
+	return resp.Status
+}
+
+// tppBaseURL returns the root of the TPP server, connections commonly use the URL of the WebSDK, as cert-manager
+// does not mind either.
+func tppBaseURL(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, "/vedsdk")
+	return strings.TrimSuffix(url, "/")
+}
+
+// tppPolicyDN returns the distinguished name of the policy folder for a zone, which cert-manager treats as relative
+// to the policy root unless it is already absolute.
+func tppPolicyDN(zone string) string {
+	if strings.HasPrefix(zone, tppPolicyRoot) {
+		return zone
+	}
+
+	return tppPolicyRoot + `\` + strings.TrimPrefix(zone, `\`)
+}
+
+// parseTPPScope parses a TPP scope, such as "certificate:manage,revoke;configuration", into the privileges granted
+// for each scope.
+func parseTPPScope(scope string) map[string][]string {
+	scopes := make(map[string][]string)
+	for _, part := range strings.Split(scope, ";") {
+		if part == "" {
+			continue
+		}
+
+		name, privileges, _ := strings.Cut(part, ":")
+		scopes[name] = nil
+		if privileges != "" {
+			scopes[name] = strings.Split(privileges, ",")
+		}
+	}
+
+	return scopes
+}
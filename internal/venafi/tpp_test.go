@@ -0,0 +1,202 @@
+package venafi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTPP is a minimal implementation of the parts of the TPP REST API used to verify connections.
+type fakeTPP struct {
+	tokens   map[string]string // scope granted to each valid access token
+	policies []string
+	revoked  []string
+}
+
+func (f *fakeTPP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	readJSON := func() map[string]string {
+		body := make(map[string]string)
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		return body
+	}
+
+	if r.URL.Path == "/vedauth/authorize/oauth" {
+		body := readJSON()
+		if body["username"] != "user" || body["password"] != "pass" || body["client_id"] != tppClientID {
+			writeJSON(http.StatusBadRequest, tppError{Error: "invalid_grant", ErrorDescription: "Username/password combination not valid"})
+			return
+		}
+		f.tokens["password-token"] = body["scope"]
+		writeJSON(http.StatusOK, map[string]string{"access_token": "password-token"})
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	scope, ok := f.tokens[token]
+	if !ok {
+		writeJSON(http.StatusUnauthorized, tppError{Error: "invalid_token"})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/vedauth/authorize/verify":
+		writeJSON(http.StatusOK, map[string]string{
+			"application": tppClientID,
+			"expires":     "2030-01-02T03:04:05Z",
+			"identity":    "local:{jsctl}",
+			"scope":       scope,
+		})
+	case "/vedauth/revoke/token":
+		f.revoked = append(f.revoked, token)
+		delete(f.tokens, token)
+	case "/vedsdk/certificates/checkpolicy":
+		if !strings.HasPrefix(scope, "certificate") {
+			writeJSON(http.StatusUnauthorized, tppError{Error: "insufficient_scope"})
+			return
+		}
+		policyDN := readJSON()["PolicyDN"]
+		for _, policy := range f.policies {
+			if policy == policyDN {
+				writeJSON(http.StatusOK, map[string]interface{}{"Error": nil, "Policy": map[string]interface{}{}})
+				return
+			}
+		}
+		writeJSON(http.StatusOK, map[string]string{"Error": "Policy folder does not exist"})
+	case "/vedsdk/config/findobjectsofclass":
+		if !strings.Contains(scope, "configuration") {
+			writeJSON(http.StatusUnauthorized, tppError{Error: "insufficient_scope"})
+			return
+		}
+		objects := make([]map[string]string, 0, len(f.policies))
+		for _, policy := range f.policies {
+			objects = append(objects, map[string]string{"DN": policy})
+		}
+		writeJSON(http.StatusOK, map[string]interface{}{"Objects": objects, "Result": 1})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVerifyTPPConnection(t *testing.T) {
+	ctx := context.Background()
+
+	newServer := func(t *testing.T) (*fakeTPP, *httptest.Server) {
+		tpp := &fakeTPP{
+			tokens: map[string]string{
+				"issuer-token": "certificate:manage",
+				"admin-token":  "certificate:manage,revoke;configuration",
+				"read-token":   "certificate",
+			},
+			policies: []string{`\VED\Policy\Certificates\Web`, `\VED\Policy\Certificates\Internal`},
+		}
+		server := httptest.NewTLSServer(tpp)
+		t.Cleanup(server.Close)
+		return tpp, server
+	}
+
+	t.Run("It should verify an access token and list zones", func(t *testing.T) {
+		_, server := newServer(t)
+
+		verification, err := VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:         server.URL + "/vedsdk/",
+			Zone:        `Certificates\Web`,
+			AccessToken: "admin-token",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "local:{jsctl}", verification.Identity)
+		assert.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), verification.Expires)
+		assert.True(t, verification.HasScope("certificate", "revoke"))
+		assert.Empty(t, verification.Problems())
+		assert.NoError(t, verification.ZonesError)
+		assert.Equal(t, []string{`Certificates\Internal`, `Certificates\Web`}, verification.Zones)
+	})
+
+	t.Run("It should authenticate with a username and password and revoke the token afterwards", func(t *testing.T) {
+		tpp, server := newServer(t)
+
+		verification, err := VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:      server.URL,
+			Zone:     `\VED\Policy\Certificates\Web`,
+			Username: "user",
+			Password: "pass",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string][]string{"certificate": {"manage"}}, verification.Scopes)
+		assert.Empty(t, verification.Problems())
+		assert.EqualError(t, verification.ZonesError, "listing zones requires a token with the configuration scope")
+		assert.Equal(t, []string{"password-token"}, tpp.revoked)
+	})
+
+	t.Run("It should report a zone that does not exist", func(t *testing.T) {
+		_, server := newServer(t)
+
+		verification, err := VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:         server.URL,
+			Zone:        `Certificates\Missing`,
+			AccessToken: "issuer-token",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{`zone \VED\Policy\Certificates\Missing cannot be used: Policy folder does not exist`}, verification.Problems())
+	})
+
+	t.Run("It should report a token that cannot issue certificates", func(t *testing.T) {
+		_, server := newServer(t)
+
+		verification, err := VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:         server.URL,
+			Zone:        `Certificates\Web`,
+			AccessToken: "read-token",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"token does not have the certificate:manage scope required to issue certificates"}, verification.Problems())
+	})
+
+	t.Run("It should return an error for invalid credentials", func(t *testing.T) {
+		_, server := newServer(t)
+
+		_, err := VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:         server.URL,
+			Zone:        `Certificates\Web`,
+			AccessToken: "expired-token",
+		})
+		assert.ErrorIs(t, err, ErrTPPUnauthorized)
+
+		_, err = VerifyTPPConnection(ctx, server.Client(), &VenafiConnection{
+			URL:      server.URL,
+			Zone:     `Certificates\Web`,
+			Username: "user",
+			Password: "wrong",
+		})
+		assert.ErrorContains(t, err, "Username/password combination not valid")
+	})
+
+	t.Run("It should not verify connections using a credentials Secret", func(t *testing.T) {
+		_, err := VerifyTPPConnection(ctx, nil, &VenafiConnection{
+			URL:               "https://tpp.example.com/vedsdk",
+			Zone:              `Certificates\Web`,
+			CredentialsSecret: "tpp-credentials",
+		})
+		assert.ErrorIs(t, err, ErrTPPCredentialsSecret)
+	})
+
+	t.Run("It should not verify VaaS connections", func(t *testing.T) {
+		_, err := VerifyTPPConnection(ctx, nil, &VenafiConnection{URL: "https://api.venafi.cloud/v1", APIKey: "foo"})
+		assert.Error(t, err)
+	})
+}
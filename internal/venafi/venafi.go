@@ -3,17 +3,20 @@ package venafi
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	alpha1operatorv1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	tppType                  = "tpp"
+	vaasType                 = "vaas"
 	issuerSecretNameTemplate = "%s-jsctl"
 
 	clusterNamespace = "jetstack-secure"
@@ -21,21 +24,34 @@ const (
 	usernameKey    = "username"
 	passwordKey    = "password"
 	accessTokenKey = "access-token"
+	apiKeyKey      = "api-key"
 
 	errMsgInvalidIssuerTemplate    = "invalid isuer template expected 'type:connection:name:[namespace] got %s"
-	errMsgInvalidIssuerType        = "invalid issuer type: %s, valid types are: [tpp]"
+	errMsgInvalidIssuerType        = "invalid issuer type: %s, valid types are: [tpp vaas]"
 	errMsgMissingVenafiConnection  = "VenafiConnection %s not found. Make sure that it is included in config passed to --experimental-venafi-connections-config"
 	errMsgIncompleteIssuerTemplate = "internal error (please report this): issuer template is empty or missing venafi connection details: %+#v"
-	errMsgMissingConnectionCreds   = "missing credentials: expected either Venafi access token or username and password: got access-token %s, username: %s, password: %s"
+	errMsgMissingTPPCreds          = "missing credentials: expected either Venafi access token or username and password: got %s"
+	errMsgUnexpectedAPIKey         = "incorrect credentials: an api-key can only be used with vaas issuers, expected either Venafi access token or username and password"
+	errMsgMissingAPIKey            = "missing credentials: expected a Venafi as a Service api-key"
+	errMsgUnexpectedTPPCreds       = "incorrect credentials: vaas issuers authenticate with an api-key, got an access token, username or password"
+	errMsgCredentialsSecretWithVOH = "credentials-secret cannot be used with venafi-oauth-helper, which generates the issuer's credentials from a bootstrap Secret created by jsctl"
+	errMsgMissingVaaSZone          = "missing zone: vaas issuers require a zone in the form '<application>\\<issuing template alias>'"
 )
 
-// VenafiConnection holds connection details for a Venafi server
+// VenafiConnection holds connection details for a Venafi server. TPP connections authenticate with an access token
+// or a username and password, Venafi as a Service (VaaS) connections authenticate with an API key and use URL as the
+// tenant URL, which defaults to the public Venafi Cloud API if left blank. Credentials can refer to external sources
+// (see ResolveCredentials), or CredentialsSecret can name an existing Secret that contains them, in which case jsctl
+// does not generate one.
 type VenafiConnection struct {
 	URL         string `yaml:"url,omitempty"`
 	Zone        string `yaml:"zone,omitempty"`
 	AccessToken string `yaml:"access-token,omitempty"`
 	Username    string `yaml:"username,omitempty"`
 	Password    string `yaml:"password,omitempty"`
+	APIKey      string `yaml:"api-key,omitempty"`
+
+	CredentialsSecret string `yaml:"credentials-secret,omitempty"`
 }
 
 type VenafiIssuer struct {
@@ -51,6 +67,32 @@ type Conn struct {
 	ManagedByVOH bool
 }
 
+// LoadConnections reads the yaml formatted Venafi connections, keyed by name, from the file at configPath and
+// resolves their credentials. No connections are returned if configPath is blank.
+func LoadConnections(configPath string) (map[string]*VenafiConnection, error) {
+	if configPath == "" {
+		return nil, nil
+	}
+	vcs := make(map[string]*VenafiConnection)
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+	if err = yaml.NewDecoder(file).Decode(&vcs); err != nil {
+		return nil, fmt.Errorf("error decoding connection configuration: %w", err)
+	}
+	for name, vc := range vcs {
+		if vc == nil {
+			continue
+		}
+		if err := vc.ResolveCredentials(); err != nil {
+			return nil, fmt.Errorf("connection %s: %w", name, err)
+		}
+	}
+	return vcs, nil
+}
+
 // ParseIssuerConfig parses issuer configuration in form
 // 'type:connection:name:[namespace]' and generates and returns a list of parsed
 // VenafiIssuer
@@ -74,6 +116,8 @@ func ParseIssuerConfig(issuers []string, vcs map[string]*VenafiConnection, vohEn
 		switch {
 		case parts[0] == tppType:
 			iss.IssuerType = tppType
+		case parts[0] == vaasType:
+			iss.IssuerType = vaasType
 		default:
 			return nil, fmt.Errorf(errMsgInvalidIssuerType, parts[0])
 		}
@@ -84,8 +128,9 @@ func ParseIssuerConfig(issuers []string, vcs map[string]*VenafiConnection, vohEn
 			return nil, fmt.Errorf(errMsgMissingVenafiConnection, parts[1])
 		}
 		conn := &Conn{
-			VC:           vc,
-			ManagedByVOH: vohEnabled,
+			VC: vc,
+			// venafi-oauth-helper only refreshes TPP access tokens, VaaS API keys are used as they are
+			ManagedByVOH: vohEnabled && iss.IssuerType == tppType,
 		}
 		iss.Conn = conn
 		vi[i] = iss
@@ -104,7 +149,9 @@ func ParseCertDiscoveryVenafiConfig(vcName string, vcs map[string]*VenafiConnect
 		return nil, fmt.Errorf(errMsgMissingVenafiConnection, vcName)
 	}
 
-	if len(vc.AccessToken) > 0 {
+	if len(vc.CredentialsSecret) > 0 {
+		return nil, errors.New("incorrect connection credentials for cert-discovery-venafi, expected access token got credentials-secret")
+	} else if len(vc.AccessToken) > 0 {
 		return vc, nil
 	} else if len(vc.Username) > 0 && len(vc.Password) > 0 {
 		return nil, errors.New("incorrect connection credentials for cert-discovery-venafi, expected access token got username and password")
@@ -113,6 +160,8 @@ func ParseCertDiscoveryVenafiConfig(vcName string, vcs map[string]*VenafiConnect
 	}
 }
 
+// GenerateOperatorManifestsForIssuer generates the operator's Issuer spec for the issuer and the Secret containing
+// its credentials. The returned Secret is nil if the issuer's connection refers to an existing Secret.
 func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1.Issuer, *corev1.Secret, error) {
 	// Generate Issuer spec
 	if issuer == nil || issuer.Conn == nil || issuer.Conn.VC == nil {
@@ -122,16 +171,14 @@ func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1
 	iss := &alpha1operatorv1.Issuer{
 		Venafi: &cmapi.VenafiIssuer{
 			Zone: vc.Zone,
-			TPP: &cmapi.VenafiTPP{
-				URL: vc.URL,
-			},
 		},
 	}
 	iss.ClusterScope = issuer.ClusterScope
 	iss.Namespace = issuer.Namespace
 	iss.Name = issuer.Name
-	iss.Venafi.TPP.CredentialsRef = certmanagermetav1.LocalObjectReference{
-		Name: fmt.Sprintf(issuerSecretNameTemplate, iss.Name),
+	credentialsSecretName := fmt.Sprintf(issuerSecretNameTemplate, iss.Name)
+	if len(vc.CredentialsSecret) > 0 {
+		credentialsSecretName = vc.CredentialsSecret
 	}
 
 	// Generate Secret from the Venafi Connection associated with the issuer
@@ -147,21 +194,65 @@ func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1
 		namespace = clusterNamespace
 	}
 	secret.Namespace = namespace
-	name := iss.Venafi.TPP.CredentialsRef.Name
-	if issuer.Conn.ManagedByVOH {
-		name = fmt.Sprintf("%s-voh-bootstrap", iss.Venafi.TPP.CredentialsRef.Name)
-	}
-
-	secret.Name = name
+	secret.Name = credentialsSecretName
 
 	data := make(map[string][]byte)
-	if len(vc.AccessToken) > 0 {
-		data[accessTokenKey] = []byte(vc.AccessToken)
-	} else if len(vc.Password) > 0 && len(vc.Username) > 0 {
-		data[usernameKey] = []byte(vc.Username)
-		data[passwordKey] = []byte(vc.Password)
-	} else {
-		return nil, nil, fmt.Errorf(errMsgMissingConnectionCreds, vc.AccessToken, vc.Username, vc.Password)
+	switch issuer.IssuerType {
+	case vaasType:
+		switch {
+		case len(vc.CredentialsSecret) > 0:
+			// the existing Secret is expected to contain the API key
+		case len(vc.AccessToken) > 0 || len(vc.Username) > 0 || len(vc.Password) > 0:
+			return nil, nil, errors.New(errMsgUnexpectedTPPCreds)
+		case len(vc.APIKey) == 0:
+			return nil, nil, errors.New(errMsgMissingAPIKey)
+		}
+		if _, _, err := vaasZone(vc.Zone); err != nil {
+			return nil, nil, err
+		}
+
+		iss.Venafi.Cloud = &cmapi.VenafiCloud{
+			URL: vc.URL,
+			APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+				LocalObjectReference: certmanagermetav1.LocalObjectReference{
+					Name: credentialsSecretName,
+				},
+				Key: apiKeyKey,
+			},
+		}
+		data[apiKeyKey] = []byte(vc.APIKey)
+	default:
+		if len(vc.APIKey) > 0 {
+			return nil, nil, errors.New(errMsgUnexpectedAPIKey)
+		}
+
+		iss.Venafi.TPP = &cmapi.VenafiTPP{
+			URL: vc.URL,
+			CredentialsRef: certmanagermetav1.LocalObjectReference{
+				Name: credentialsSecretName,
+			},
+		}
+		if issuer.Conn.ManagedByVOH {
+			if len(vc.CredentialsSecret) > 0 {
+				return nil, nil, errors.New(errMsgCredentialsSecretWithVOH)
+			}
+			secret.Name = fmt.Sprintf("%s-voh-bootstrap", credentialsSecretName)
+		}
+
+		switch {
+		case len(vc.CredentialsSecret) > 0:
+			// the existing Secret is expected to contain an access token or a username and password
+		case len(vc.AccessToken) > 0:
+			data[accessTokenKey] = []byte(vc.AccessToken)
+		case len(vc.Password) > 0 && len(vc.Username) > 0:
+			data[usernameKey] = []byte(vc.Username)
+			data[passwordKey] = []byte(vc.Password)
+		default:
+			return nil, nil, missingTPPCredsError(vc)
+		}
+	}
+	if len(vc.CredentialsSecret) > 0 {
+		return iss, nil, nil
 	}
 	secret.Data = data
 	return iss, secret, nil
@@ -189,3 +280,37 @@ func GenerateManifestsForCertDiscoveryVenafi(vc *VenafiConnection) (*alpha1opera
 
 	return cdv, secret
 }
+
+// missingTPPCredsError reports which of a connection's TPP credentials are set, without including their values
+func missingTPPCredsError(vc *VenafiConnection) error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{accessTokenKey, vc.AccessToken},
+		{usernameKey, vc.Username},
+		{passwordKey, vc.Password},
+	}
+
+	presence := make([]string, len(fields))
+	for i, field := range fields {
+		if field.value == "" {
+			presence[i] = field.name + " missing"
+		} else {
+			presence[i] = field.name + " set"
+		}
+	}
+
+	return fmt.Errorf(errMsgMissingTPPCreds, strings.Join(presence, ", "))
+}
+
+// vaasZone splits a VaaS zone, written as '<application>\<issuing template alias>', into the application and the
+// issuing template alias.
+func vaasZone(zone string) (string, string, error) {
+	application, template, ok := strings.Cut(zone, `\`)
+	if !ok || application == "" || template == "" {
+		return "", "", errors.New(errMsgMissingVaaSZone)
+	}
+
+	return application, template, nil
+}
@@ -28,6 +28,10 @@ func TestParseIssuerConfig(t *testing.T) {
 			ManagedByVOH: false,
 		},
 	}
+	vaasConnection := &VenafiConnection{
+		Zone:   "app\\template",
+		APIKey: "foo",
+	}
 	tests := map[string]struct {
 		issuers         []string
 		vcs             map[string]*VenafiConnection
@@ -94,6 +98,32 @@ func TestParseIssuerConfig(t *testing.T) {
 				},
 			}},
 		},
+		"create a vaas issuer": {
+			issuers: []string{"vaas:cloud:foo:foo"},
+			vcs:     map[string]*VenafiConnection{"cloud": vaasConnection},
+			expectedIssuers: []*VenafiIssuer{&VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: vaasConnection,
+				},
+			}},
+		},
+		"do not record vaas issuer secrets as managed by voh": {
+			issuers:    []string{"vaas:cloud:foo"},
+			vcs:        map[string]*VenafiConnection{"cloud": vaasConnection},
+			vohEnabled: true,
+			expectedIssuers: []*VenafiIssuer{&VenafiIssuer{
+				IssuerType:   "vaas",
+				Name:         "foo",
+				ClusterScope: true,
+				Conn: &Conn{
+					VC:           vaasConnection,
+					ManagedByVOH: false,
+				},
+			}},
+		},
 		"record more than one issuer": {
 			issuers: []string{"tpp:default:foo:foo", "tpp:bar:bar"},
 			vcs: map[string]*VenafiConnection{"default": baseConnection,
@@ -280,7 +310,7 @@ func TestGenerateOperatorManifestsForIssuer(t *testing.T) {
 					},
 				},
 			},
-			expectedErr: fmt.Sprintf(errMsgMissingConnectionCreds, "", "foo", ""),
+			expectedErr: "missing credentials: expected either Venafi access token or username and password: got access-token missing, username set, password missing",
 		},
 		"create a Namespaced issuer and a Secret with an access token": {
 			issuerTemplate: &VenafiIssuer{
@@ -320,6 +350,221 @@ func TestGenerateOperatorManifestsForIssuer(t *testing.T) {
 				Data: map[string][]byte{accessTokenKey: []byte("foo")},
 			},
 		},
+		"create a Namespaced vaas issuer and a Secret with an api key": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						URL:    "https://api.venafi.eu/v1",
+						Zone:   "app\\template",
+						APIKey: "foo",
+					},
+				},
+			},
+			expectedIssuer: &operatorv1alpha1.Issuer{
+				Name:      "foo",
+				Namespace: "foo",
+				Venafi: &cmapi.VenafiIssuer{
+					Zone: "app\\template",
+					Cloud: &cmapi.VenafiCloud{
+						URL: "https://api.venafi.eu/v1",
+						APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+							LocalObjectReference: certmanagermetav1.LocalObjectReference{
+								Name: "foo-jsctl",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-jsctl",
+					Namespace: "foo",
+				},
+				Data: map[string][]byte{apiKeyKey: []byte("foo")},
+			},
+		},
+		"create a cluster scoped vaas issuer using the default tenant URL": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType:   "vaas",
+				Name:         "foo",
+				ClusterScope: true,
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						Zone:   "app\\template",
+						APIKey: "foo",
+					},
+				},
+			},
+			expectedIssuer: &operatorv1alpha1.Issuer{
+				Name:         "foo",
+				ClusterScope: true,
+				Venafi: &cmapi.VenafiIssuer{
+					Zone: "app\\template",
+					Cloud: &cmapi.VenafiCloud{
+						APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+							LocalObjectReference: certmanagermetav1.LocalObjectReference{
+								Name: "foo-jsctl",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-jsctl",
+					Namespace: "jetstack-secure",
+				},
+				Data: map[string][]byte{apiKeyKey: []byte("foo")},
+			},
+		},
+		"error out if a vaas connection has no api key": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						Zone: "app\\template",
+					},
+				},
+			},
+			expectedErr: errMsgMissingAPIKey,
+		},
+		"error out if a vaas connection has TPP credentials": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn:       baseConn,
+			},
+			expectedErr: errMsgUnexpectedTPPCreds,
+		},
+		"error out if a vaas connection has no zone": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						APIKey: "foo",
+					},
+				},
+			},
+			expectedErr: errMsgMissingVaaSZone,
+		},
+		"error out if a vaas zone has no issuing template alias": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						Zone:   "foo",
+						APIKey: "foo",
+					},
+				},
+			},
+			expectedErr: errMsgMissingVaaSZone,
+		},
+		"error out if a tpp connection has an api key": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "tpp",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						URL:    "foo",
+						Zone:   "foo",
+						APIKey: "foo",
+					},
+				},
+			},
+			expectedErr: errMsgUnexpectedAPIKey,
+		},
+		"reference an existing Secret instead of generating one": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "tpp",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						URL:               "foo",
+						Zone:              "foo",
+						CredentialsSecret: "tpp-credentials",
+					},
+				},
+			},
+			expectedIssuer: &operatorv1alpha1.Issuer{
+				Name:      "foo",
+				Namespace: "foo",
+				Venafi: &cmapi.VenafiIssuer{
+					Zone: "foo",
+					TPP: &cmapi.VenafiTPP{
+						URL: "foo",
+						CredentialsRef: certmanagermetav1.LocalObjectReference{
+							Name: "tpp-credentials",
+						},
+					},
+				},
+			},
+		},
+		"reference an existing Secret with an api key for a vaas issuer": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType:   "vaas",
+				Name:         "foo",
+				ClusterScope: true,
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						Zone:              "app\\template",
+						CredentialsSecret: "vaas-credentials",
+					},
+				},
+			},
+			expectedIssuer: &operatorv1alpha1.Issuer{
+				Name:         "foo",
+				ClusterScope: true,
+				Venafi: &cmapi.VenafiIssuer{
+					Zone: "app\\template",
+					Cloud: &cmapi.VenafiCloud{
+						APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+							LocalObjectReference: certmanagermetav1.LocalObjectReference{
+								Name: "vaas-credentials",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			},
+		},
+		"error out if an existing Secret is used with venafi-oauth-helper": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "tpp",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{
+						URL:               "foo",
+						Zone:              "foo",
+						CredentialsSecret: "tpp-credentials",
+					},
+					ManagedByVOH: true,
+				},
+			},
+			expectedErr: errMsgCredentialsSecretWithVOH,
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {